@@ -0,0 +1,233 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/sprucehealth/twimulator/model"
+)
+
+const (
+	// MachineDetectionEnable returns as soon as a human or machine is recognized
+	MachineDetectionEnable = "Enable"
+	// MachineDetectionDetectMessageEnd waits for the end of a machine greeting
+	MachineDetectionDetectMessageEnd = "DetectMessageEnd"
+
+	defaultMachineDetectionTimeout = 30 * time.Second
+)
+
+// WithMachineDetectionTime sets how long answering machine detection takes to
+// recognize an outcome chosen with SetAnsweredBy before the call was answered.
+// It is capped at the call's MachineDetectionTimeout. By default the outcome is
+// recognized as soon as the call is answered.
+func WithMachineDetectionTime(d time.Duration) EngineOption {
+	return func(e *EngineImpl) {
+		e.machineDetectionTime = d
+	}
+}
+
+// answeredByValues lists the AnsweredBy outcomes Twilio reports for each mode
+var answeredByValues = map[string][]string{
+	MachineDetectionEnable:           {"human", "machine_start", "fax", "unknown"},
+	MachineDetectionDetectMessageEnd: {"human", "machine_end_beep", "machine_end_silence", "machine_end_other", "fax", "unknown"},
+}
+
+// amdConfig holds the answering machine detection settings of an outbound call
+type amdConfig struct {
+	mode           string
+	timeout        time.Duration
+	async          bool
+	callback       string
+	callbackMethod string
+}
+
+// newAMDConfig validates the machine detection parameters of CreateCall.
+// It returns nil when machine detection is not requested.
+func newAMDConfig(mode *string, timeoutSeconds *int, asyncAmd, callback, callbackMethod *string) (*amdConfig, error) {
+	if mode == nil || *mode == "" {
+		return nil, nil
+	}
+	if _, ok := answeredByValues[*mode]; !ok {
+		return nil, fmt.Errorf("invalid MachineDetection %q: must be Enable or DetectMessageEnd", *mode)
+	}
+	cfg := &amdConfig{
+		mode:           *mode,
+		timeout:        defaultMachineDetectionTimeout,
+		callbackMethod: "POST",
+	}
+	if timeoutSeconds != nil {
+		if *timeoutSeconds < 3 || *timeoutSeconds > 59 {
+			return nil, fmt.Errorf("MachineDetectionTimeout must be between 3 and 59 seconds")
+		}
+		cfg.timeout = time.Duration(*timeoutSeconds) * time.Second
+	}
+	if asyncAmd != nil && *asyncAmd != "" {
+		async, err := strconv.ParseBool(*asyncAmd)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AsyncAmd %q", *asyncAmd)
+		}
+		cfg.async = async
+	}
+	if callback != nil {
+		cfg.callback = *callback
+	}
+	if callbackMethod != nil && *callbackMethod != "" {
+		cfg.callbackMethod = *callbackMethod
+	}
+	return cfg, nil
+}
+
+func validAnsweredBy(mode, answeredBy string) bool {
+	for _, v := range answeredByValues[mode] {
+		if v == answeredBy {
+			return true
+		}
+	}
+	return false
+}
+
+// SetAnsweredBy chooses the outcome of answering machine detection for a call.
+// It can be called before the call is answered or while detection is running.
+func (e *EngineImpl) SetAnsweredBy(subaccountSID, callSID model.SID, answeredBy string) error {
+	// Get subaccount state
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[subaccountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return notFoundError(subaccountSID)
+	}
+
+	state.mu.Lock()
+	call, exists := state.calls[callSID]
+	if !exists {
+		state.mu.Unlock()
+		return notFoundError(callSID)
+	}
+	runner := state.runners[callSID]
+	if runner == nil {
+		state.mu.Unlock()
+		return notFoundError(callSID)
+	}
+	if runner.amd == nil {
+		state.mu.Unlock()
+		return fmt.Errorf("machine detection is not enabled for call %s", callSID)
+	}
+	if !validAnsweredBy(runner.amd.mode, answeredBy) {
+		state.mu.Unlock()
+		return fmt.Errorf("invalid AnsweredBy %q for MachineDetection=%s", answeredBy, runner.amd.mode)
+	}
+	e.addCallEventLocked(state, call, "call.answered_by_set", map[string]any{
+		"call_sid":    callSID,
+		"answered_by": answeredBy,
+	})
+	state.mu.Unlock()
+
+	if !notify(e, runner.answeredByCh, answeredBy) {
+		return fmt.Errorf("call %s already has a pending AnsweredBy", callSID)
+	}
+	return nil
+}
+
+// detectMachine waits for the simulated detection outcome, falling back to
+// "unknown" after MachineDetectionTimeout. It returns false if the call was
+// hung up first.
+func (r *CallRunner) detectMachine(ctx context.Context) (string, time.Duration, bool) {
+	start := r.clock.Now()
+	r.addCallEvent("amd.started", map[string]any{
+		"mode":    r.amd.mode,
+		"timeout": r.amd.timeout.Seconds(),
+		"async":   r.amd.async,
+	})
+
+	var answeredBy string
+	select {
+	case answeredBy = <-r.answeredByCh:
+		// An outcome chosen before the call was answered is recognized after
+		// the configured detection time. Without one it is recognized right
+		// away, even if the call hangs up immediately afterwards.
+		if d := min(r.engine.machineDetectionTime, r.amd.timeout); d > 0 {
+			detected, stop := r.engine.after(r.clock, d)
+			defer stop()
+			r.engine.park(r.hangupCh, detected)
+			select {
+			case <-ctx.Done():
+				return "", 0, false
+			case <-r.hangupCh:
+				r.addCallEvent("amd.interrupted", map[string]any{"reason": "hangup"})
+				return "", 0, false
+			case <-detected:
+			}
+		}
+	default:
+		timeout, stop := r.engine.after(r.clock, r.amd.timeout)
+		defer stop()
+		r.engine.park(r.hangupCh, r.answeredByCh, timeout)
+		select {
+		case <-ctx.Done():
+			return "", 0, false
+		case <-r.hangupCh:
+			r.addCallEvent("amd.interrupted", map[string]any{"reason": "hangup"})
+			return "", 0, false
+		case answeredBy = <-r.answeredByCh:
+		case <-timeout:
+			answeredBy = "unknown"
+		}
+	}
+	duration := r.clock.Now().Sub(start)
+
+	r.state.mu.Lock()
+	r.call.AnsweredBy = answeredBy
+	r.state.mu.Unlock()
+	r.addCallEvent("amd.completed", map[string]any{
+		"answered_by": answeredBy,
+		"duration_ms": duration.Milliseconds(),
+	})
+	return answeredBy, duration, true
+}
+
+// runAsyncAMD runs detection alongside TwiML execution and reports the result
+// to AsyncAmdStatusCallback
+func (r *CallRunner) runAsyncAMD(ctx context.Context, currentURL string) {
+	answeredBy, duration, ok := r.detectMachine(ctx)
+	if !ok || r.amd.callback == "" {
+		return
+	}
+
+	resolvedURL, err := resolveURL(currentURL, r.amd.callback)
+	if err != nil {
+		r.addCallEvent("amd.callback_error", map[string]any{
+			"url":   r.amd.callback,
+			"error": err.Error(),
+		})
+		r.recordError(err)
+		return
+	}
+
+	form := url.Values{}
+	form.Set("CallSid", string(r.call.SID))
+	form.Set("AccountSid", string(r.call.AccountSID))
+	form.Set("AnsweredBy", answeredBy)
+	form.Set("MachineDetectionDuration", strconv.FormatInt(duration.Milliseconds(), 10))
+
+	err = r.postCallback(ctx, r.amd.callbackMethod, resolvedURL, form)
+	if err != nil {
+		r.addCallEvent("amd.callback_error", map[string]any{
+			"url":   resolvedURL,
+			"error": err.Error(),
+		})
+		r.recordError(err)
+		return
+	}
+	r.addCallEvent("amd.callback", map[string]any{
+		"url":         resolvedURL,
+		"answered_by": answeredBy,
+	})
+}
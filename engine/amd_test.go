@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"testing"
+	"time"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+
+	"github.com/sprucehealth/twimulator/engine"
+)
+
+func TestMachineDetectionSync(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, nil)
+
+	params := newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/voice")
+	params.SetMachineDetection("DetectMessageEnd")
+	call := mustCreateCall(t, e, params)
+
+	settle(t, e)
+	if err := e.AnswerCall(subAccount.SID, call.SID); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	e.Advance(4 * time.Second)
+	settle(t, e)
+
+	if len(mock.GetCallsTo("http://test/voice")) != 0 {
+		t.Fatal("voice URL should not be fetched until detection completes")
+	}
+
+	if err := e.SetAnsweredBy(subAccount.SID, call.SID, "machine_end_beep"); err != nil {
+		t.Fatalf("SetAnsweredBy failed: %v", err)
+	}
+	settle(t, e)
+
+	calls := mock.GetCallsTo("http://test/voice")
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 voice fetch, got %d", len(calls))
+	}
+	if got := calls[0].Form.Get("AnsweredBy"); got != "machine_end_beep" {
+		t.Errorf("expected AnsweredBy machine_end_beep, got %q", got)
+	}
+	if got := calls[0].Form.Get("MachineDetectionDuration"); got != "4000" {
+		t.Errorf("expected MachineDetectionDuration 4000, got %q", got)
+	}
+
+	apiCall, err := e.FetchCall(string(call.SID), (&twilioopenapi.FetchCallParams{}).SetPathAccountSid(string(subAccount.SID)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if apiCall.AnsweredBy == nil || *apiCall.AnsweredBy != "machine_end_beep" {
+		t.Errorf("expected API AnsweredBy machine_end_beep, got %v", apiCall.AnsweredBy)
+	}
+}
+
+func TestMachineDetectionTimeout(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, nil)
+
+	params := newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/voice")
+	params.SetMachineDetection("Enable")
+	params.SetMachineDetectionTimeout(5)
+	call := mustCreateCall(t, e, params)
+
+	settle(t, e)
+	if err := e.AnswerCall(subAccount.SID, call.SID); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	e.Advance(5 * time.Second)
+	settle(t, e)
+
+	calls := mock.GetCallsTo("http://test/voice")
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 voice fetch, got %d", len(calls))
+	}
+	if got := calls[0].Form.Get("AnsweredBy"); got != "unknown" {
+		t.Errorf("expected AnsweredBy unknown, got %q", got)
+	}
+	if got := calls[0].Form.Get("MachineDetectionDuration"); got != "5000" {
+		t.Errorf("expected MachineDetectionDuration 5000, got %q", got)
+	}
+}
+
+func TestMachineDetectionAsync(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, nil)
+
+	params := newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/voice")
+	params.SetMachineDetection("Enable")
+	params.SetAsyncAmd("true")
+	params.SetAsyncAmdStatusCallback("/amd")
+	call := mustCreateCall(t, e, params)
+
+	// The outcome may be chosen before the call is answered
+	if err := e.SetAnsweredBy(subAccount.SID, call.SID, "human"); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	if err := e.AnswerCall(subAccount.SID, call.SID); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	voice := mock.GetCallsTo("http://test/voice")
+	if len(voice) != 1 {
+		t.Fatalf("expected voice URL to be fetched immediately, got %d fetches", len(voice))
+	}
+	amd := mock.GetCallsTo("http://test/amd")
+	if len(amd) != 1 {
+		t.Fatalf("expected 1 async AMD callback, got %d", len(amd))
+	}
+	if got := amd[0].Form.Get("AnsweredBy"); got != "human" {
+		t.Errorf("expected AnsweredBy human, got %q", got)
+	}
+	if got := amd[0].Form.Get("MachineDetectionDuration"); got != "0" {
+		t.Errorf("expected MachineDetectionDuration 0, got %q", got)
+	}
+}
+
+func TestMachineDetectionTime(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, nil, engine.WithMachineDetectionTime(2*time.Second))
+
+	params := newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/voice")
+	params.SetMachineDetection("Enable")
+	call := mustCreateCall(t, e, params)
+	if err := e.SetAnsweredBy(subAccount.SID, call.SID, "human"); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	if err := e.AnswerCall(subAccount.SID, call.SID); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	// The chosen outcome is recognized after the detection time
+	if n := len(mock.GetCallsTo("http://test/voice")); n != 0 {
+		t.Fatalf("expected the voice URL to wait for detection, got %d fetches", n)
+	}
+	e.Advance(2 * time.Second)
+	settle(t, e)
+
+	voice := mock.GetCallsTo("http://test/voice")
+	if len(voice) != 1 {
+		t.Fatalf("expected 1 voice URL fetch, got %d", len(voice))
+	}
+	if got := voice[0].Form.Get("AnsweredBy"); got != "human" {
+		t.Errorf("expected AnsweredBy human, got %q", got)
+	}
+	if got := voice[0].Form.Get("MachineDetectionDuration"); got != "2000" {
+		t.Errorf("expected MachineDetectionDuration 2000, got %q", got)
+	}
+}
+
+func TestMachineDetectionValidation(t *testing.T) {
+	e, _, subAccount := newTestEngine(t, nil)
+
+	params := newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/voice")
+	params.SetMachineDetection("Sometimes")
+	if _, err := e.CreateCall(params); err == nil {
+		t.Error("expected error for invalid MachineDetection")
+	}
+
+	params = newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/voice")
+	params.SetMachineDetection("Enable")
+	params.SetMachineDetectionTimeout(90)
+	if _, err := e.CreateCall(params); err == nil {
+		t.Error("expected error for out of range MachineDetectionTimeout")
+	}
+
+	params = newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/voice")
+	params.SetMachineDetection("Enable")
+	call := mustCreateCall(t, e, params)
+	if err := e.SetAnsweredBy(subAccount.SID, call.SID, "machine_end_beep"); err == nil {
+		t.Error("expected error for machine_end_beep with MachineDetection=Enable")
+	}
+
+	plain := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/voice"))
+	if err := e.SetAnsweredBy(subAccount.SID, plain.SID, "human"); err == nil {
+		t.Error("expected error when machine detection is not enabled")
+	}
+}
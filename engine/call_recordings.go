@@ -0,0 +1,516 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+
+	"github.com/sprucehealth/twimulator/model"
+)
+
+const (
+	// RecordingInProgress is the status of a call recording that is capturing audio
+	RecordingInProgress = "in-progress"
+	// RecordingPaused is the status of a call recording that has been paused
+	RecordingPaused = "paused"
+	// RecordingStopped ends a call recording through UpdateCallRecording
+	RecordingStopped = "stopped"
+	// RecordingCompleted is the status of a call recording that has ended
+	RecordingCompleted = "completed"
+
+	// PauseBehaviorSkip leaves paused spans out of the recording
+	PauseBehaviorSkip = "skip"
+	// PauseBehaviorSilence records paused spans as silence
+	PauseBehaviorSilence = "silence"
+)
+
+// recordingConfig holds the channels and status callback of a call recording
+type recordingConfig struct {
+	channels       int
+	callback       string
+	callbackMethod string
+	callbackEvents []string
+}
+
+// newRecordingConfig validates the recording parameters of CreateCall and
+// CreateCallRecording
+func newRecordingConfig(channels, callback, callbackMethod *string, callbackEvents *[]string) (*recordingConfig, error) {
+	cfg := &recordingConfig{
+		channels:       1,
+		callbackMethod: "POST",
+		callbackEvents: []string{RecordingCompleted},
+	}
+	if channels != nil && *channels != "" {
+		switch *channels {
+		case "mono":
+			cfg.channels = 1
+		case "dual":
+			cfg.channels = 2
+		default:
+			return nil, fmt.Errorf("invalid RecordingChannels %q: must be mono or dual", *channels)
+		}
+	}
+	if callback != nil {
+		cfg.callback = *callback
+	}
+	if callbackMethod != nil && *callbackMethod != "" {
+		cfg.callbackMethod = *callbackMethod
+	}
+	if callbackEvents != nil && len(*callbackEvents) > 0 {
+		for _, event := range *callbackEvents {
+			switch event {
+			case RecordingInProgress, RecordingCompleted, "absent":
+			default:
+				return nil, fmt.Errorf("invalid RecordingStatusCallbackEvent %q", event)
+			}
+		}
+		cfg.callbackEvents = *callbackEvents
+	}
+	return cfg, nil
+}
+
+// callRecording tracks a recording of a call started with CreateCall Record=true
+// or CreateCallRecording until it is stopped or the call ends
+type callRecording struct {
+	*recordingConfig
+	recording *model.Recording
+
+	pausedAt      time.Time // zero unless paused
+	pauseBehavior string
+	skipped       time.Duration // paused time left out of the recording
+}
+
+// duration returns the length of the recording at now, excluding skipped pauses
+func (c *callRecording) duration(now time.Time) int {
+	d := now.Sub(c.recording.CreatedAt) - c.skipped
+	if !c.pausedAt.IsZero() && c.pauseBehavior == PauseBehaviorSkip {
+		d -= now.Sub(c.pausedAt)
+	}
+	return int(d.Seconds())
+}
+
+// startCallRecordingLocked starts recording an in-progress call. state.mu must be held.
+func (e *EngineImpl) startCallRecordingLocked(state *subAccountState, call *model.Call, cfg *recordingConfig, source string) *callRecording {
+	callSID := call.SID
+	recording := &model.Recording{
+		SID:        e.ids.RecordingSID(),
+		AccountSID: call.AccountSID,
+		CallSID:    &callSID,
+		Status:     RecordingInProgress,
+		Channels:   cfg.channels,
+		Source:     source,
+		CreatedAt:  state.clock.Now(),
+	}
+	state.recordings[recording.SID] = recording
+	active := &callRecording{recordingConfig: cfg, recording: recording}
+	state.activeRecordings[recording.SID] = active
+
+	e.addCallEventLocked(state, call, "recording.started", map[string]any{
+		"recording_sid": recording.SID,
+		"source":        source,
+		"channels":      cfg.channels,
+	})
+	e.queueRecordingStatusCallbackLocked(state, call, active)
+	return active
+}
+
+// stopCallRecordingLocked completes an active call recording. state.mu must be held.
+func (e *EngineImpl) stopCallRecordingLocked(state *subAccountState, call *model.Call, active *callRecording) {
+	recording := active.recording
+	recording.Duration = active.duration(state.clock.Now())
+	recording.Status = RecordingCompleted
+	delete(state.activeRecordings, recording.SID)
+
+	e.addCallEventLocked(state, call, "recording.completed", map[string]any{
+		"recording_sid":      recording.SID,
+		"recording_duration": recording.Duration,
+	})
+	e.queueRecordingStatusCallbackLocked(state, call, active)
+}
+
+// endCallRecordingsLocked completes every recording of a call that has ended. state.mu must be held.
+func (e *EngineImpl) endCallRecordingsLocked(state *subAccountState, call *model.Call) {
+	delete(state.recordOnAnswer, call.SID)
+	var ended []*callRecording
+	for _, active := range state.activeRecordings {
+		if *active.recording.CallSID == call.SID {
+			ended = append(ended, active)
+		}
+	}
+	sort.Slice(ended, func(i, j int) bool {
+		return ended[i].recording.SID < ended[j].recording.SID
+	})
+	for _, active := range ended {
+		e.stopCallRecordingLocked(state, call, active)
+	}
+}
+
+// queueRecordingStatusCallbackLocked queues the recordingStatusCallback for the
+// current status of a call recording if it is subscribed. state.mu must be held.
+func (e *EngineImpl) queueRecordingStatusCallbackLocked(state *subAccountState, call *model.Call, active *callRecording) {
+	recording := active.recording
+	if active.callback == "" || !slices.Contains(active.callbackEvents, recording.Status) {
+		return
+	}
+
+	callbackURL := active.callback
+	method := active.callbackMethod
+	form := url.Values{}
+	form.Set("AccountSid", string(recording.AccountSID))
+	form.Set("CallSid", string(call.SID))
+	form.Set("RecordingSid", string(recording.SID))
+	form.Set("RecordingUrl", e.recordingURL(recording.AccountSID, recording.SID))
+	form.Set("RecordingStatus", recording.Status)
+	form.Set("RecordingChannels", strconv.Itoa(recording.Channels))
+	form.Set("RecordingSource", recording.Source)
+	form.Set("RecordingStartTime", recording.CreatedAt.UTC().Format(time.RFC1123Z))
+	if recording.Status != RecordingInProgress {
+		form.Set("RecordingDuration", strconv.Itoa(recording.Duration))
+	}
+
+	e.enqueue(call.CallbackQueue, func() {
+		if err := e.postCallback(e.callRequestContext(e.ctx, state, call), state, method, callbackURL, form); err != nil {
+			e.addCallEvent(state, call, "webhook.recording_status_callback.error", map[string]any{
+				"url":   callbackURL,
+				"error": err.Error(),
+			})
+			e.recordError(state, err)
+			return
+		}
+		e.addCallEvent(state, call, "webhook.recording_status_callback", map[string]any{
+			"url":              callbackURL,
+			"recording_status": form.Get("RecordingStatus"),
+		})
+	})
+}
+
+// CreateCallRecording starts recording an in-progress call
+func (e *EngineImpl) CreateCallRecording(callSid string, params *twilioopenapi.CreateCallRecordingParams) (*twilioopenapi.ApiV2010CallRecording, error) {
+	if params == nil || params.PathAccountSid == nil || *params.PathAccountSid == "" {
+		return nil, fmt.Errorf("PathAccountSid is required")
+	}
+	accountSID := model.SID(*params.PathAccountSid)
+
+	cfg, err := newRecordingConfig(params.RecordingChannels, params.RecordingStatusCallback, params.RecordingStatusCallbackMethod, params.RecordingStatusCallbackEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[accountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return nil, notFoundError(accountSID)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	call, exists := state.calls[model.SID(callSid)]
+	if !exists {
+		return nil, notFoundError(model.SID(callSid))
+	}
+	if call.Status != model.CallInProgress {
+		return nil, fmt.Errorf("call %s is not in progress", callSid)
+	}
+
+	active := e.startCallRecordingLocked(state, call, cfg, "StartCallRecordingAPI")
+	return e.buildAPICallRecordingResponse(state, active.recording), nil
+}
+
+// UpdateCallRecording pauses, resumes or stops a call recording
+func (e *EngineImpl) UpdateCallRecording(callSid string, sid string, params *twilioopenapi.UpdateCallRecordingParams) (*twilioopenapi.ApiV2010CallRecording, error) {
+	if params == nil || params.PathAccountSid == nil || *params.PathAccountSid == "" {
+		return nil, fmt.Errorf("PathAccountSid is required")
+	}
+	accountSID := model.SID(*params.PathAccountSid)
+	if params.Status == nil || *params.Status == "" {
+		return nil, fmt.Errorf("Status is required")
+	}
+	status := *params.Status
+	if status != RecordingPaused && status != RecordingInProgress && status != RecordingStopped {
+		return nil, fmt.Errorf("invalid Status %q: must be paused, in-progress or stopped", status)
+	}
+	pauseBehavior := PauseBehaviorSkip
+	if params.PauseBehavior != nil && *params.PauseBehavior != "" {
+		pauseBehavior = *params.PauseBehavior
+		if pauseBehavior != PauseBehaviorSkip && pauseBehavior != PauseBehaviorSilence {
+			return nil, fmt.Errorf("invalid PauseBehavior %q: must be skip or silence", pauseBehavior)
+		}
+	}
+
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[accountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return nil, notFoundError(accountSID)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	call, exists := state.calls[model.SID(callSid)]
+	if !exists {
+		return nil, notFoundError(model.SID(callSid))
+	}
+	recording, exists := state.recordings[model.SID(sid)]
+	if !exists || recording.CallSID == nil || *recording.CallSID != call.SID {
+		return nil, notFoundError(model.SID(sid))
+	}
+	active, exists := state.activeRecordings[recording.SID]
+	if !exists {
+		return nil, fmt.Errorf("recording %s is not in progress", sid)
+	}
+
+	now := state.clock.Now()
+	switch status {
+	case RecordingPaused:
+		if recording.Status == RecordingPaused {
+			break
+		}
+		active.pausedAt = now
+		active.pauseBehavior = pauseBehavior
+		recording.Status = RecordingPaused
+		recording.Duration = active.duration(now)
+		e.addCallEventLocked(state, call, "recording.paused", map[string]any{
+			"recording_sid":  recording.SID,
+			"pause_behavior": pauseBehavior,
+		})
+	case RecordingInProgress:
+		if recording.Status == RecordingInProgress {
+			break
+		}
+		if active.pauseBehavior == PauseBehaviorSkip {
+			active.skipped += now.Sub(active.pausedAt)
+		}
+		active.pausedAt = time.Time{}
+		recording.Status = RecordingInProgress
+		recording.Duration = active.duration(now)
+		e.addCallEventLocked(state, call, "recording.resumed", map[string]any{
+			"recording_sid": recording.SID,
+		})
+	case RecordingStopped:
+		e.stopCallRecordingLocked(state, call, active)
+	}
+
+	return e.buildAPICallRecordingResponse(state, recording), nil
+}
+
+// ListCallRecording returns the recordings of a call, newest first
+func (e *EngineImpl) ListCallRecording(callSid string, params *twilioopenapi.ListCallRecordingParams) ([]twilioopenapi.ApiV2010CallRecording, error) {
+	if params == nil || params.PathAccountSid == nil || *params.PathAccountSid == "" {
+		return nil, fmt.Errorf("PathAccountSid is required")
+	}
+	accountSID := model.SID(*params.PathAccountSid)
+
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[accountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return nil, notFoundError(accountSID)
+	}
+
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	if _, exists := state.calls[model.SID(callSid)]; !exists {
+		return nil, notFoundError(model.SID(callSid))
+	}
+
+	result := []twilioopenapi.ApiV2010CallRecording{}
+	for _, recording := range sortedRecordingsLocked(state) {
+		if recording.CallSID == nil || string(*recording.CallSID) != callSid {
+			continue
+		}
+		if params.Limit != nil && len(result) >= *params.Limit {
+			break
+		}
+		result = append(result, *e.buildAPICallRecordingResponse(state, recording))
+	}
+	return result, nil
+}
+
+// ListRecording returns the recordings of an account, newest first
+func (e *EngineImpl) ListRecording(params *twilioopenapi.ListRecordingParams) ([]twilioopenapi.ApiV2010Recording, error) {
+	if params == nil || params.PathAccountSid == nil || *params.PathAccountSid == "" {
+		return nil, fmt.Errorf("PathAccountSid is required")
+	}
+	accountSID := model.SID(*params.PathAccountSid)
+
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[accountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return nil, notFoundError(accountSID)
+	}
+
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	result := []twilioopenapi.ApiV2010Recording{}
+	for _, recording := range sortedRecordingsLocked(state) {
+		if params.CallSid != nil && *params.CallSid != "" && (recording.CallSID == nil || string(*recording.CallSID) != *params.CallSid) {
+			continue
+		}
+		created := recording.CreatedAt.UTC()
+		if params.DateCreated != nil && created.Format(time.DateOnly) != params.DateCreated.UTC().Format(time.DateOnly) {
+			continue
+		}
+		if params.DateCreatedBefore != nil && !created.Before(*params.DateCreatedBefore) {
+			continue
+		}
+		if params.DateCreatedAfter != nil && !created.After(*params.DateCreatedAfter) {
+			continue
+		}
+		if params.Limit != nil && len(result) >= *params.Limit {
+			break
+		}
+		result = append(result, *e.buildAPIRecordingResponse(state, recording))
+	}
+	return result, nil
+}
+
+// DeleteRecording deletes a recording that is no longer in progress
+func (e *EngineImpl) DeleteRecording(sid string, params *twilioopenapi.DeleteRecordingParams) error {
+	if params == nil || params.PathAccountSid == nil || *params.PathAccountSid == "" {
+		return fmt.Errorf("PathAccountSid is required")
+	}
+	accountSID := model.SID(*params.PathAccountSid)
+
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[accountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return notFoundError(accountSID)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	recordingSID := model.SID(sid)
+	if _, exists := state.recordings[recordingSID]; !exists {
+		return notFoundError(recordingSID)
+	}
+	if _, active := state.activeRecordings[recordingSID]; active {
+		return fmt.Errorf("recording %s is in progress and cannot be deleted", sid)
+	}
+	delete(state.recordings, recordingSID)
+	for callSID, sid := range state.callRecordings {
+		if sid == recordingSID {
+			delete(state.callRecordings, callSID)
+		}
+	}
+	for callSID, sid := range state.callVoicemails {
+		if sid == recordingSID {
+			delete(state.callVoicemails, callSID)
+		}
+	}
+	return nil
+}
+
+// sortedRecordingsLocked returns the recordings of an account, newest first. state.mu must be held.
+func sortedRecordingsLocked(state *subAccountState) []*model.Recording {
+	recordings := make([]*model.Recording, 0, len(state.recordings))
+	for _, recording := range state.recordings {
+		recordings = append(recordings, recording)
+	}
+	sort.Slice(recordings, func(i, j int) bool {
+		if recordings[i].CreatedAt.Equal(recordings[j].CreatedAt) {
+			return recordings[i].SID > recordings[j].SID
+		}
+		return recordings[i].CreatedAt.After(recordings[j].CreatedAt)
+	})
+	return recordings
+}
+
+// recordingDurationLocked returns the duration of a recording, measured up to
+// now if it is still being recorded. state.mu must be held.
+func recordingDurationLocked(state *subAccountState, recording *model.Recording) int {
+	if active, ok := state.activeRecordings[recording.SID]; ok {
+		return active.duration(state.clock.Now())
+	}
+	return recording.Duration
+}
+
+// buildAPIRecordingResponse converts a recording to the Twilio API representation. state.mu must be held.
+func (e *EngineImpl) buildAPIRecordingResponse(state *subAccountState, recording *model.Recording) *twilioopenapi.ApiV2010Recording {
+	sidStr := string(recording.SID)
+	accountSIDStr := string(recording.AccountSID)
+	apiVersion := e.apiVersion
+	status := recording.Status
+	duration := strconv.Itoa(recordingDurationLocked(state, recording))
+	dateCreated := recording.CreatedAt.UTC().Format(time.RFC1123Z)
+
+	resp := &twilioopenapi.ApiV2010Recording{
+		Sid:         &sidStr,
+		AccountSid:  &accountSIDStr,
+		ApiVersion:  &apiVersion,
+		Status:      &status,
+		Duration:    &duration,
+		DateCreated: &dateCreated,
+		StartTime:   &dateCreated,
+	}
+	if recording.CallSID != nil {
+		callSIDStr := string(*recording.CallSID)
+		resp.CallSid = &callSIDStr
+	}
+	if recording.Channels != 0 {
+		channels := recording.Channels
+		resp.Channels = &channels
+	}
+	if recording.Source != "" {
+		source := recording.Source
+		resp.Source = &source
+	}
+	if e.baseURL != "" {
+		uri := fmt.Sprintf("%s/Accounts/%s/Recordings/%s", e.baseURL, recording.AccountSID, recording.SID)
+		resp.Uri = &uri
+	}
+	return resp
+}
+
+// buildAPICallRecordingResponse converts a recording to the Twilio API
+// representation of a call recording. state.mu must be held.
+func (e *EngineImpl) buildAPICallRecordingResponse(state *subAccountState, recording *model.Recording) *twilioopenapi.ApiV2010CallRecording {
+	sidStr := string(recording.SID)
+	accountSIDStr := string(recording.AccountSID)
+	callSIDStr := string(*recording.CallSID)
+	apiVersion := e.apiVersion
+	status := recording.Status
+	duration := strconv.Itoa(recordingDurationLocked(state, recording))
+	dateCreated := recording.CreatedAt.UTC().Format(time.RFC1123Z)
+	uri := fmt.Sprintf("/2010-04-01/Accounts/%s/Calls/%s/Recordings/%s.json", recording.AccountSID, callSIDStr, recording.SID)
+
+	resp := &twilioopenapi.ApiV2010CallRecording{
+		Sid:         &sidStr,
+		AccountSid:  &accountSIDStr,
+		CallSid:     &callSIDStr,
+		ApiVersion:  &apiVersion,
+		Status:      &status,
+		Duration:    &duration,
+		DateCreated: &dateCreated,
+		DateUpdated: &dateCreated,
+		StartTime:   &dateCreated,
+		Uri:         &uri,
+		Channels:    max(recording.Channels, 1),
+	}
+	if recording.Source != "" {
+		source := recording.Source
+		resp.Source = &source
+	}
+	return resp
+}
@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"testing"
+	"time"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+
+	"github.com/sprucehealth/twimulator/model"
+)
+
+// holdRoutes keep answered calls waiting on a long gather
+var holdRoutes = map[string]string{
+	"http://test/answer": `<Response><Gather timeout="600"></Gather></Response>`,
+}
+
+func TestCreateCallRecord(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, holdRoutes)
+	params := newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/answer").
+		SetRecord(true).
+		SetRecordingChannels("dual").
+		SetRecordingStatusCallback("http://test/rec-status").
+		SetRecordingStatusCallbackEvent([]string{"in-progress", "completed"})
+	call := mustCreateCall(t, e, params)
+
+	settle(t, e)
+	accountSID := string(subAccount.SID)
+	recordings, err := e.ListCallRecording(string(call.SID), &twilioopenapi.ListCallRecordingParams{PathAccountSid: &accountSID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recordings) != 0 {
+		t.Fatalf("expected no recording before the call is answered, got %d", len(recordings))
+	}
+
+	answerCall(t, e, subAccount.SID, call.SID)
+	e.Advance(20 * time.Second)
+	if err := e.Hangup(subAccount.SID, call.SID); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	callbacks := mock.GetCallsTo("http://test/rec-status")
+	if len(callbacks) != 2 {
+		t.Fatalf("expected in-progress and completed callbacks, got %d", len(callbacks))
+	}
+	if callbacks[0].Form.Get("RecordingStatus") != "in-progress" || callbacks[1].Form.Get("RecordingStatus") != "completed" {
+		t.Errorf("unexpected recording statuses %s, %s", callbacks[0].Form.Get("RecordingStatus"), callbacks[1].Form.Get("RecordingStatus"))
+	}
+	form := callbacks[1].Form
+	if form.Get("RecordingDuration") != "20" || form.Get("RecordingChannels") != "2" || form.Get("RecordingSource") != "OutboundAPI" || form.Get("CallSid") != string(call.SID) {
+		t.Errorf("unexpected completed callback form %v", form)
+	}
+
+	recording, err := e.GetRecording(subAccount.SID, model.SID(form.Get("RecordingSid")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recording.Status != "completed" || recording.Duration != 20 {
+		t.Errorf("expected a completed 20 second recording, got %+v", recording)
+	}
+}
+
+func TestCallRecordingPause(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, holdRoutes)
+	call := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/answer"))
+	accountSID := string(subAccount.SID)
+
+	if _, err := e.CreateCallRecording(string(call.SID), &twilioopenapi.CreateCallRecordingParams{PathAccountSid: &accountSID}); err == nil {
+		t.Fatal("expected an error recording a call that is not in progress")
+	}
+	answerCall(t, e, subAccount.SID, call.SID)
+
+	created, err := e.CreateCallRecording(string(call.SID), (&twilioopenapi.CreateCallRecordingParams{}).
+		SetPathAccountSid(accountSID).
+		SetRecordingStatusCallback("http://test/rec-status"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *created.Status != "in-progress" || *created.Source != "StartCallRecordingAPI" {
+		t.Fatalf("unexpected created recording %+v", created)
+	}
+	recordingSID := *created.Sid
+
+	update := func(status string) *twilioopenapi.ApiV2010CallRecording {
+		t.Helper()
+		updated, err := e.UpdateCallRecording(string(call.SID), recordingSID, (&twilioopenapi.UpdateCallRecordingParams{}).
+			SetPathAccountSid(accountSID).
+			SetStatus(status))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return updated
+	}
+
+	// Card details are collected while the recording is paused
+	e.Advance(10 * time.Second)
+	if paused := update("paused"); *paused.Status != "paused" || *paused.Duration != "10" {
+		t.Fatalf("unexpected paused recording %+v", paused)
+	}
+	e.Advance(30 * time.Second)
+	update("in-progress")
+	e.Advance(5 * time.Second)
+
+	recordings, err := e.ListCallRecording(string(call.SID), &twilioopenapi.ListCallRecordingParams{PathAccountSid: &accountSID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recordings) != 1 || *recordings[0].Duration != "15" {
+		t.Fatalf("expected one recording of 15 seconds so far, got %+v", recordings)
+	}
+
+	if err := e.DeleteRecording(recordingSID, &twilioopenapi.DeleteRecordingParams{PathAccountSid: &accountSID}); err == nil {
+		t.Fatal("expected an error deleting a recording in progress")
+	}
+
+	if stopped := update("stopped"); *stopped.Status != "completed" || *stopped.Duration != "15" {
+		t.Fatalf("unexpected stopped recording %+v", stopped)
+	}
+	settle(t, e)
+	callbacks := mock.GetCallsTo("http://test/rec-status")
+	if len(callbacks) != 1 || callbacks[0].Form.Get("RecordingDuration") != "15" {
+		t.Fatalf("expected one completed callback of 15 seconds, got %+v", callbacks)
+	}
+
+	if _, err := e.UpdateCallRecording(string(call.SID), recordingSID, (&twilioopenapi.UpdateCallRecordingParams{}).
+		SetPathAccountSid(accountSID).
+		SetStatus("paused")); err == nil {
+		t.Error("expected an error pausing a stopped recording")
+	}
+
+	all, err := e.ListRecording((&twilioopenapi.ListRecordingParams{}).SetPathAccountSid(accountSID).SetCallSid(string(call.SID)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || *all[0].Sid != recordingSID {
+		t.Fatalf("expected the call recording in ListRecording, got %+v", all)
+	}
+	if err := e.DeleteRecording(recordingSID, &twilioopenapi.DeleteRecordingParams{PathAccountSid: &accountSID}); err != nil {
+		t.Fatal(err)
+	}
+	all, _ = e.ListRecording((&twilioopenapi.ListRecordingParams{}).SetPathAccountSid(accountSID))
+	if len(all) != 0 {
+		t.Errorf("expected no recordings after delete, got %d", len(all))
+	}
+}
+
+func TestCallRecordingPauseSilence(t *testing.T) {
+	e, _, subAccount := newTestEngine(t, holdRoutes)
+	call := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/answer"))
+	accountSID := string(subAccount.SID)
+	answerCall(t, e, subAccount.SID, call.SID)
+
+	created, err := e.CreateCallRecording(string(call.SID), (&twilioopenapi.CreateCallRecordingParams{}).SetPathAccountSid(accountSID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.Advance(10 * time.Second)
+	if _, err := e.UpdateCallRecording(string(call.SID), *created.Sid, (&twilioopenapi.UpdateCallRecordingParams{}).
+		SetPathAccountSid(accountSID).
+		SetStatus("paused").
+		SetPauseBehavior("silence")); err != nil {
+		t.Fatal(err)
+	}
+	e.Advance(30 * time.Second)
+
+	// The call ending completes the recording, with the pause recorded as silence
+	if err := e.Hangup(subAccount.SID, call.SID); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	recording, err := e.GetRecording(subAccount.SID, model.SID(*created.Sid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recording.Status != "completed" || recording.Duration != 40 {
+		t.Errorf("expected a completed 40 second recording, got %+v", recording)
+	}
+}
@@ -227,6 +227,21 @@ func (c *ManualClock) AdvanceTo(t time.Time) {
 	}
 }
 
+// NextTimer returns when the earliest pending timer fires
+func (c *ManualClock) NextTimer() (time.Time, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var next time.Time
+	found := false
+	for _, mt := range c.timers {
+		if !mt.stopped && (!found || mt.fireAt.Before(next)) {
+			next = mt.fireAt
+			found = true
+		}
+	}
+	return next, found
+}
+
 func (c *ManualClock) fireDueTimers() {
 	// Fire timers in order until we run out of due timers
 	for len(c.timers) > 0 {
@@ -260,17 +275,16 @@ type manualTimer struct {
 }
 
 func (t *manualTimer) Stop() bool {
-	if t.stopped {
-		return false
-	}
-	t.stopped = true
-
-	// Remove from heap
+	// Mark as stopped under the clock lock; it is removed from the heap on
+	// the next advance
 	if t.clock != nil {
 		t.clock.mu.Lock()
 		defer t.clock.mu.Unlock()
-		// Mark as stopped; will be cleaned up on next advance
 	}
+	if t.stopped {
+		return false
+	}
+	t.stopped = true
 	return true
 }
 
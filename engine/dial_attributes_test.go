@@ -0,0 +1,310 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+
+	"github.com/sprucehealth/twimulator/engine"
+	"github.com/sprucehealth/twimulator/httpstub"
+	"github.com/sprucehealth/twimulator/model"
+	"github.com/sprucehealth/twimulator/twimtest"
+)
+
+func TestDialTimeLimit(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, map[string]string{"http://test/answer": `<Response><Dial timeLimit="60" action="http://test/dial-done"><Number>+15553334444</Number></Dial></Response>`})
+	sid := startCall(t, e, subAccount.SID).SID
+	children := childCalls(t, e, subAccount.SID, sid)
+	if len(children) != 1 {
+		t.Fatalf("expected one child call, got %v", children)
+	}
+	answerCall(t, e, subAccount.SID, children[0])
+
+	e.Advance(59 * time.Second)
+	settle(t, e)
+	if child, _ := e.GetCallState(subAccount.SID, children[0]); child.Status != model.CallInProgress {
+		t.Fatalf("expected the bridge to last until the time limit, got %s", child.Status)
+	}
+	e.Advance(time.Second)
+	settle(t, e)
+
+	twimtest.ExpectWebhook(t, mock, "http://test/dial-done").
+		WithFormValues(url.Values{
+			"DialCallStatus":   {"completed"},
+			"DialCallSid":      {string(children[0])},
+			"DialCallDuration": {"60"},
+			"DialBridged":      {"true"},
+		}).
+		Times(1)
+	if child, _ := e.GetCallState(subAccount.SID, children[0]); child.Status != model.CallCompleted {
+		t.Errorf("expected the time limit to hang up the dialed call, got %s", child.Status)
+	}
+}
+
+func TestDialSequential(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, map[string]string{"http://test/answer": `<Response><Dial sequential="true" timeout="10" action="http://test/dial-done"><Number>+15553334444</Number><Number>+15554445555</Number><Number>+15555556666</Number></Dial></Response>`})
+	sid := startCall(t, e, subAccount.SID).SID
+
+	// Only the first number rings until it is busy
+	children := childCalls(t, e, subAccount.SID, sid)
+	if len(children) != 1 {
+		t.Fatalf("expected the numbers to be dialed one at a time, got %v", children)
+	}
+	if err := e.SetCallBusy(subAccount.SID, children[0]); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	// The second number rings out
+	children = childCalls(t, e, subAccount.SID, sid)
+	if len(children) != 2 {
+		t.Fatalf("expected the second number to be dialed, got %v", children)
+	}
+	e.Advance(10 * time.Second)
+	settle(t, e)
+
+	// The third number answers and hangs up after 30 seconds
+	children = childCalls(t, e, subAccount.SID, sid)
+	if len(children) != 3 {
+		t.Fatalf("expected the third number to be dialed, got %v", children)
+	}
+	answerCall(t, e, subAccount.SID, children[2])
+	e.Advance(30 * time.Second)
+	if err := e.Hangup(subAccount.SID, children[2]); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	twimtest.ExpectWebhook(t, mock, "http://test/dial-done").
+		WithFormValues(url.Values{
+			"DialCallStatus":   {"completed"},
+			"DialCallSid":      {string(children[2])},
+			"DialCallDuration": {"30"},
+			"DialBridged":      {"true"},
+		}).
+		Times(1)
+}
+
+func TestDialActionParams(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		end    func(e *engine.EngineImpl, accountSID, child model.SID) error
+		status string
+	}{
+		{name: "busy", end: (*engine.EngineImpl).SetCallBusy, status: "busy"},
+		{name: "failed", end: (*engine.EngineImpl).SetCallFailed, status: "failed"},
+		{name: "no-answer", status: "no-answer"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			e, mock, subAccount := newTestEngine(t, map[string]string{"http://test/answer": `<Response><Dial timeout="10" action="http://test/dial-done"><Number>+15553334444</Number></Dial></Response>`})
+			sid := startCall(t, e, subAccount.SID).SID
+			children := childCalls(t, e, subAccount.SID, sid)
+			if tc.end != nil {
+				if err := tc.end(e, subAccount.SID, children[0]); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				e.Advance(10 * time.Second)
+			}
+			settle(t, e)
+
+			form := twimtest.ExpectWebhook(t, mock, "http://test/dial-done").
+				WithFormValues(url.Values{
+					"DialCallStatus": {tc.status},
+					"DialCallSid":    {string(children[0])},
+					"DialBridged":    {"false"},
+				}).
+				Times(1).
+				Form()
+			if form.Has("DialCallDuration") {
+				t.Errorf("expected no DialCallDuration for an unanswered dial, got %s", form.Get("DialCallDuration"))
+			}
+		})
+	}
+}
+
+func TestDialRecordingURL(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, map[string]string{"http://test/answer": `<Response><Dial record="record-from-answer" action="http://test/dial-done"><Number>+15553334444</Number></Dial></Response>`})
+	sid := startCall(t, e, subAccount.SID).SID
+	children := childCalls(t, e, subAccount.SID, sid)
+	answerCall(t, e, subAccount.SID, children[0])
+	recordingSID, err := e.SetCallRecording(subAccount.SID, sid, "/tmp/dial.wav", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Hangup(subAccount.SID, children[0]); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	form := twimtest.ExpectWebhook(t, mock, "http://test/dial-done").
+		WithForm("RecordingSid", string(recordingSID)).
+		WithForm("RecordingDuration", "12").
+		Times(1).
+		Form()
+	if form.Get("RecordingUrl") == "" {
+		t.Error("expected the recording URL in the action request")
+	}
+}
+
+func TestDialAnswerOnBridge(t *testing.T) {
+	start := func(t *testing.T) (*engine.EngineImpl, *httpstub.MockWebhookClient, model.SID, model.SID) {
+		e, mock, subAccount := newTestEngine(t, map[string]string{"http://test/answer": `<Response><Dial answerOnBridge="true" ringTone="uk" callerId="+15550001111" action="http://test/dial-done"><Number>+15553334444</Number></Dial></Response>`})
+		accountSID := string(subAccount.SID)
+		app, err := e.CreateApplication((&twilioopenapi.CreateApplicationParams{}).
+			SetPathAccountSid(accountSID).
+			SetVoiceUrl("http://test/answer").
+			SetStatusCallback("http://test/status"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := e.CreateIncomingPhoneNumber((&twilioopenapi.CreateIncomingPhoneNumberParams{}).
+			SetPathAccountSid(accountSID).
+			SetPhoneNumber("+15550002222").
+			SetVoiceApplicationSid(*app.Sid)); err != nil {
+			t.Fatal(err)
+		}
+		apiCall, err := e.CreateIncomingCall(subAccount.SID, "+15552223333", "+15550002222")
+		if err != nil {
+			t.Fatal(err)
+		}
+		settle(t, e)
+
+		sid := model.SID(*apiCall.Sid)
+		call, _ := e.GetCallState(subAccount.SID, sid)
+		if call.Status != model.CallRinging || call.AnsweredAt != nil {
+			t.Fatalf("expected the caller to keep ringing, got %s answered at %v", call.Status, call.AnsweredAt)
+		}
+		return e, mock, subAccount.SID, sid
+	}
+
+	t.Run("answered", func(t *testing.T) {
+		e, _, accountSID, sid := start(t)
+		answerCall(t, e, accountSID, childCalls(t, e, accountSID, sid)[0])
+
+		call, _ := e.GetCallState(accountSID, sid)
+		if call.Status != model.CallInProgress || call.AnsweredAt == nil {
+			t.Errorf("expected the caller to be answered with the dialed party, got %s", call.Status)
+		}
+		if !hasEvent(call, "dial.answer_on_bridge") {
+			t.Error("expected the answer on bridge on the timeline")
+		}
+	})
+
+	t.Run("busy", func(t *testing.T) {
+		e, mock, accountSID, sid := start(t)
+		if err := e.SetCallBusy(accountSID, childCalls(t, e, accountSID, sid)[0]); err != nil {
+			t.Fatal(err)
+		}
+		settle(t, e)
+
+		twimtest.ExpectCall(t, e, sid).EndedWith(model.CallBusy)
+		call, _ := e.GetCallState(accountSID, sid)
+		if call.AnsweredAt != nil {
+			t.Errorf("expected the caller to never be answered, got %v", call.AnsweredAt)
+		}
+		twimtest.ExpectWebhook(t, mock, "http://test/status").WithForm("CallStatus", "busy").Times(1)
+	})
+}
+
+func TestDialSimultaneousUnansweredCallSID(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, map[string]string{"http://test/answer": `<Response><Dial timeout="10" action="http://test/dial-done"><Number>+15553334444</Number><Number>+15554445555</Number></Dial></Response>`})
+	sid := startCall(t, e, subAccount.SID).SID
+	if children := childCalls(t, e, subAccount.SID, sid); len(children) != 2 {
+		t.Fatalf("expected both numbers to ring, got %v", children)
+	}
+	e.Advance(10 * time.Second)
+	settle(t, e)
+
+	// No dialed call stands for the dial when several rang
+	form := twimtest.ExpectWebhook(t, mock, "http://test/dial-done").
+		WithForm("DialCallStatus", "no-answer").
+		Times(1).
+		Form()
+	if form.Has("DialCallSid") {
+		t.Errorf("expected no DialCallSid, got %s", form.Get("DialCallSid"))
+	}
+}
+
+func TestDialConferenceTimeLimit(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, map[string]string{"http://test/answer": `<Response><Dial timeLimit="60" action="http://test/dial-done"><Conference>room</Conference></Dial></Response>`})
+	call := startCall(t, e, subAccount.SID)
+
+	e.Advance(59 * time.Second)
+	settle(t, e)
+	if n := len(mock.GetCallsTo("http://test/dial-done")); n != 0 {
+		t.Fatalf("expected the conference leg to last until the time limit, got %d action requests", n)
+	}
+	e.Advance(time.Second)
+	settle(t, e)
+
+	twimtest.ExpectWebhook(t, mock, "http://test/dial-done").Times(1)
+	got, _ := e.GetCallState(subAccount.SID, call.SID)
+	if ev := findEvent(got, "dial.conference.interrupted"); ev == nil || ev.Detail["reason"] != "time_limit" {
+		t.Errorf("expected the time limit to end the conference leg, got %v", ev)
+	}
+}
+
+func TestDialQueueTimeLimit(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, map[string]string{
+		"http://test/answer": `<Response><Enqueue>support</Enqueue></Response>`,
+		"http://test/agent":  `<Response><Dial timeLimit="60" action="http://test/dial-done"><Queue>support</Queue></Dial></Response>`,
+	})
+	caller := startCall(t, e, subAccount.SID)
+	agent := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15554445555", "http://test/agent"))
+	answerCall(t, e, subAccount.SID, agent.SID)
+
+	e.Advance(60 * time.Second)
+	settle(t, e)
+
+	twimtest.ExpectWebhook(t, mock, "http://test/dial-done").Times(1)
+	got, _ := e.GetCallState(subAccount.SID, agent.SID)
+	if ev := findEvent(got, "dial.queue.bridge_interrupted"); ev == nil || ev.Detail["reason"] != "time_limit" {
+		t.Errorf("expected the time limit to end the queue bridge, got %v", ev)
+	}
+	if got, _ := e.GetCallState(subAccount.SID, caller.SID); !hasEvent(got, "enqueue.bridge_completed") {
+		t.Error("expected the caller's bridge to end too")
+	}
+}
+
+func TestDialTrimSilence(t *testing.T) {
+	for _, tc := range []struct {
+		trim     string
+		duration string
+	}{
+		{trim: "trim-silence", duration: "20"},
+		{trim: "do-not-trim", duration: "30"},
+	} {
+		t.Run(tc.trim, func(t *testing.T) {
+			e, mock, subAccount := newTestEngine(t, map[string]string{"http://test/answer": `<Response><Dial record="record-from-answer" trim="` + tc.trim + `" action="http://test/dial-done"><Number>+15553334444</Number></Dial></Response>`})
+			sid := startCall(t, e, subAccount.SID).SID
+			children := childCalls(t, e, subAccount.SID, sid)
+			answerCall(t, e, subAccount.SID, children[0])
+			if _, err := e.SetCallRecording(subAccount.SID, sid, "/tmp/dial.wav", 30); err != nil {
+				t.Fatal(err)
+			}
+
+			// The last 10 seconds of the bridge are silent
+			e.Advance(20 * time.Second)
+			if err := e.SendSilence(subAccount.SID, sid); err != nil {
+				t.Fatal(err)
+			}
+			settle(t, e)
+			e.Advance(10 * time.Second)
+			if err := e.Hangup(subAccount.SID, children[0]); err != nil {
+				t.Fatal(err)
+			}
+			settle(t, e)
+
+			twimtest.ExpectWebhook(t, mock, "http://test/dial-done").
+				WithForm("RecordingDuration", tc.duration).
+				Times(1)
+		})
+	}
+}
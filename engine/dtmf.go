@@ -0,0 +1,87 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine
+
+import "errors"
+
+// errBargeIn is returned by a Gather prompt that digits interrupted
+var errBargeIn = errors.New("prompt interrupted by digits")
+
+// SendDigits buffers digits for the call. They are read by the verb that is
+// executing, or by the next one that reads digits, so callers can type ahead
+// of a Gather.
+func (r *CallRunner) SendDigits(digits string) {
+	if digits == "" {
+		return
+	}
+	r.state.mu.Lock()
+	defer r.state.mu.Unlock()
+	r.dtmf += digits
+	r.signalDigitsLocked()
+}
+
+// takeDigits returns and clears the buffered digits
+func (r *CallRunner) takeDigits() string {
+	r.state.mu.Lock()
+	defer r.state.mu.Unlock()
+	digits := r.dtmf
+	r.dtmf = ""
+	select {
+	case <-r.dtmfCh:
+	default:
+	}
+	return digits
+}
+
+// unreadDigits puts digits that were read but not used back in front of the
+// buffer for the next verb
+func (r *CallRunner) unreadDigits(digits string) {
+	if digits == "" {
+		return
+	}
+	r.state.mu.Lock()
+	defer r.state.mu.Unlock()
+	r.dtmf = digits + r.dtmf
+	r.signalDigitsLocked()
+}
+
+// discardDigits drops the digits pressed while <Dial> or <Enqueue> connected
+// the call to another party, who heard them, so the next verb does not read
+// them
+func (r *CallRunner) discardDigits() {
+	if digits := r.takeDigits(); digits != "" {
+		r.addCallEvent("digits.discarded", map[string]any{"digits": digits})
+	}
+}
+
+// hasDigits reports whether digits are buffered
+func (r *CallRunner) hasDigits() bool {
+	r.state.mu.RLock()
+	defer r.state.mu.RUnlock()
+	return r.dtmf != ""
+}
+
+func (r *CallRunner) signalDigitsLocked() {
+	notify(r.engine, r.dtmfCh, struct{}{})
+}
+
+// bargeInCh returns the channel that interrupts a prompt when digits arrive,
+// or nil when the prompt is not interruptible
+func (r *CallRunner) bargeInCh() chan struct{} {
+	if !r.bargeIn {
+		return nil
+	}
+	return r.dtmfCh
+}
+
+// interruptPrompt stops a prompt that digits interrupted. The digits stay
+// buffered for the Gather.
+func (r *CallRunner) interruptPrompt(verbName string) error {
+	r.state.mu.Lock()
+	r.signalDigitsLocked()
+	r.state.mu.Unlock()
+	r.addCallEvent(verbName+".interrupted", map[string]any{"reason": "dtmf"})
+	return errBargeIn
+}
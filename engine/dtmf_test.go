@@ -0,0 +1,131 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sprucehealth/twimulator/engine"
+	"github.com/sprucehealth/twimulator/model"
+	"github.com/sprucehealth/twimulator/twimtest"
+)
+
+// menuRoutes serve a main menu and a billing menu, each gathering one digit
+var menuRoutes = map[string]string{
+	"http://test/answer":  `<Response><Say>Thanks for calling</Say><Gather numDigits="1" action="http://test/menu" timeout="600"><Say>For billing press 1</Say><Play>http://test/menu.mp3</Play></Gather></Response>`,
+	"http://test/menu":    `<Response><Gather numDigits="1" action="http://test/billing" timeout="600"><Say loop="0">For invoices press 2</Say></Gather></Response>`,
+	"http://test/billing": `<Response><Say>You chose 2</Say><Hangup/></Response>`,
+}
+
+func TestGatherBargeIn(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, menuRoutes, engine.WithTimedMedia())
+	call := startCall(t, e, subAccount.SID)
+
+	// The welcome outside the gather plays to the end
+	e.Advance(5 * time.Second)
+	settle(t, e)
+
+	// Pressing 1 interrupts the billing prompt before the media plays
+	sendDigits(t, e, subAccount.SID, call.SID, "1")
+	twimtest.ExpectWebhook(t, mock, "http://test/menu").WithForm("Digits", "1").Times(1)
+	for _, req := range mock.Calls {
+		if req.URL == "http://test/menu.mp3" {
+			t.Error("expected the interrupted prompt to skip the rest of the gather's children")
+		}
+	}
+
+	// A prompt that loops forever is interrupted too
+	sendDigits(t, e, subAccount.SID, call.SID, "2")
+	e.Advance(5 * time.Second)
+	settle(t, e)
+	twimtest.ExpectWebhook(t, mock, "http://test/billing").WithForm("Digits", "2").Times(1)
+	twimtest.ExpectCall(t, e, call.SID).
+		Said("Thanks for calling").
+		ThenGathered(twimtest.WithAction("http://test/menu")).
+		ThenGathered(twimtest.WithAction("http://test/billing")).
+		ThenSaid("You chose 2").
+		EndedWith(model.CallCompleted)
+
+	got, _ := e.GetCallState(subAccount.SID, call.SID)
+	var interrupted []string
+	for _, ev := range got.Timeline {
+		if strings.HasSuffix(ev.Type, ".interrupted") && ev.Detail["reason"] == "dtmf" {
+			interrupted = append(interrupted, ev.Type)
+		}
+	}
+	if len(interrupted) != 2 || interrupted[0] != "say.interrupted" || interrupted[1] != "say.interrupted" {
+		t.Errorf("expected both prompts to be interrupted by digits, got %v", interrupted)
+	}
+}
+
+func TestGatherTypeAhead(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, menuRoutes, engine.WithTimedMedia())
+	call := startCall(t, e, subAccount.SID)
+
+	// Both menus are answered while the welcome is still playing
+	sendDigits(t, e, subAccount.SID, call.SID, "1")
+	sendDigits(t, e, subAccount.SID, call.SID, "2")
+	e.Advance(5 * time.Second)
+	settle(t, e)
+	e.Advance(5 * time.Second)
+	settle(t, e)
+
+	twimtest.ExpectWebhook(t, mock, "http://test/menu").WithForm("Digits", "1").Times(1)
+	twimtest.ExpectWebhook(t, mock, "http://test/billing").WithForm("Digits", "2").Times(1)
+	twimtest.ExpectCall(t, e, call.SID).
+		Said("Thanks for calling").
+		ThenSaid("You chose 2").
+		EndedWith(model.CallCompleted)
+
+	got, _ := e.GetCallState(subAccount.SID, call.SID)
+	if hasEvent(got, "say.interrupted") {
+		t.Error("expected digits to not interrupt verbs outside a gather")
+	}
+	if !hasEvent(got, "gather.barge_in") {
+		t.Error("expected the typed ahead digits to skip the prompts")
+	}
+}
+
+func TestDialDiscardsDigits(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, map[string]string{
+		"http://test/answer":    `<Response><Dial action="http://test/dial-done"><Number>+15553334444</Number></Dial></Response>`,
+		"http://test/dial-done": `<Response><Gather numDigits="1" action="http://test/menu" timeout="600"/></Response>`,
+	})
+	call := startCall(t, e, subAccount.SID)
+	children := childCalls(t, e, subAccount.SID, call.SID)
+	answerCall(t, e, subAccount.SID, children[0])
+
+	// Digits pressed while connected are heard by the dialed party
+	sendDigits(t, e, subAccount.SID, call.SID, "5")
+	if err := e.Hangup(subAccount.SID, children[0]); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	if n := len(mock.GetCallsTo("http://test/menu")); n != 0 {
+		t.Fatalf("expected the gather after the dial to wait for new digits, got %d requests", n)
+	}
+	got, _ := e.GetCallState(subAccount.SID, call.SID)
+	if ev := findEvent(got, "digits.discarded"); ev == nil || ev.Detail["digits"] != "5" {
+		t.Errorf("expected the digits to be discarded, got %+v", ev)
+	}
+
+	sendDigits(t, e, subAccount.SID, call.SID, "2")
+	twimtest.ExpectWebhook(t, mock, "http://test/menu").WithForm("Digits", "2").Times(1)
+}
+
+func TestRecordKeepsDigitsAfterFinishKey(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, map[string]string{
+		"http://test/answer":   `<Response><Record finishOnKey="#" action="http://test/recorded"/></Response>`,
+		"http://test/recorded": `<Response><Gather numDigits="1" action="http://test/menu" timeout="600"/></Response>`,
+	})
+	call := startCall(t, e, subAccount.SID)
+
+	// The digit typed after the finish key answers the next gather
+	sendDigits(t, e, subAccount.SID, call.SID, "#3")
+	twimtest.ExpectWebhook(t, mock, "http://test/recorded").WithForm("Digits", "#").Times(1)
+	twimtest.ExpectWebhook(t, mock, "http://test/menu").WithForm("Digits", "3").Times(1)
+}
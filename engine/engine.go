@@ -7,8 +7,8 @@ package engine
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
-	"math/rand"
 	"net/http"
 	"net/url"
 	"runtime/debug"
@@ -62,15 +62,37 @@ type Engine interface {
 	SetCallFailed(subaccountSID model.SID, callSID model.SID) error
 	Hangup(subaccountSID model.SID, callSID model.SID) error
 	SendDigits(subaccountSID model.SID, callSID model.SID, digits string) error
+	SendSpeech(subaccountSID model.SID, callSID model.SID, transcript string, confidence float64) error
+	SendSilence(subaccountSID model.SID, callSID model.SID) error
+	SetAnsweredBy(subaccountSID model.SID, callSID model.SID, answeredBy string) error
+	SendStreamAudio(subaccountSID model.SID, callSID model.SID, audio []byte) error
+
+	// Messaging
+	CreateMessage(params *twilioopenapi.CreateMessageParams) (*twilioopenapi.ApiV2010Message, error)
+	FetchMessage(sid string, params *twilioopenapi.FetchMessageParams) (*twilioopenapi.ApiV2010Message, error)
+	ListMessage(params *twilioopenapi.ListMessageParams) ([]twilioopenapi.ApiV2010Message, error)
+	UpdateMessage(sid string, params *twilioopenapi.UpdateMessageParams) (*twilioopenapi.ApiV2010Message, error)
+	CreateIncomingMessage(accountSID model.SID, from string, to string, body string, mediaURLs ...string) (*twilioopenapi.ApiV2010Message, error)
+	SetMessageDelivery(accountSID model.SID, to string, status model.MessageStatus, errorCode int) error
 
 	// Introspection
 	FetchCall(sid string, params *twilioopenapi.FetchCallParams) (*twilioopenapi.ApiV2010Call, error)
+	ListCall(params *twilioopenapi.ListCallParams) ([]twilioopenapi.ApiV2010Call, error)
 	FetchConference(sid string, params *twilioopenapi.FetchConferenceParams) (*twilioopenapi.ApiV2010Conference, error)
 	ListConference(params *twilioopenapi.ListConferenceParams) ([]twilioopenapi.ApiV2010Conference, error)
 	UpdateConference(sid string, params *twilioopenapi.UpdateConferenceParams) (*twilioopenapi.ApiV2010Conference, error)
 	FetchParticipant(conferenceSid string, callSid string, params *twilioopenapi.FetchParticipantParams) (*twilioopenapi.ApiV2010Participant, error)
 	UpdateParticipant(conferenceSid string, callSid string, params *twilioopenapi.UpdateParticipantParams) (*twilioopenapi.ApiV2010Participant, error)
+	CreateParticipant(conferenceSid string, params *twilioopenapi.CreateParticipantParams) (*twilioopenapi.ApiV2010Participant, error)
+	ListParticipant(conferenceSid string, params *twilioopenapi.ListParticipantParams) ([]twilioopenapi.ApiV2010Participant, error)
+	DeleteParticipant(conferenceSid string, callSid string, params *twilioopenapi.DeleteParticipantParams) error
 	FetchRecording(sid string, params *twilioopenapi.FetchRecordingParams) (*twilioopenapi.ApiV2010Recording, error)
+	ListRecording(params *twilioopenapi.ListRecordingParams) ([]twilioopenapi.ApiV2010Recording, error)
+	DeleteRecording(sid string, params *twilioopenapi.DeleteRecordingParams) error
+	CreateCallRecording(callSid string, params *twilioopenapi.CreateCallRecordingParams) (*twilioopenapi.ApiV2010CallRecording, error)
+	UpdateCallRecording(callSid string, sid string, params *twilioopenapi.UpdateCallRecordingParams) (*twilioopenapi.ApiV2010CallRecording, error)
+	ListCallRecording(callSid string, params *twilioopenapi.ListCallRecordingParams) ([]twilioopenapi.ApiV2010CallRecording, error)
+	FetchTranscription(sid string, params *twilioopenapi.FetchTranscriptionParams) (*twilioopenapi.ApiV2010Transcription, error)
 	ListCalls(filter CallFilter) []*model.Call
 	GetQueue(accountSID model.SID, name string) (*model.Queue, bool)
 	GetConference(accountSID model.SID, name string) (*model.Conference, bool)
@@ -82,12 +104,18 @@ type Engine interface {
 	// Time control
 	SetAutoTime(enabled bool)
 	Advance(d time.Duration)
+	AdvanceUntilIdle(max time.Duration) error
+	Settle(ctx context.Context) error
 	Clock() Clock
 
 	// Recording management
 	SetCallRecording(accountSID model.SID, callSID model.SID, filePath string, duration int) (model.SID, error)
 	SetCallVoicemail(accountSID model.SID, callSID model.SID, filePath string, duration int) (model.SID, error)
 	GetRecording(accountSID model.SID, recordingSID model.SID) (*model.Recording, error)
+	SetRecordingTranscription(accountSID model.SID, recordingSID model.SID, text string, status string) error
+
+	// Event stream
+	Subscribe(filter EventFilter) (<-chan EngineEvent, func())
 
 	// Shutdown
 	Close() error
@@ -105,13 +133,15 @@ type StateSnapshot struct {
 	engine     Engine
 	accountSID model.SID
 
-	Calls       map[model.SID]*model.Call       `json:"calls"`
-	Queues      map[string]*model.Queue         `json:"queues"`
-	Conferences map[string]*model.Conference    `json:"conferences"`
-	SubAccounts map[model.SID]*model.SubAccount `json:"sub_accounts"`
-	Recordings  map[model.SID]*model.Recording  `json:"recordings"`
-	Errors      []error                         `json:"errors"`
-	Timestamp   time.Time                       `json:"timestamp"`
+	Calls          map[model.SID]*model.Call          `json:"calls"`
+	Queues         map[string]*model.Queue            `json:"queues"`
+	Conferences    map[string]*model.Conference       `json:"conferences"`
+	SubAccounts    map[model.SID]*model.SubAccount    `json:"sub_accounts"`
+	Recordings     map[model.SID]*model.Recording     `json:"recordings"`
+	Transcriptions map[model.SID]*model.Transcription `json:"transcriptions"`
+	Messages       map[model.SID]*model.Message       `json:"messages"`
+	Errors         []error                            `json:"errors"`
+	Timestamp      time.Time                          `json:"timestamp"`
 }
 
 // subAccountState holds all state for a single subaccount with its own lock
@@ -137,6 +167,14 @@ type subAccountState struct {
 	runners              map[model.SID]*CallRunner
 	errors               []error
 	recordings           map[model.SID]*model.Recording // Recordings by SID
+	messages             map[model.SID]*model.Message   // Messages by SID
+	transcriptions       map[model.SID]*model.Transcription
+
+	// Transcription results set by the test, by recording SID
+	transcriptionResults map[model.SID]transcriptionResult
+
+	// Simulated delivery outcomes by destination number
+	messageOutcomes map[string]messageOutcome
 
 	// Participant states scoped by (conferenceSID, callSID)
 	participantStates map[model.SID]map[model.SID]*model.ParticipantState
@@ -144,6 +182,13 @@ type subAccountState struct {
 	// Call-specific recording associations
 	callRecordings map[model.SID]model.SID // callSID -> recordingSID for Dial/Conference recordings
 	callVoicemails map[model.SID]model.SID // callSID -> recordingSID for Record voicemails
+
+	// Call recordings started through the API that have not ended, by recording SID
+	activeRecordings map[model.SID]*callRecording
+	// Recordings requested with CreateCall Record=true that start when the call is answered
+	recordOnAnswer map[model.SID]*recordingConfig
+	// Numbers each on-net call chain has been routed through, by inbound call SID
+	onNetPaths map[model.SID][]string
 }
 
 // EngineImpl is the concrete implementation of Engine
@@ -155,9 +200,31 @@ type EngineImpl struct {
 
 	// Immutable or globally-shared state (no lock needed after init)
 	defaultClock Clock
+	ids          *model.IDGenerator
 	webhook      httpstub.WebhookClient
 	apiVersion   string
 	baseURL      string // Base URL for generating recording URLs (e.g., "http://localhost:8080")
+	// corruptSignatures makes webhook signatures invalid to exercise rejection paths
+	corruptSignatures bool
+	// transcriber produces transcriptions not set with SetRecordingTranscription
+	transcriber        Transcriber
+	transcriptionDelay time.Duration
+	// timedMedia makes Say, Play and Pause take time on the engine clock
+	timedMedia bool
+	// onNetRouting delivers calls to numbers owned in the engine as inbound calls
+	onNetRouting bool
+	// machineDetectionTime is how long detection takes to recognize an outcome
+	// chosen before the call was answered
+	machineDetectionTime time.Duration
+	// busy counts the runners and workers that Settle waits for
+	busy busyTracker
+	// Event subscribers
+	subscriptionsMu sync.RWMutex
+	subscriptions   map[*subscription]struct{}
+	// stateFrom is restored by NewEngine once the options are applied
+	stateFrom io.Reader
+	// stateFromErr is why stateFrom could not be restored
+	stateFromErr error
 	ctx          context.Context
 	cancel       context.CancelFunc
 	wg           sync.WaitGroup
@@ -170,7 +237,17 @@ type incomingNumber struct {
 	SID              model.SID
 	PhoneNumber      string
 	VoiceApplication *model.SID
-	CreatedAt        time.Time
+	SmsApplication   *model.SID
+	// VoiceURL is used for calls to the number when it has no voice application
+	VoiceURL    string
+	VoiceMethod string
+	// VoiceFallbackURL is used for calls to the number when its voice
+	// application has no fallback URL of its own
+	VoiceFallbackURL    string
+	VoiceFallbackMethod string
+	SmsURL              string
+	SmsMethod           string
+	CreatedAt           time.Time
 }
 
 type applicationRecord struct {
@@ -178,8 +255,13 @@ type applicationRecord struct {
 	FriendlyName         string
 	VoiceMethod          string
 	VoiceURL             string
+	VoiceFallbackMethod  string
+	VoiceFallbackURL     string
 	StatusCallbackMethod string
 	StatusCallback       string
+	SmsURL               string
+	SmsMethod            string
+	SmsStatusCallback    string
 	CreatedAt            time.Time
 }
 
@@ -211,6 +293,14 @@ func WithClock(clock Clock) EngineOption {
 	}
 }
 
+// WithSeed makes every SID, auth token and signing key secret the engine
+// generates the same on every run for the same seed
+func WithSeed(seed int64) EngineOption {
+	return func(e *EngineImpl) {
+		e.ids = model.NewSeededIDGenerator(seed)
+	}
+}
+
 // WithWebhookClient sets the webhook client
 func WithWebhookClient(client httpstub.WebhookClient) EngineOption {
 	return func(e *EngineImpl) {
@@ -225,6 +315,14 @@ func WithBaseURL(baseURL string) EngineOption {
 	}
 }
 
+// WithCorruptedSignatures signs webhooks with an invalid X-Twilio-Signature so
+// that request validation failures can be tested
+func WithCorruptedSignatures() EngineOption {
+	return func(e *EngineImpl) {
+		e.corruptSignatures = true
+	}
+}
+
 // NewEngine creates a new engine instance
 func NewEngine(opts ...EngineOption) *EngineImpl {
 	ctx, cancel := context.WithCancel(context.Background())
@@ -233,43 +331,32 @@ func NewEngine(opts ...EngineOption) *EngineImpl {
 	e := &EngineImpl{
 		timeout:      timeout,
 		defaultClock: NewManualClock(time.Time{}), // default to manual
+		ids:          model.NewIDGenerator(),
 		webhook:      httpstub.NewDefaultWebhookClient(timeout),
 		apiVersion:   "2010-04-01",
 		subAccounts:  make(map[model.SID]*subAccountState),
 		ctx:          ctx,
-		cancel:       cancel,
+
+		busy:          busyTracker{parked: make(map[*parking]struct{})},
+		subscriptions: make(map[*subscription]struct{}),
+		cancel:        cancel,
+
+		transcriptionDelay: defaultTranscriptionDelay,
 	}
 
 	for _, opt := range opts {
 		opt(e)
 	}
+	e.loadInitialState()
 
 	return e
 }
 
-// CreateAccount creates a new simulated Twilio subaccount
-func (e *EngineImpl) CreateAccount(params *twilioopenapi.CreateAccountParams) (*twilioopenapi.ApiV2010Account, error) {
-	friendlyName := ""
-	if params != nil && params.FriendlyName != nil {
-		friendlyName = *params.FriendlyName
-	}
-
-	sid := model.NewSubAccountSID()
-	now := e.defaultClock.Now()
-	authToken := model.NewAuthToken()
-
-	subAccount := &model.SubAccount{
-		SID:          sid,
-		FriendlyName: friendlyName,
-		Status:       "active",
-		CreatedAt:    now,
-		AuthToken:    authToken,
-	}
-
-	// Create new subaccount state
-	state := &subAccountState{
-		clock:                e.defaultClock,
-		account:              subAccount,
+// newSubAccountState creates the empty state of a subaccount
+func newSubAccountState(account *model.SubAccount, clock Clock) *subAccountState {
+	return &subAccountState{
+		clock:                clock,
+		account:              account,
 		incomingNumbers:      make(map[string]*incomingNumber),
 		applications:         make(map[model.SID]*applicationRecord),
 		addresses:            make(map[model.SID]*model.Address),
@@ -284,11 +371,41 @@ func (e *EngineImpl) CreateAccount(params *twilioopenapi.CreateAccountParams) (*
 		conferences:          make(map[string]*model.Conference),
 		runners:              make(map[model.SID]*CallRunner),
 		recordings:           make(map[model.SID]*model.Recording),
+		messages:             make(map[model.SID]*model.Message),
+		transcriptions:       make(map[model.SID]*model.Transcription),
+		transcriptionResults: make(map[model.SID]transcriptionResult),
+		messageOutcomes:      make(map[string]messageOutcome),
 		participantStates:    make(map[model.SID]map[model.SID]*model.ParticipantState),
 		callRecordings:       make(map[model.SID]model.SID),
 		callVoicemails:       make(map[model.SID]model.SID),
+		activeRecordings:     make(map[model.SID]*callRecording),
+		recordOnAnswer:       make(map[model.SID]*recordingConfig),
+		onNetPaths:           make(map[model.SID][]string),
+	}
+}
+
+// CreateAccount creates a new simulated Twilio subaccount
+func (e *EngineImpl) CreateAccount(params *twilioopenapi.CreateAccountParams) (*twilioopenapi.ApiV2010Account, error) {
+	friendlyName := ""
+	if params != nil && params.FriendlyName != nil {
+		friendlyName = *params.FriendlyName
+	}
+
+	sid := e.ids.SubAccountSID()
+	now := e.defaultClock.Now()
+	authToken := e.ids.AuthToken()
+
+	subAccount := &model.SubAccount{
+		SID:          sid,
+		FriendlyName: friendlyName,
+		Status:       "active",
+		CreatedAt:    now,
+		AuthToken:    authToken,
 	}
 
+	// Create new subaccount state
+	state := newSubAccountState(subAccount, e.defaultClock)
+
 	// Only lock when adding to subaccounts map
 	e.subAccountsMu.Lock()
 	e.subAccounts[sid] = state
@@ -363,14 +480,16 @@ func (e *EngineImpl) ListAccount(params *twilioopenapi.ListAccountParams) ([]twi
 
 // CreateCall initiates a new call using Twilio-compatible parameters
 func (e *EngineImpl) CreateCall(params *twilioopenapi.CreateCallParams) (*twilioopenapi.ApiV2010Call, error) {
-	return e.createCall(params, nil)
+	return e.createCall(params, nil, nil)
 }
 
 func (e *EngineImpl) createChildCall(params *twilioopenapi.CreateCallParams, parentCallSID *model.SID) (*twilioopenapi.ApiV2010Call, error) {
-	return e.createCall(params, parentCallSID)
+	return e.createCall(params, parentCallSID, nil)
 }
 
-func (e *EngineImpl) createCall(params *twilioopenapi.CreateCallParams, parentCallSID *model.SID) (*twilioopenapi.ApiV2010Call, error) {
+// createCall creates an outbound call. When participantTwiML is set the call executes it once answered instead
+// of fetching its Url.
+func (e *EngineImpl) createCall(params *twilioopenapi.CreateCallParams, parentCallSID *model.SID, participantTwiML *twiml.Response) (*twilioopenapi.ApiV2010Call, error) {
 	if params == nil {
 		return nil, fmt.Errorf("params is required")
 	}
@@ -393,6 +512,16 @@ func (e *EngineImpl) createCall(params *twilioopenapi.CreateCallParams, parentCa
 		method = *params.Method
 	}
 
+	fallbackURL := ""
+	if params.FallbackUrl != nil {
+		fallbackURL = *params.FallbackUrl
+	}
+
+	fallbackMethod := http.MethodPost
+	if params.FallbackMethod != nil {
+		fallbackMethod = *params.FallbackMethod
+	}
+
 	from := ""
 	if params.From != nil {
 		from = *params.From
@@ -433,6 +562,19 @@ func (e *EngineImpl) createCall(params *twilioopenapi.CreateCallParams, parentCa
 		callToken = *params.CallToken
 	}
 
+	amd, err := newAMDConfig(params.MachineDetection, params.MachineDetectionTimeout, params.AsyncAmd, params.AsyncAmdStatusCallback, params.AsyncAmdStatusCallbackMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	var recordCfg *recordingConfig
+	if params.Record != nil && *params.Record {
+		recordCfg, err = newRecordingConfig(params.RecordingChannels, params.RecordingStatusCallback, params.RecordingStatusCallbackMethod, params.RecordingStatusCallbackEvent)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	accountSIDModel := model.SID(accountSID)
 
 	// Get subaccount state
@@ -444,6 +586,9 @@ func (e *EngineImpl) createCall(params *twilioopenapi.CreateCallParams, parentCa
 		return nil, notFoundError(accountSIDModel)
 	}
 
+	// Look for the account that owns the number before locking this one
+	owner, onNet := e.onNetOwner(to)
+
 	// Lock only this subaccount
 	state.mu.Lock()
 	defer state.mu.Unlock()
@@ -471,7 +616,7 @@ func (e *EngineImpl) createCall(params *twilioopenapi.CreateCallParams, parentCa
 	}
 	now := state.clock.Now()
 	call := &model.Call{
-		SID:                  model.NewCallSID(),
+		SID:                  e.ids.CallSID(),
 		AccountSID:           accountSIDModel,
 		From:                 from,
 		To:                   to,
@@ -483,25 +628,22 @@ func (e *EngineImpl) createCall(params *twilioopenapi.CreateCallParams, parentCa
 		Variables:            make(map[string]string),
 		Url:                  url,
 		Method:               method,
+		FallbackURL:          fallbackURL,
+		FallbackMethod:       fallbackMethod,
 		StatusCallback:       statusCallback,
 		StatusCallbackEvents: statusEvents,
 		CallbackQueue:        make(chan func(), 10), // Buffered to avoid blocking
 	}
 
 	// Start worker goroutine to process callbacks serially for this call
-	go func() {
-		for fn := range call.CallbackQueue {
-			fn() // Execute callback serially
-		}
-	}()
+	e.goTracked(func() { e.runQueue(call.CallbackQueue) })
 
 	if callToken != "" {
 		call.Variables["call_token"] = callToken
 	}
 
-	// Preserve machine detection flag if provided
-	if params.MachineDetection != nil {
-		call.Variables["machine_detection"] = *params.MachineDetection
+	if amd != nil {
+		call.MachineDetection = amd.mode
 	}
 
 	// Record event
@@ -515,43 +657,72 @@ func (e *EngineImpl) createCall(params *twilioopenapi.CreateCallParams, parentCa
 	})
 
 	state.calls[call.SID] = call
+	if recordCfg != nil {
+		state.recordOnAnswer[call.SID] = recordCfg
+	}
 
 	runner := NewCallRunner(call, state, e, timeout)
+	runner.amd = amd
+	runner.participantTwiML = participantTwiML
+	if onNet {
+		runner.onNet = newOnNetRouteLocked(state, parentCallSID, owner)
+	}
 	state.runners[call.SID] = runner
 
 	e.wg.Add(1)
-	go func() {
+	e.goTracked(func() {
 		defer e.wg.Done()
 		runner.Run(e.ctx)
-	}()
+	})
 
 	return buildAPICallResponse(call, e.apiVersion), nil
 }
 
-// CreateIncomingCall simulates an incoming call to a number with an application
+// CreateIncomingCall simulates an incoming call to a number with an application or voice URL
 func (e *EngineImpl) CreateIncomingCall(accountSID model.SID, from string, to string) (*twilioopenapi.ApiV2010Call, error) {
 	return e.createIncomingCallWithParams(accountSID, from, to, map[string]string{}, func(state *subAccountState, call *model.Call) (*model.Call, error) {
-		// Find the incoming number
-		incomingNum := state.incomingNumbers[to]
-		if incomingNum == nil {
-			return nil, fmt.Errorf("to number %s not provisioned for account %s", to, accountSID)
+		if err := configureNumberCallLocked(state, call); err != nil {
+			return nil, err
 		}
+		return call, nil
+	})
+}
+
+// configureNumberCallLocked sets up an inbound call to use the voice
+// configuration of the number it is made to
+func configureNumberCallLocked(state *subAccountState, call *model.Call) error {
+	// Find the incoming number
+	incomingNum := state.incomingNumbers[call.To]
+	if incomingNum == nil {
+		return fmt.Errorf("to number %s not provisioned for account %s", call.To, state.account.SID)
+	}
 
-		// Validate the number has an application configured
-		if incomingNum.VoiceApplication == nil {
-			return nil, fmt.Errorf("number %s does not have a voice application configured", to)
+	if incomingNum.VoiceApplication == nil {
+		// A number without an application uses its own voice URL
+		if incomingNum.VoiceURL == "" {
+			return fmt.Errorf("number %s does not have a voice application configured", call.To)
 		}
-		applicationSID := incomingNum.VoiceApplication
-		if applicationSID == nil {
-			return nil, fmt.Errorf("no application configured for account %s", accountSID)
+		call.Method = incomingNum.VoiceMethod
+		if call.Method == "" {
+			call.Method = http.MethodPost
 		}
-		// Get the application configuration
-		app := state.applications[*applicationSID]
-		call.Method = app.VoiceMethod
-		call.Url = app.VoiceURL
-		call.StatusCallback = app.StatusCallback
-		return call, nil
-	})
+		call.Url = incomingNum.VoiceURL
+		call.FallbackMethod = incomingNum.VoiceFallbackMethod
+		call.FallbackURL = incomingNum.VoiceFallbackURL
+		return nil
+	}
+	// Get the application configuration
+	app := state.applications[*incomingNum.VoiceApplication]
+	call.Method = app.VoiceMethod
+	call.Url = app.VoiceURL
+	call.FallbackMethod = app.VoiceFallbackMethod
+	call.FallbackURL = app.VoiceFallbackURL
+	if call.FallbackURL == "" {
+		call.FallbackMethod = incomingNum.VoiceFallbackMethod
+		call.FallbackURL = incomingNum.VoiceFallbackURL
+	}
+	call.StatusCallback = app.StatusCallback
+	return nil
 }
 
 func (e *EngineImpl) CreateIncomingCallFromSoftphone(accountSID model.SID, from string, to string, accessToken string, params map[string]string) (*twilioopenapi.ApiV2010Call, error) {
@@ -583,6 +754,8 @@ func (e *EngineImpl) CreateIncomingCallFromSoftphone(accountSID model.SID, from
 		app := state.applications[*applicationSID]
 		call.Method = app.VoiceMethod
 		call.Url = app.VoiceURL
+		call.FallbackMethod = app.VoiceFallbackMethod
+		call.FallbackURL = app.VoiceFallbackURL
 		call.StatusCallback = app.StatusCallback
 		return call, nil
 	})
@@ -632,6 +805,8 @@ func (e *EngineImpl) CreateIncomingCallFromSIP(accountSID model.SID, fromSIP str
 
 		call.Method = domainModel.VoiceMethod
 		call.Url = domainModel.VoiceUrl
+		call.FallbackMethod = domainModel.VoiceFallbackMethod
+		call.FallbackURL = domainModel.VoiceFallbackUrl
 		call.StatusCallback = domainModel.VoiceStatusCallbackUrl
 		call.SIPDomainSID = domainModel.SID.String()
 		return call, nil
@@ -655,7 +830,7 @@ func (e *EngineImpl) createIncomingCallWithParams(accountSID model.SID, from str
 	// Create the call with application's configuration
 	now := state.clock.Now()
 	call := &model.Call{
-		SID:                  model.NewCallSID(),
+		SID:                  e.ids.CallSID(),
 		AccountSID:           accountSID,
 		From:                 from,
 		To:                   to,
@@ -673,11 +848,7 @@ func (e *EngineImpl) createIncomingCallWithParams(accountSID model.SID, from str
 		return nil, fmt.Errorf("failed to mutate call: %w", err)
 	}
 	// Start worker goroutine to process callbacks serially for this call
-	go func() {
-		for fn := range call.CallbackQueue {
-			fn() // Execute callback serially
-		}
-	}()
+	e.goTracked(func() { e.runQueue(call.CallbackQueue) })
 
 	// Record event
 	e.addCallEventLocked(state, call, "call.created", map[string]any{
@@ -694,10 +865,10 @@ func (e *EngineImpl) createIncomingCallWithParams(accountSID model.SID, from str
 	state.runners[call.SID] = runner
 
 	e.wg.Add(1)
-	go func() {
+	e.goTracked(func() {
 		defer e.wg.Done()
 		runner.Run(e.ctx)
-	}()
+	})
 
 	return buildAPICallResponse(call, e.apiVersion), nil
 }
@@ -722,11 +893,11 @@ func (e *EngineImpl) CreateIncomingPhoneNumber(params *twilioopenapi.CreateIncom
 	}
 	if phone == "" {
 		if params.AreaCode != nil {
-			// create a random phone number with the given area code using rand.Intn
-			// rand.Intn generates a random int in the range [0, n)
+			// create a random phone number with the given area code using ids.Intn
+			// ids.Intn generates a random int in the range [0, n)
 			// to ensure a valid phone number, we need to add 1000000 to the result
 			// to get a number in the range [1000000, 9999999]
-			phone = fmt.Sprintf("+1%s%s", *params.AreaCode, strconv.Itoa(1000000+rand.Intn(9000000)))
+			phone = fmt.Sprintf("+1%s%s", *params.AreaCode, strconv.Itoa(1000000+e.ids.Intn(9000000)))
 		}
 	}
 
@@ -760,14 +931,42 @@ func (e *EngineImpl) CreateIncomingPhoneNumber(params *twilioopenapi.CreateIncom
 		appValue = string(appSID)
 	}
 
+	var smsAppSID *model.SID
+	if params.SmsApplicationSid != nil && *params.SmsApplicationSid != "" {
+		appSID := model.SID(*params.SmsApplicationSid)
+		if state.applications[appSID] == nil {
+			return nil, fmt.Errorf("application %s not found for account %s", appSID, accountSID)
+		}
+		smsAppSID = &appSID
+	}
+
 	now := state.clock.Now()
-	sid := model.NewPhoneNumberSID()
+	sid := e.ids.PhoneNumberSID()
 	record := &incomingNumber{
 		SID:              sid,
 		PhoneNumber:      phone,
 		VoiceApplication: voiceAppSID,
+		SmsApplication:   smsAppSID,
 		CreatedAt:        now,
 	}
+	if params.VoiceUrl != nil {
+		record.VoiceURL = *params.VoiceUrl
+	}
+	if params.VoiceMethod != nil {
+		record.VoiceMethod = *params.VoiceMethod
+	}
+	if params.SmsUrl != nil {
+		record.SmsURL = *params.SmsUrl
+	}
+	if params.SmsMethod != nil {
+		record.SmsMethod = *params.SmsMethod
+	}
+	if params.VoiceFallbackUrl != nil {
+		record.VoiceFallbackURL = *params.VoiceFallbackUrl
+	}
+	if params.VoiceFallbackMethod != nil {
+		record.VoiceFallbackMethod = *params.VoiceFallbackMethod
+	}
 	state.incomingNumbers[phone] = record
 
 	var appStrPtr *string
@@ -780,6 +979,13 @@ func (e *EngineImpl) CreateIncomingPhoneNumber(params *twilioopenapi.CreateIncom
 		SID:                 string(sid),
 		PhoneNumber:         phone,
 		VoiceApplicationSID: appStrPtr,
+		SmsApplicationSID:   sidStringPtr(smsAppSID),
+		VoiceURL:            record.VoiceURL,
+		VoiceMethod:         record.VoiceMethod,
+		VoiceFallbackURL:    record.VoiceFallbackURL,
+		VoiceFallbackMethod: record.VoiceFallbackMethod,
+		SmsURL:              record.SmsURL,
+		SmsMethod:           record.SmsMethod,
 		CreatedAt:           now,
 	})
 
@@ -796,6 +1002,8 @@ func (e *EngineImpl) CreateIncomingPhoneNumber(params *twilioopenapi.CreateIncom
 		appCopy := appValue
 		resp.VoiceApplicationSid = &appCopy
 	}
+	setIncomingNumberVoiceFields(resp, record)
+	setIncomingNumberSmsFields(resp, record)
 	return resp, nil
 }
 
@@ -825,7 +1033,8 @@ func (e *EngineImpl) ListIncomingPhoneNumber(params *twilioopenapi.ListIncomingP
 	defer state.mu.RUnlock()
 
 	result := make([]twilioopenapi.ApiV2010IncomingPhoneNumber, 0)
-	for phone, rec := range state.incomingNumbers {
+	for _, rec := range sortedValues(state.incomingNumbers, func(n *incomingNumber) string { return string(n.SID) }) {
+		phone := rec.PhoneNumber
 		if filterPhone != "" && phone != filterPhone {
 			continue
 		}
@@ -841,6 +1050,8 @@ func (e *EngineImpl) ListIncomingPhoneNumber(params *twilioopenapi.ListIncomingP
 			appCopy := string(*rec.VoiceApplication)
 			entry.VoiceApplicationSid = &appCopy
 		}
+		setIncomingNumberVoiceFields(&entry, rec)
+		setIncomingNumberSmsFields(&entry, rec)
 		result = append(result, entry)
 	}
 
@@ -911,6 +1122,49 @@ func (e *EngineImpl) UpdateIncomingPhoneNumber(sid string, params *twilioopenapi
 		}
 	}
 
+	// Update messaging configuration if provided
+	if params.SmsApplicationSid != nil {
+		if *params.SmsApplicationSid == "" {
+			foundNumber.SmsApplication = nil
+		} else {
+			appSID := model.SID(*params.SmsApplicationSid)
+			if state.applications[appSID] == nil {
+				return nil, fmt.Errorf("application %s not found for account %s", appSID, state.account.SID)
+			}
+			foundNumber.SmsApplication = &appSID
+		}
+	}
+	if params.SmsUrl != nil {
+		foundNumber.SmsURL = *params.SmsUrl
+	}
+	if params.SmsMethod != nil {
+		foundNumber.SmsMethod = *params.SmsMethod
+	}
+	if params.VoiceUrl != nil {
+		foundNumber.VoiceURL = *params.VoiceUrl
+	}
+	if params.VoiceMethod != nil {
+		foundNumber.VoiceMethod = *params.VoiceMethod
+	}
+	if params.VoiceFallbackUrl != nil {
+		foundNumber.VoiceFallbackURL = *params.VoiceFallbackUrl
+	}
+	if params.VoiceFallbackMethod != nil {
+		foundNumber.VoiceFallbackMethod = *params.VoiceFallbackMethod
+	}
+	for i := range state.account.IncomingNumbers {
+		if state.account.IncomingNumbers[i].SID == string(foundNumber.SID) {
+			state.account.IncomingNumbers[i].VoiceURL = foundNumber.VoiceURL
+			state.account.IncomingNumbers[i].VoiceMethod = foundNumber.VoiceMethod
+			state.account.IncomingNumbers[i].VoiceFallbackURL = foundNumber.VoiceFallbackURL
+			state.account.IncomingNumbers[i].VoiceFallbackMethod = foundNumber.VoiceFallbackMethod
+			state.account.IncomingNumbers[i].SmsApplicationSID = sidStringPtr(foundNumber.SmsApplication)
+			state.account.IncomingNumbers[i].SmsURL = foundNumber.SmsURL
+			state.account.IncomingNumbers[i].SmsMethod = foundNumber.SmsMethod
+			break
+		}
+	}
+
 	// Build response
 	sidStr := string(foundNumber.SID)
 	phoneCopy := foundPhone
@@ -924,10 +1178,32 @@ func (e *EngineImpl) UpdateIncomingPhoneNumber(sid string, params *twilioopenapi
 		appCopy := string(*foundNumber.VoiceApplication)
 		resp.VoiceApplicationSid = &appCopy
 	}
+	setIncomingNumberVoiceFields(resp, foundNumber)
+	setIncomingNumberSmsFields(resp, foundNumber)
 
 	return resp, nil
 }
 
+// setIncomingNumberVoiceFields sets the voice URL and fallback of a number in an API response
+func setIncomingNumberVoiceFields(resp *twilioopenapi.ApiV2010IncomingPhoneNumber, rec *incomingNumber) {
+	if rec.VoiceURL != "" {
+		voiceURL := rec.VoiceURL
+		resp.VoiceUrl = &voiceURL
+	}
+	if rec.VoiceMethod != "" {
+		voiceMethod := rec.VoiceMethod
+		resp.VoiceMethod = &voiceMethod
+	}
+	if rec.VoiceFallbackURL != "" {
+		fallbackURL := rec.VoiceFallbackURL
+		resp.VoiceFallbackUrl = &fallbackURL
+	}
+	if rec.VoiceFallbackMethod != "" {
+		fallbackMethod := rec.VoiceFallbackMethod
+		resp.VoiceFallbackMethod = &fallbackMethod
+	}
+}
+
 // DeleteIncomingPhoneNumber removes a provisioned number
 func (e *EngineImpl) DeleteIncomingPhoneNumber(sid string, params *twilioopenapi.DeleteIncomingPhoneNumberParams) error {
 	if params == nil || params.PathAccountSid == nil || *params.PathAccountSid == "" {
@@ -981,6 +1257,14 @@ func (e *EngineImpl) CreateApplication(params *twilioopenapi.CreateApplicationPa
 	if params.VoiceMethod != nil {
 		voiceMethod = *params.VoiceMethod
 	}
+	voiceFallbackURL := ""
+	if params.VoiceFallbackUrl != nil {
+		voiceFallbackURL = *params.VoiceFallbackUrl
+	}
+	voiceFallbackMethod := ""
+	if params.VoiceFallbackMethod != nil {
+		voiceFallbackMethod = *params.VoiceFallbackMethod
+	}
 	statusCallback := ""
 	if params.StatusCallback != nil {
 		statusCallback = *params.StatusCallback
@@ -989,6 +1273,18 @@ func (e *EngineImpl) CreateApplication(params *twilioopenapi.CreateApplicationPa
 	if params.StatusCallbackMethod != nil {
 		statusCallbackMethod = *params.StatusCallbackMethod
 	}
+	smsURL := ""
+	if params.SmsUrl != nil {
+		smsURL = *params.SmsUrl
+	}
+	smsMethod := ""
+	if params.SmsMethod != nil {
+		smsMethod = *params.SmsMethod
+	}
+	smsStatusCallback := ""
+	if params.SmsStatusCallback != nil {
+		smsStatusCallback = *params.SmsStatusCallback
+	}
 
 	// Get subaccount state
 	e.subAccountsMu.RLock()
@@ -1004,14 +1300,19 @@ func (e *EngineImpl) CreateApplication(params *twilioopenapi.CreateApplicationPa
 	defer state.mu.Unlock()
 
 	now := state.clock.Now()
-	sid := model.NewApplicationSID()
+	sid := e.ids.ApplicationSID()
 	rec := &applicationRecord{
 		SID:                  sid,
 		FriendlyName:         friendly,
 		VoiceMethod:          voiceMethod,
 		VoiceURL:             voiceURL,
+		VoiceFallbackMethod:  voiceFallbackMethod,
+		VoiceFallbackURL:     voiceFallbackURL,
 		StatusCallbackMethod: statusCallbackMethod,
 		StatusCallback:       statusCallback,
+		SmsURL:               smsURL,
+		SmsMethod:            smsMethod,
+		SmsStatusCallback:    smsStatusCallback,
 		CreatedAt:            now,
 	}
 	state.applications[sid] = rec
@@ -1020,8 +1321,13 @@ func (e *EngineImpl) CreateApplication(params *twilioopenapi.CreateApplicationPa
 		FriendlyName:         friendly,
 		VoiceMethod:          voiceMethod,
 		VoiceURL:             voiceURL,
+		VoiceFallbackMethod:  voiceFallbackMethod,
+		VoiceFallbackURL:     voiceFallbackURL,
 		StatusCallbackMethod: statusCallbackMethod,
 		StatusCallback:       statusCallback,
+		SmsURL:               smsURL,
+		SmsMethod:            smsMethod,
+		SmsStatusCallback:    smsStatusCallback,
 		CreatedAt:            now,
 	})
 
@@ -1029,9 +1335,16 @@ func (e *EngineImpl) CreateApplication(params *twilioopenapi.CreateApplicationPa
 	dateCreated := now.UTC().Format(time.RFC1123Z)
 
 	return &twilioopenapi.ApiV2010Application{
-		Sid:          &sidStr,
-		FriendlyName: &friendly,
-		DateCreated:  &dateCreated,
+		Sid:                 &sidStr,
+		FriendlyName:        &friendly,
+		DateCreated:         &dateCreated,
+		VoiceUrl:            &voiceURL,
+		VoiceMethod:         &voiceMethod,
+		VoiceFallbackUrl:    &voiceFallbackURL,
+		VoiceFallbackMethod: &voiceFallbackMethod,
+		SmsUrl:              &smsURL,
+		SmsMethod:           &smsMethod,
+		SmsStatusCallback:   &smsStatusCallback,
 	}, nil
 }
 
@@ -1122,7 +1435,7 @@ func (e *EngineImpl) CreateAddress(params *twilioopenapi.CreateAddressParams) (*
 	defer state.mu.Unlock()
 
 	now := state.clock.Now()
-	sid := model.NewAddressSID()
+	sid := e.ids.AddressSID()
 
 	// Extract optional parameters
 	friendlyName := ""
@@ -1217,8 +1530,8 @@ func (e *EngineImpl) CreateNewSigningKey(params *twilioopenapi.CreateNewSigningK
 	defer state.mu.Unlock()
 
 	now := state.clock.Now()
-	sid := model.NewSigningKeySID()
-	secret := model.NewSigningKeySecret()
+	sid := e.ids.SigningKeySID()
+	secret := e.ids.SigningKeySecret()
 
 	// Create the signing key
 	signingKey := &model.SigningKey{
@@ -1273,7 +1586,7 @@ func (e *EngineImpl) CreateSipDomain(params *twilioopenapi.CreateSipDomainParams
 	defer state.mu.Unlock()
 
 	now := state.clock.Now()
-	sid := model.NewSipDomainSID()
+	sid := e.ids.SipDomainSID()
 
 	// Extract parameters
 	domainName := *params.DomainName
@@ -1289,6 +1602,14 @@ func (e *EngineImpl) CreateSipDomain(params *twilioopenapi.CreateSipDomainParams
 	if params.VoiceMethod != nil {
 		voiceMethod = *params.VoiceMethod
 	}
+	voiceFallbackUrl := ""
+	if params.VoiceFallbackUrl != nil {
+		voiceFallbackUrl = *params.VoiceFallbackUrl
+	}
+	voiceFallbackMethod := "POST"
+	if params.VoiceFallbackMethod != nil {
+		voiceFallbackMethod = *params.VoiceFallbackMethod
+	}
 	voiceStatusCallbackUrl := ""
 	if params.VoiceStatusCallbackUrl != nil {
 		voiceStatusCallbackUrl = *params.VoiceStatusCallbackUrl
@@ -1314,6 +1635,8 @@ func (e *EngineImpl) CreateSipDomain(params *twilioopenapi.CreateSipDomainParams
 		FriendlyName:              friendlyName,
 		VoiceUrl:                  voiceUrl,
 		VoiceMethod:               voiceMethod,
+		VoiceFallbackUrl:          voiceFallbackUrl,
+		VoiceFallbackMethod:       voiceFallbackMethod,
 		VoiceStatusCallbackUrl:    voiceStatusCallbackUrl,
 		VoiceStatusCallbackMethod: voiceStatusCallbackMethod,
 		SipRegistration:           sipRegistration,
@@ -1340,6 +1663,8 @@ func (e *EngineImpl) CreateSipDomain(params *twilioopenapi.CreateSipDomainParams
 		FriendlyName:              &friendlyName,
 		VoiceUrl:                  &voiceUrl,
 		VoiceMethod:               &voiceMethod,
+		VoiceFallbackUrl:          &voiceFallbackUrl,
+		VoiceFallbackMethod:       &voiceFallbackMethod,
 		VoiceStatusCallbackUrl:    &voiceStatusCallbackUrl,
 		VoiceStatusCallbackMethod: &voiceStatusCallbackMethod,
 		SipRegistration:           &sipRegistration,
@@ -1370,7 +1695,7 @@ func (e *EngineImpl) ListSipCredentialList(params *twilioopenapi.ListSipCredenti
 	defer state.mu.RUnlock()
 
 	results := make([]twilioopenapi.ApiV2010SipCredentialList, 0, len(state.sipCredentialLists))
-	for _, credList := range state.sipCredentialLists {
+	for _, credList := range sortedValues(state.sipCredentialLists, func(l *model.SipCredentialList) string { return string(l.SID) }) {
 		sidStr := string(credList.SID)
 		accountSidStr := string(credList.AccountSID)
 		dateCreated := credList.CreatedAt.UTC().Format(time.RFC1123Z)
@@ -1415,7 +1740,7 @@ func (e *EngineImpl) CreateSipCredentialList(params *twilioopenapi.CreateSipCred
 	defer state.mu.Unlock()
 
 	now := state.clock.Now()
-	sid := model.NewSipCredentialListSID()
+	sid := e.ids.SipCredentialListSID()
 
 	// Create the SIP credential list
 	credentialList := &model.SipCredentialList{
@@ -1482,7 +1807,7 @@ func (e *EngineImpl) CreateSipAuthCallsCredentialListMapping(DomainSid string, p
 	}
 
 	now := state.clock.Now()
-	sid := model.NewSipAuthCallsMappingSID()
+	sid := e.ids.SipAuthCallsMappingSID()
 
 	// Create the mapping
 	mapping := &model.SipAuthCallsCredentialListMapping{
@@ -1550,7 +1875,7 @@ func (e *EngineImpl) CreateSipAuthRegistrationsCredentialListMapping(DomainSid s
 	}
 
 	now := state.clock.Now()
-	sid := model.NewSipAuthRegistrationsMappingSID()
+	sid := e.ids.SipAuthRegistrationsMappingSID()
 
 	// Create the mapping
 	mapping := &model.SipAuthRegistrationsCredentialListMapping{
@@ -1678,7 +2003,7 @@ func (e *EngineImpl) CreateSipCredential(CredentialListSid string, params *twili
 	}
 
 	now := state.clock.Now()
-	sid := model.NewSipCredentialSID()
+	sid := e.ids.SipCredentialSID()
 
 	// Create the SIP credential
 	credential := &model.SipCredential{
@@ -1739,7 +2064,7 @@ func (e *EngineImpl) ListSipCredential(CredentialListSid string, params *twilioo
 
 	// Find all credentials for this credential list
 	results := make([]twilioopenapi.ApiV2010SipCredential, 0)
-	for _, credential := range state.sipCredentials {
+	for _, credential := range sortedValues(state.sipCredentials, func(c *model.SipCredential) string { return string(c.SID) }) {
 		if credential.CredentialListSID == credentialListSID {
 			sidStr := string(credential.SID)
 			accountSidStr := string(credential.AccountSID)
@@ -1801,6 +2126,14 @@ func (e *EngineImpl) UpdateCall(sid string, params *twilioopenapi.UpdateCallPara
 		updatedFields["url"] = *params.Url
 		urlUpdated = true
 	}
+	if params.FallbackUrl != nil {
+		call.FallbackURL = *params.FallbackUrl
+		updatedFields["fallback_url"] = *params.FallbackUrl
+	}
+	if params.FallbackMethod != nil {
+		call.FallbackMethod = *params.FallbackMethod
+		updatedFields["fallback_method"] = *params.FallbackMethod
+	}
 	if params.StatusCallback != nil {
 		call.StatusCallback = *params.StatusCallback
 		updatedFields["status_callback"] = *params.StatusCallback
@@ -1872,9 +2205,7 @@ func (e *EngineImpl) AnswerCall(subaccountSID, callSID model.SID) error {
 	})
 
 	if runner != nil {
-		runner.answerOnce.Do(func() {
-			close(runner.answerCh)
-		})
+		runner.broadcast(&runner.answerOnce, runner.answerCh)
 	}
 
 	return nil
@@ -1909,9 +2240,7 @@ func (e *EngineImpl) SetCallBusy(subaccountSID, callSID model.SID) error {
 	})
 
 	if runner != nil {
-		runner.busyOnce.Do(func() {
-			close(runner.busyCh)
-		})
+		runner.broadcast(&runner.busyOnce, runner.busyCh)
 	}
 
 	return nil
@@ -1946,9 +2275,7 @@ func (e *EngineImpl) SetCallFailed(subaccountSID, callSID model.SID) error {
 	})
 
 	if runner != nil {
-		runner.failedOnce.Do(func() {
-			close(runner.failedCh)
-		})
+		runner.broadcast(&runner.failedOnce, runner.failedCh)
 	}
 
 	return nil
@@ -2025,6 +2352,82 @@ func (e *EngineImpl) SendDigits(subaccountSID, callSID model.SID, digits string)
 	return nil
 }
 
+// SendSpeech simulates the caller saying transcript while a gather is
+// listening. Speech at any other time is an error.
+func (e *EngineImpl) SendSpeech(subaccountSID, callSID model.SID, transcript string, confidence float64) error {
+	if strings.TrimSpace(transcript) == "" {
+		return fmt.Errorf("transcript is required")
+	}
+	if confidence < 0 || confidence > 1 {
+		return fmt.Errorf("confidence must be between 0 and 1")
+	}
+	// Get subaccount state
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[subaccountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return notFoundError(subaccountSID)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	call, exists := state.calls[callSID]
+	if !exists {
+		return notFoundError(callSID)
+	}
+	runner := state.runners[callSID]
+	if runner == nil {
+		return notFoundError(callSID)
+	}
+
+	if !runner.sendSpeechLocked(transcript, confidence) {
+		return fmt.Errorf("call %s is not gathering input or already has pending speech input", callSID)
+	}
+	e.addCallEventLocked(state, call, "call.speech_sent", map[string]any{
+		"call_sid":   callSID,
+		"transcript": transcript,
+		"confidence": confidence,
+	})
+	return nil
+}
+
+// SendSilence simulates the caller going silent. A <Record> in progress ends
+// once the silence lasts its timeout, and a <Dial> with trim="trim-silence"
+// cuts the silence from the end of its recording.
+func (e *EngineImpl) SendSilence(subaccountSID, callSID model.SID) error {
+	// Get subaccount state
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[subaccountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return notFoundError(subaccountSID)
+	}
+
+	state.mu.Lock()
+	call, exists := state.calls[callSID]
+	if !exists {
+		state.mu.Unlock()
+		return notFoundError(callSID)
+	}
+	runner := state.runners[callSID]
+	if runner == nil {
+		state.mu.Unlock()
+		return notFoundError(callSID)
+	}
+
+	e.addCallEventLocked(state, call, "call.silence_sent", map[string]any{
+		"call_sid": callSID,
+	})
+	state.mu.Unlock()
+
+	if !runner.SendSilence() {
+		return fmt.Errorf("call %s already has pending silence", callSID)
+	}
+	return nil
+}
+
 // FetchCall returns a Twilio-style call response
 func (e *EngineImpl) FetchCall(sid string, params *twilioopenapi.FetchCallParams) (*twilioopenapi.ApiV2010Call, error) {
 	if params == nil || params.PathAccountSid == nil || *params.PathAccountSid == "" {
@@ -2053,6 +2456,57 @@ func (e *EngineImpl) FetchCall(sid string, params *twilioopenapi.FetchCallParams
 	return resp, nil
 }
 
+// ListCall returns Twilio-style call responses for an account, most recent first
+func (e *EngineImpl) ListCall(params *twilioopenapi.ListCallParams) ([]twilioopenapi.ApiV2010Call, error) {
+	if params == nil || params.PathAccountSid == nil || *params.PathAccountSid == "" {
+		return nil, fmt.Errorf("PathAccountSid is required")
+	}
+	accountSID := model.SID(*params.PathAccountSid)
+	// Get subaccount state
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[accountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return nil, notFoundError(accountSID)
+	}
+
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	matches := make([]*model.Call, 0, len(state.calls))
+	for _, call := range state.calls {
+		if params.To != nil && *params.To != "" && call.To != *params.To {
+			continue
+		}
+		if params.From != nil && *params.From != "" && call.From != *params.From {
+			continue
+		}
+		if params.ParentCallSid != nil && *params.ParentCallSid != "" {
+			if call.ParentCallSID == nil || string(*call.ParentCallSID) != *params.ParentCallSid {
+				continue
+			}
+		}
+		if params.Status != nil && *params.Status != "" && string(call.Status) != *params.Status {
+			continue
+		}
+		matches = append(matches, call)
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].StartAt.Equal(matches[j].StartAt) {
+			return matches[i].SID > matches[j].SID
+		}
+		return matches[i].StartAt.After(matches[j].StartAt)
+	})
+
+	result := make([]twilioopenapi.ApiV2010Call, len(matches))
+	for i, call := range matches {
+		result[i] = *buildAPICallResponse(call, e.apiVersion)
+	}
+	return result, nil
+}
+
 // FetchConference returns a Twilio-style conference response by SID
 func (e *EngineImpl) FetchConference(sid string, params *twilioopenapi.FetchConferenceParams) (*twilioopenapi.ApiV2010Conference, error) {
 	if params == nil || params.PathAccountSid == nil || *params.PathAccountSid == "" {
@@ -2110,7 +2564,7 @@ func (e *EngineImpl) ListConference(params *twilioopenapi.ListConferenceParams)
 	defer state.mu.RUnlock()
 
 	result := make([]twilioopenapi.ApiV2010Conference, 0)
-	for _, conf := range state.conferences {
+	for _, conf := range sortedValues(state.conferences, func(c *model.Conference) string { return string(c.SID) }) {
 		// Filter by friendly name if provided (friendly name is the conference Name)
 		if friendlyName != "" && conf.Name != friendlyName {
 			continue
@@ -2166,21 +2620,14 @@ func (e *EngineImpl) UpdateConference(sid string, params *twilioopenapi.UpdateCo
 			conf.Status = model.ConferenceCompleted
 			now := state.clock.Now()
 			conf.EndedAt = &now
-			conf.Timeline = append(conf.Timeline, model.NewEvent(
-				now,
-				"conference.ended",
-				map[string]any{
-					"reason": "updated_via_api",
-				},
-			))
+			e.addConferenceEventLocked(state, conf, "conference.ended", map[string]any{
+				"reason": "updated_via_api",
+			})
 			// Notify all participants to exit the conference
 			for _, participantSID := range conf.Participants {
 				if runner, exists := state.runners[participantSID]; exists {
-					select {
-					case runner.conferenceCompleteCh <- struct{}{}:
-					default:
-						// Channel full or already signaled
-					}
+					// A pending signal is enough if the channel is full
+					notify(e, runner.conferenceCompleteCh, struct{}{})
 				}
 			}
 		case "in-progress":
@@ -2188,8 +2635,14 @@ func (e *EngineImpl) UpdateConference(sid string, params *twilioopenapi.UpdateCo
 		}
 	}
 
-	// Note: AnnounceUrl and AnnounceMethod are in params but not used for now
-	// as per requirements
+	// Play the announcement to everyone still in the conference
+	if params.AnnounceUrl != nil && *params.AnnounceUrl != "" && conf.Status != model.ConferenceCompleted {
+		announceMethod := ""
+		if params.AnnounceMethod != nil {
+			announceMethod = *params.AnnounceMethod
+		}
+		e.announceLocked(state, conf, conf.Participants, *params.AnnounceUrl, announceMethod, nil)
+	}
 
 	sidStr := string(conf.SID)
 	status := string(conf.Status)
@@ -2244,14 +2697,15 @@ func (e *EngineImpl) FetchParticipant(conferenceSid string, callSid string, para
 		return nil, fmt.Errorf("call %s is not a participant in conference %s", callSid, conferenceSid)
 	}
 
-	// Return participant with CallSid and ConferenceSid
-	callSidStr := callSid
-	conferenceSidStr := conferenceSid
-
-	return &twilioopenapi.ApiV2010Participant{
-		CallSid:       &callSidStr,
-		ConferenceSid: &conferenceSidStr,
-	}, nil
+	call, exists := state.calls[callSIDModel]
+	if !exists {
+		return nil, notFoundError(callSIDModel)
+	}
+	partState := state.participantStates[conf.SID][callSIDModel]
+	if partState == nil {
+		partState = &model.ParticipantState{}
+	}
+	return buildAPIParticipantResponse(conf, call, partState), nil
 }
 
 // UpdateParticipant updates a participant in a conference
@@ -2317,7 +2771,6 @@ func (e *EngineImpl) UpdateParticipant(conferenceSid string, callSid string, par
 	}
 
 	// Update participant state
-	now := state.clock.Now()
 	updatedFields := make(map[string]any)
 
 	if params.Muted != nil {
@@ -2353,21 +2806,17 @@ func (e *EngineImpl) UpdateParticipant(conferenceSid string, callSid string, par
 	// Add timeline event to the call if any fields were updated
 	if len(updatedFields) > 0 {
 		updatedFields["conference_sid"] = conferenceSid
-		call.Timeline = append(call.Timeline, model.NewEvent(
-			now,
-			"participant.updated",
-			updatedFields,
-		))
+		e.addCallEventLocked(state, call, "participant.updated", updatedFields)
 	}
 
-	// Return participant with CallSid and ConferenceSid
-	callSidStr := callSid
-	conferenceSidStr := conferenceSid
+	if params.Hold != nil || params.HoldUrl != nil || params.HoldMethod != nil {
+		notifyParticipantLocked(state, callSIDModel)
+	}
+	if params.AnnounceUrl != nil && *params.AnnounceUrl != "" {
+		e.announceLocked(state, conf, []model.SID{callSIDModel}, partState.AnnounceUrl, partState.AnnounceMethod, &callSIDModel)
+	}
 
-	return &twilioopenapi.ApiV2010Participant{
-		CallSid:       &callSidStr,
-		ConferenceSid: &conferenceSidStr,
-	}, nil
+	return buildAPIParticipantResponse(conf, call, partState), nil
 }
 
 // FetchRecording returns a recording by SID
@@ -2376,11 +2825,18 @@ func (e *EngineImpl) FetchRecording(sid string, params *twilioopenapi.FetchRecor
 		return nil, fmt.Errorf("PathAccountSid is required")
 	}
 	accountSID := model.SID(*params.PathAccountSid)
-	recordingSID := model.SID(sid)
 
-	// Get the recording
-	recording, err := e.GetRecording(accountSID, recordingSID)
-	if err != nil {
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[accountSID]
+	e.subAccountsMu.RUnlock()
+
+	var recording *model.Recording
+	if exists {
+		state.mu.RLock()
+		defer state.mu.RUnlock()
+		recording = state.recordings[model.SID(sid)]
+	}
+	if recording == nil {
 		// Return "absent" status if recording not found (Twilio-like behavior)
 		sidStr := sid
 		status := "absent"
@@ -2390,34 +2846,7 @@ func (e *EngineImpl) FetchRecording(sid string, params *twilioopenapi.FetchRecor
 		}, nil
 	}
 
-	// Build Twilio-style response
-	sidStr := sid
-	accountSIDStr := string(recording.AccountSID)
-	status := recording.Status
-	duration := fmt.Sprintf("%d", recording.Duration)
-	dateCreated := recording.CreatedAt.UTC().Format(time.RFC1123Z)
-
-	resp := &twilioopenapi.ApiV2010Recording{
-		Sid:         &sidStr,
-		AccountSid:  &accountSIDStr,
-		Status:      &status,
-		Duration:    &duration,
-		DateCreated: &dateCreated,
-	}
-
-	// Add CallSid if this recording is associated with a call
-	if recording.CallSID != nil {
-		callSIDStr := string(*recording.CallSID)
-		resp.CallSid = &callSIDStr
-	}
-
-	// Generate recording URL using baseURL if set
-	if e.baseURL != "" {
-		uri := fmt.Sprintf("%s/Accounts/%s/Recordings/%s", e.baseURL, recording.AccountSID, recordingSID)
-		resp.Uri = &uri
-	}
-
-	return resp, nil
+	return e.buildAPIRecordingResponse(state, recording), nil
 }
 
 // GetCallState exposes the internal call model for inspection (tests, console)
@@ -2519,14 +2948,16 @@ func (e *EngineImpl) Snapshot(accountSID model.SID) (*StateSnapshot, error) {
 	defer state.mu.RUnlock()
 
 	snap := &StateSnapshot{
-		engine:      e,
-		accountSID:  accountSID,
-		Calls:       make(map[model.SID]*model.Call),
-		Queues:      make(map[string]*model.Queue),
-		Conferences: make(map[string]*model.Conference),
-		SubAccounts: make(map[model.SID]*model.SubAccount),
-		Recordings:  make(map[model.SID]*model.Recording),
-		Timestamp:   state.clock.Now(),
+		engine:         e,
+		accountSID:     accountSID,
+		Calls:          make(map[model.SID]*model.Call),
+		Queues:         make(map[string]*model.Queue),
+		Conferences:    make(map[string]*model.Conference),
+		SubAccounts:    make(map[model.SID]*model.SubAccount),
+		Recordings:     make(map[model.SID]*model.Recording),
+		Transcriptions: make(map[model.SID]*model.Transcription),
+		Messages:       make(map[model.SID]*model.Message),
+		Timestamp:      state.clock.Now(),
 	}
 
 	// Only include calls for this subaccount
@@ -2562,6 +2993,19 @@ func (e *EngineImpl) Snapshot(accountSID model.SID) (*StateSnapshot, error) {
 		snap.Recordings[sid] = &recordingCopy
 	}
 
+	for sid, transcription := range state.transcriptions {
+		transcriptionCopy := *transcription
+		snap.Transcriptions[sid] = &transcriptionCopy
+	}
+
+	// Only include messages for this subaccount
+	for sid, msg := range state.messages {
+		msgCopy := *msg
+		msgCopy.MediaURLs = append([]string(nil), msg.MediaURLs...)
+		msgCopy.Timeline = append([]model.Event{}, msg.Timeline...)
+		snap.Messages[sid] = &msgCopy
+	}
+
 	// Copy errors state.errors
 	snap.Errors = append(snap.Errors, state.errors...)
 
@@ -2654,6 +3098,10 @@ func buildAPICallResponse(call *model.Call, apiVersion string) *twilioopenapi.Ap
 		to := call.To
 		resp.To = &to
 	}
+	if call.AnsweredBy != "" {
+		answeredBy := call.AnsweredBy
+		resp.AnsweredBy = &answeredBy
+	}
 	if call.EndedAt != nil {
 		end := call.EndedAt.UTC().Format(time.RFC1123Z)
 		resp.EndTime = &end
@@ -2771,36 +3219,36 @@ func (e *EngineImpl) updateCallStatusLocked(state *subAccountState, call *model.
 	call.Status = newStatus
 
 	// Add timeline event
-	call.Timeline = append(call.Timeline, model.NewEvent(
-		state.clock.Now(),
-		"status.changed",
-		map[string]any{
-			"from": oldStatus,
-			"to":   newStatus,
-		},
-	))
+	e.addCallEventLocked(state, call, "status.changed", map[string]any{
+		"from": oldStatus,
+		"to":   newStatus,
+	})
 
 	// Trigger status callback if configured and user is interested in this event
 	if call.StatusCallback != "" && e.shouldSendStatusCallback(call, newStatus) {
 		// Queue the callback for serial execution
-		call.CallbackQueue <- func() {
+		e.enqueue(call.CallbackQueue, func() {
 			e.sendCallStatusCallback(state, call)
-		}
+		})
 	} else {
 		// skipped status callback
-		call.Timeline = append(call.Timeline, model.NewEvent(
-			state.clock.Now(),
-			"webhook.status_callback_skipped",
-			map[string]any{
-				"from": oldStatus,
-				"to":   newStatus,
-			}))
+		e.addCallEventLocked(state, call, "webhook.status_callback_skipped", map[string]any{
+			"from": oldStatus,
+			"to":   newStatus,
+		})
+	}
+
+	// A call created with Record=true starts recording once answered
+	if cfg, ok := state.recordOnAnswer[call.SID]; ok && newStatus == model.CallInProgress {
+		delete(state.recordOnAnswer, call.SID)
+		e.startCallRecordingLocked(state, call, cfg, "OutboundAPI")
 	}
 
 	// Close the callback queue when call reaches terminal status
 	// This allows the worker goroutine to exit cleanly
 	if newStatus.IsTerminal() {
-		close(call.CallbackQueue)
+		e.endCallRecordingsLocked(state, call)
+		e.closeQueue(call.CallbackQueue)
 	}
 }
 
@@ -2853,32 +3301,94 @@ func (e *EngineImpl) addCallEvent(state *subAccountState, call *model.Call, even
 	e.addCallEventLocked(state, call, eventType, detail)
 }
 
-func (e *EngineImpl) addCallEventLocked(state *subAccountState, call *model.Call, eventType string, detail map[string]any) {
-	call.Timeline = append(call.Timeline, model.NewEvent(
-		state.clock.Now(),
-		eventType,
-		detail,
-	))
-}
-
 func (e *EngineImpl) addConferenceEvent(state *subAccountState, cnf *model.Conference, eventType string, detail map[string]any) {
 	state.mu.Lock()
 	defer state.mu.Unlock()
-	cnf.Timeline = append(cnf.Timeline, model.NewEvent(
-		state.clock.Now(),
-		eventType,
-		detail,
-	))
+	e.addConferenceEventLocked(state, cnf, eventType, detail)
+}
+
+// signedHeaders returns the headers for a webhook request, including the
+// X-Twilio-Signature computed with the account's auth token
+func (e *EngineImpl) signedHeaders(state *subAccountState, targetURL string, form url.Values) http.Header {
+	signature := httpstub.ComputeSignature(state.account.AuthToken, targetURL, form)
+	if e.corruptSignatures {
+		signature = httpstub.ComputeSignature("corrupted"+state.account.AuthToken, targetURL, form)
+	}
+	headers := make(http.Header)
+	headers.Set(httpstub.SignatureHeader, signature)
+	return headers
+}
+
+// callRequestContext tags a webhook request with the call it is made for, so
+// that webhook clients can match it and record events on the call's timeline
+func (e *EngineImpl) callRequestContext(ctx context.Context, state *subAccountState, call *model.Call) context.Context {
+	return httpstub.WithRequestInfo(ctx, httpstub.RequestInfo{
+		CallSID: string(call.SID),
+		Clock:   webhookClock{engine: e, clock: state.clock},
+		Record: func(eventType string, detail map[string]any) {
+			e.addCallEvent(state, call, eventType, detail)
+		},
+	})
+}
+
+// webhookClock is the clock handed to webhook clients. Waiting on it parks
+// the calling goroutine, so the engine settles while a response is delayed.
+type webhookClock struct {
+	engine *EngineImpl
+	clock  Clock
+}
+
+func (c webhookClock) After(d time.Duration) <-chan time.Time {
+	return c.clock.After(d)
+}
+
+func (c webhookClock) Wait(ctx context.Context, d time.Duration) error {
+	if !c.engine.sleep(ctx, c.clock, d) {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// postCallback sends a signed notification webhook whose response body is
+// ignored. Non-2xx responses are returned as errors.
+func (e *EngineImpl) postCallback(ctx context.Context, state *subAccountState, method, targetURL string, form url.Values) error {
+	reqCtx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	var status int
+	var err error
+	if method == "GET" {
+		u, parseErr := url.Parse(targetURL)
+		if parseErr != nil {
+			return fmt.Errorf("failed to parse URL %s: %w", targetURL, parseErr)
+		}
+		q := u.Query()
+		for k, v := range form {
+			q[k] = v
+		}
+		u.RawQuery = q.Encode()
+		signedURL := u.String()
+		status, _, _, err = e.webhook.GET(reqCtx, signedURL, e.signedHeaders(state, signedURL, nil))
+	} else {
+		status, _, _, err = e.webhook.POST(reqCtx, targetURL, form, e.signedHeaders(state, targetURL, form))
+	}
+	if err != nil {
+		return fmt.Errorf("webhook request to %s failed: %w", targetURL, err)
+	}
+	if status < 200 || status >= 300 {
+		return fmt.Errorf("webhook URL %s returned status %d", targetURL, status)
+	}
+	return nil
 }
 
 // sendCallStatusCallback posts to the status callback URL
 func (e *EngineImpl) sendCallStatusCallback(state *subAccountState, call *model.Call) {
 	form := e.buildCallbackForm(state.clock, call)
 
-	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	ctx, cancel := context.WithTimeout(e.callRequestContext(context.Background(), state, call), e.timeout)
 	defer cancel()
 
-	status, body, headers, err := e.webhook.POST(ctx, call.StatusCallback, form)
+	status, body, headers, err := e.webhook.POST(ctx, call.StatusCallback, form, e.signedHeaders(state, call.StatusCallback, form))
 	if err != nil {
 		e.addCallEvent(state, call, "webhook.status_callback.error", map[string]any{
 			"url":   call.StatusCallback,
@@ -2932,6 +3442,9 @@ func (e *EngineImpl) buildCallbackForm(clock Clock, call *model.Call) url.Values
 	if call.SIPDomainSID != "" {
 		form.Set("SipDomainSid", call.SIPDomainSID)
 	}
+	if call.AnsweredBy != "" {
+		form.Set("AnsweredBy", call.AnsweredBy)
+	}
 	return form
 }
 
@@ -2955,6 +3468,10 @@ func (e *EngineImpl) shouldSendConferenceStatusCallback(conf *model.Conference,
 		apiEventName = "join"
 	case "participant-leave":
 		apiEventName = "leave"
+	case "participant-hold", "participant-unhold":
+		apiEventName = "hold"
+	case "announcement-end":
+		apiEventName = "announcement"
 	default:
 		apiEventName = eventType
 	}
@@ -2985,7 +3502,7 @@ func (e *EngineImpl) sendConferenceStatusCallback(state *subAccountState, conf *
 		e.recordError(state, err)
 		return
 	}
-	status, body, headers, err := e.webhook.POST(ctx, resolvedURL, form)
+	status, body, headers, err := e.webhook.POST(ctx, resolvedURL, form, e.signedHeaders(state, resolvedURL, form))
 	// Check for non-2xx status codes
 	if status < 200 || status >= 300 {
 		e.addConferenceEvent(state, conf, "play.error", map[string]any{
@@ -3018,8 +3535,8 @@ func (e *EngineImpl) buildConferenceCallbackForm(clock Clock, conf *model.Confer
 	form.Set("AccountSid", string(conf.AccountSID))
 	form.Set("Timestamp", clock.Now().Format(time.RFC3339))
 
-	// For participant events (join/leave), include the CallSid
-	if callSID != nil && (eventType == "participant-join" || eventType == "participant-leave") {
+	// For participant events, include the CallSid
+	if callSID != nil {
 		form.Set("CallSid", string(*callSID))
 	}
 
@@ -3034,17 +3551,12 @@ func (e *EngineImpl) getOrCreateQueueLocked(state *subAccountState, accountSID m
 
 	queue := &model.Queue{
 		Name:       name,
-		SID:        model.NewQueueSID(),
+		SID:        e.ids.QueueSID(),
 		AccountSID: accountSID,
 		Members:    []model.SID{},
 		Timeline:   []model.Event{},
 	}
-	now := state.clock.Now()
-	queue.Timeline = append(queue.Timeline, model.NewEvent(
-		now,
-		"queue.created",
-		map[string]any{"name": name, "sid": queue.SID, "account_sid": accountSID},
-	))
+	e.addQueueEventLocked(state, queue, "queue.created", map[string]any{"name": name, "sid": queue.SID, "account_sid": accountSID})
 	state.queues[name] = queue
 	return queue
 }
@@ -3065,7 +3577,7 @@ func (e *EngineImpl) getOrCreateConferenceLocked(state *subAccountState, account
 	now := state.clock.Now()
 	conf := &model.Conference{
 		Name:          cnf.Name,
-		SID:           model.NewConferenceSID(),
+		SID:           e.ids.ConferenceSID(),
 		AccountSID:    accountSID,
 		Participants:  []model.SID{},
 		Status:        model.ConferenceCreated,
@@ -3083,17 +3595,9 @@ func (e *EngineImpl) getOrCreateConferenceLocked(state *subAccountState, account
 	}
 
 	// Start worker goroutine to process callbacks serially for this conference
-	go func() {
-		for fn := range conf.CallbackQueue {
-			fn() // Execute callback serially
-		}
-	}()
+	e.goTracked(func() { e.runQueue(conf.CallbackQueue) })
 
-	conf.Timeline = append(conf.Timeline, model.NewEvent(
-		now,
-		"conference.created",
-		map[string]any{"name": cnf.Name, "sid": conf.SID, "account_sid": accountSID},
-	))
+	e.addConferenceEventLocked(state, conf, "conference.created", map[string]any{"name": cnf.Name, "sid": conf.SID, "account_sid": accountSID})
 	state.conferences[cnf.Name] = conf
 	return conf
 }
@@ -3150,7 +3654,7 @@ func (e *EngineImpl) SetCallRecording(accountSID model.SID, callSID model.SID, f
 	}
 
 	// Generate a new recording SID every time (even for the same file)
-	recordingSID := model.NewRecordingSID()
+	recordingSID := e.ids.RecordingSID()
 	now := state.clock.Now()
 
 	recording := &model.Recording{
@@ -3189,7 +3693,7 @@ func (e *EngineImpl) SetCallVoicemail(accountSID model.SID, callSID model.SID, f
 	}
 
 	// Generate a new recording SID every time (even for the same file)
-	recordingSID := model.NewRecordingSID()
+	recordingSID := e.ids.RecordingSID()
 	now := state.clock.Now()
 
 	recording := &model.Recording{
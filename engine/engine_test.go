@@ -5,6 +5,7 @@
 package engine_test
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -697,6 +698,98 @@ func mustProvisionNumbers(t *testing.T, e *engine.EngineImpl, accountSID model.S
 	}
 }
 
+// newTestEngine creates an engine on a manual clock with a subaccount that owns
+// +15550001111. Webhooks to the URLs in routes return their TwiML and every
+// other webhook returns an empty document.
+func newTestEngine(t *testing.T, routes map[string]string, opts ...engine.EngineOption) (*engine.EngineImpl, *httpstub.MockWebhookClient, *model.SubAccount) {
+	t.Helper()
+	mock := newTwiMLMock(routes)
+	e := engine.NewEngine(append([]engine.EngineOption{engine.WithManualClock(), engine.WithWebhookClient(mock)}, opts...)...)
+	t.Cleanup(func() { e.Close() })
+
+	subAccount := createTestSubAccount(t, e, "Test")
+	mustProvisionNumbers(t, e, subAccount.SID, "+15550001111")
+	return e, mock, subAccount
+}
+
+// newTwiMLMock answers webhooks to the URLs in routes with their TwiML and
+// every other webhook with an empty document. GET requests carry their
+// parameters in the query, which is ignored when matching routes.
+func newTwiMLMock(routes map[string]string) *httpstub.MockWebhookClient {
+	mock := httpstub.NewMockWebhookClient()
+	mock.ResponseFunc = func(targetURL string, form url.Values) (int, []byte, http.Header, error) {
+		target, _, _ := strings.Cut(targetURL, "?")
+		body, ok := routes[target]
+		if !ok {
+			body = `<Response></Response>`
+		}
+		return 200, []byte(body), make(http.Header), nil
+	}
+	return mock
+}
+
+// startCall places a call from +15550001111 to http://test/answer and answers it
+func startCall(t *testing.T, e *engine.EngineImpl, accountSID model.SID) *model.Call {
+	t.Helper()
+	call := mustCreateCall(t, e, newCreateCallParams(accountSID, "+15550001111", "+15552223333", "http://test/answer"))
+	answerCall(t, e, accountSID, call.SID)
+	return call
+}
+
+// answerCall answers a ringing call once the engine settles and settles it again
+func answerCall(t *testing.T, e *engine.EngineImpl, accountSID, sid model.SID) {
+	t.Helper()
+	settle(t, e)
+	if err := e.AnswerCall(accountSID, sid); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+}
+
+// sendDigits sends DTMF digits to a call and settles the engine
+func sendDigits(t *testing.T, e *engine.EngineImpl, accountSID, sid model.SID, digits string) {
+	t.Helper()
+	if err := e.SendDigits(accountSID, sid, digits); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+}
+
+// settle waits for every call of the engine to block on the clock or input
+func settle(t *testing.T, e *engine.EngineImpl) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.Settle(ctx); err != nil {
+		t.Fatalf("engine did not settle: %v", err)
+	}
+}
+
+// childCalls returns the calls placed by a parent's <Dial>
+func childCalls(t *testing.T, e *engine.EngineImpl, accountSID, parentSID model.SID) []model.SID {
+	t.Helper()
+	parent, ok := e.GetCallState(accountSID, parentSID)
+	if !ok {
+		t.Fatalf("call %s not found", parentSID)
+	}
+	return parent.ChildCallSIDs
+}
+
+// findEvent returns the first event of the given type on a call's timeline
+func findEvent(call *model.Call, eventType string) *model.Event {
+	for i, event := range call.Timeline {
+		if event.Type == eventType {
+			return &call.Timeline[i]
+		}
+	}
+	return nil
+}
+
+// hasEvent reports whether a call's timeline has an event of the given type
+func hasEvent(call *model.Call, eventType string) bool {
+	return findEvent(call, eventType) != nil
+}
+
 func TestUpdateCall(t *testing.T) {
 	e := engine.NewEngine(engine.WithManualClock())
 	defer e.Close()
@@ -1393,6 +1486,52 @@ func TestSIDLength(t *testing.T) {
 	}
 }
 
+func TestWithSeed(t *testing.T) {
+	type generated struct {
+		accountSID model.SID
+		authToken  string
+		callSID    model.SID
+		keySID     string
+		keySecret  string
+		number     string
+	}
+	run := func(seed int64) generated {
+		e := engine.NewEngine(engine.WithManualClock(), engine.WithSeed(seed))
+		defer e.Close()
+
+		account, err := e.CreateAccount((&twilioopenapi.CreateAccountParams{}).SetFriendlyName("Seeded"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		accountSID := model.SID(*account.Sid)
+		mustProvisionNumbers(t, e, accountSID, "+15550001111")
+		call := mustCreateCall(t, e, newCreateCallParams(accountSID, "+15550001111", "+15552223333", "http://test/answer"))
+		key, err := e.CreateNewSigningKey((&twilioopenapi.CreateNewSigningKeyParams{}).SetPathAccountSid(string(accountSID)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		number, err := e.CreateIncomingPhoneNumber((&twilioopenapi.CreateIncomingPhoneNumberParams{}).
+			SetPathAccountSid(string(accountSID)).
+			SetAreaCode("415"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return generated{accountSID, *account.AuthToken, call.SID, *key.Sid, *key.Secret, *number.PhoneNumber}
+	}
+
+	first := run(42)
+	if second := run(42); second != first {
+		t.Errorf("expected the same IDs for the same seed, got %+v and %+v", first, second)
+	}
+	if other := run(7); other.accountSID == first.accountSID || other.authToken == first.authToken {
+		t.Errorf("expected different IDs for a different seed, got %+v", other)
+	}
+	// Counters belong to the engine, so the first call of every engine is number 1
+	if !strings.HasPrefix(string(first.callSID), "CAFAKE00000000000001") {
+		t.Errorf("expected the first call SID of the engine, got %s", first.callSID)
+	}
+}
+
 func TestRecordWithAction(t *testing.T) {
 	mock := httpstub.NewMockWebhookClient()
 	recordActionCalled := false
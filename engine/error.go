@@ -12,6 +12,18 @@ import (
 
 const ErrorCodeResourceNotFound = 20404
 
+const (
+	// ErrorCodeHTTPRetrievalFailure is reported when a TwiML webhook fails or
+	// returns a non-2xx status
+	ErrorCodeHTTPRetrievalFailure = 11200
+	// ErrorCodeDocumentParseFailure is reported when a TwiML webhook returns a
+	// document that does not parse
+	ErrorCodeDocumentParseFailure = 12100
+	// ErrorCodeSchemaValidation is reported for TwiML that is not valid where
+	// it is used, such as <Reject> on an answered call
+	ErrorCodeSchemaValidation = 12200
+)
+
 func notFoundError(sid model.SID) *client.TwilioRestError {
 	return &client.TwilioRestError{
 		Code:     ErrorCodeResourceNotFound,
@@ -21,3 +33,14 @@ func notFoundError(sid model.SID) *client.TwilioRestError {
 		Status:   ErrorCodeResourceNotFound,
 	}
 }
+
+// twimlFetchError is a failure to fetch TwiML, with the Twilio error code that
+// is passed to the fallback URL
+type twimlFetchError struct {
+	code int
+	err  error
+}
+
+func (e *twimlFetchError) Error() string { return e.err.Error() }
+
+func (e *twimlFetchError) Unwrap() error { return e.err }
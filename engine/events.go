@@ -0,0 +1,204 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/sprucehealth/twimulator/model"
+)
+
+// EngineEvent is a timeline event published to subscribers, tagged with the
+// resources it belongs to. SIDs that do not apply are empty.
+type EngineEvent struct {
+	model.Event
+	AccountSID    model.SID `json:"account_sid"`
+	CallSID       model.SID `json:"call_sid,omitempty"`
+	ConferenceSID model.SID `json:"conference_sid,omitempty"`
+	QueueSID      model.SID `json:"queue_sid,omitempty"`
+}
+
+// EventFilter selects the events a subscriber receives. Empty fields match
+// every event.
+type EventFilter struct {
+	// TypePrefixes matches events whose type starts with any of the prefixes,
+	// such as "twiml.gather" or "participant."
+	TypePrefixes  []string
+	AccountSID    model.SID
+	CallSID       model.SID
+	ConferenceSID model.SID
+	QueueSID      model.SID
+}
+
+// Matches reports whether the filter selects the event
+func (f EventFilter) Matches(ev EngineEvent) bool {
+	if f.AccountSID != "" && f.AccountSID != ev.AccountSID {
+		return false
+	}
+	if f.CallSID != "" && f.CallSID != ev.CallSID {
+		return false
+	}
+	if f.ConferenceSID != "" && f.ConferenceSID != ev.ConferenceSID {
+		return false
+	}
+	if f.QueueSID != "" && f.QueueSID != ev.QueueSID {
+		return false
+	}
+	if len(f.TypePrefixes) == 0 {
+		return true
+	}
+	for _, prefix := range f.TypePrefixes {
+		if strings.HasPrefix(ev.Type, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// subscription buffers events for one subscriber so that publishing never
+// blocks the engine while it holds a subaccount lock
+type subscription struct {
+	filter EventFilter
+	ch     chan EngineEvent
+
+	mu      sync.Mutex
+	pending []EngineEvent
+	notify  chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// Subscribe returns a channel of the events that match the filter as they are
+// added to call, conference and queue timelines. Events are delivered in order
+// and are never dropped. The channel is closed when cancel is called or the
+// engine is closed.
+func (e *EngineImpl) Subscribe(filter EventFilter) (<-chan EngineEvent, func()) {
+	sub := &subscription{
+		filter: filter,
+		ch:     make(chan EngineEvent),
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+
+	e.subscriptionsMu.Lock()
+	e.subscriptions[sub] = struct{}{}
+	e.subscriptionsMu.Unlock()
+
+	cancel := func() {
+		e.subscriptionsMu.Lock()
+		delete(e.subscriptions, sub)
+		e.subscriptionsMu.Unlock()
+		sub.once.Do(func() { close(sub.done) })
+	}
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		defer close(sub.ch)
+		sub.run(e.ctx.Done())
+	}()
+	return sub.ch, cancel
+}
+
+// run delivers pending events until the subscription is canceled or stop is closed
+func (s *subscription) run(stop <-chan struct{}) {
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-stop:
+			return
+		case <-s.notify:
+		}
+		s.mu.Lock()
+		events := s.pending
+		s.pending = nil
+		s.mu.Unlock()
+		for _, ev := range events {
+			select {
+			case s.ch <- ev:
+			case <-s.done:
+				return
+			case <-stop:
+				return
+			}
+		}
+	}
+}
+
+func (s *subscription) push(ev EngineEvent) {
+	s.mu.Lock()
+	s.pending = append(s.pending, ev)
+	s.mu.Unlock()
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// publish sends an event to every subscriber whose filter matches it
+func (e *EngineImpl) publish(ev EngineEvent) {
+	e.subscriptionsMu.RLock()
+	defer e.subscriptionsMu.RUnlock()
+	for sub := range e.subscriptions {
+		if sub.filter.Matches(ev) {
+			sub.push(ev)
+		}
+	}
+}
+
+// addCallEventLocked adds an event to a call's timeline. Caller must hold state.mu.
+func (e *EngineImpl) addCallEventLocked(state *subAccountState, call *model.Call, eventType string, detail map[string]any) {
+	event := model.NewEvent(state.clock.Now(), eventType, detail)
+	call.Timeline = append(call.Timeline, event)
+
+	ev := EngineEvent{Event: event, AccountSID: call.AccountSID, CallSID: call.SID}
+	if name, ok := strings.CutPrefix(call.CurrentEndpoint, "conference:"); ok {
+		if conf := state.conferences[name]; conf != nil {
+			ev.ConferenceSID = conf.SID
+		}
+	} else if name, ok := strings.CutPrefix(call.CurrentEndpoint, "queue:"); ok {
+		if queue := state.queues[name]; queue != nil {
+			ev.QueueSID = queue.SID
+		}
+	}
+	e.publish(ev)
+}
+
+// addConferenceEventLocked adds an event to a conference's timeline. Caller must hold state.mu.
+func (e *EngineImpl) addConferenceEventLocked(state *subAccountState, conf *model.Conference, eventType string, detail map[string]any) {
+	event := model.NewEvent(state.clock.Now(), eventType, detail)
+	conf.Timeline = append(conf.Timeline, event)
+	e.publish(EngineEvent{
+		Event:         event,
+		AccountSID:    conf.AccountSID,
+		CallSID:       eventCallSID(detail),
+		ConferenceSID: conf.SID,
+	})
+}
+
+// addQueueEventLocked adds an event to a queue's timeline. Caller must hold state.mu.
+func (e *EngineImpl) addQueueEventLocked(state *subAccountState, queue *model.Queue, eventType string, detail map[string]any) {
+	event := model.NewEvent(state.clock.Now(), eventType, detail)
+	queue.Timeline = append(queue.Timeline, event)
+	e.publish(EngineEvent{
+		Event:      event,
+		AccountSID: queue.AccountSID,
+		CallSID:    eventCallSID(detail),
+		QueueSID:   queue.SID,
+	})
+}
+
+// eventCallSID returns the call a conference or queue event is about, if any
+func eventCallSID(detail map[string]any) model.SID {
+	switch sid := detail["call_sid"].(type) {
+	case model.SID:
+		return sid
+	case string:
+		return model.SID(sid)
+	}
+	return ""
+}
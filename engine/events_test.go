@@ -0,0 +1,82 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/sprucehealth/twimulator/engine"
+	"github.com/sprucehealth/twimulator/httpstub"
+)
+
+// nextEvent waits for the next event on a subscription
+func nextEvent(t *testing.T, events <-chan engine.EngineEvent) engine.EngineEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-events:
+		if !ok {
+			t.Fatal("subscription closed")
+		}
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	return engine.EngineEvent{}
+}
+
+func TestSubscribe(t *testing.T) {
+	mock := httpstub.NewMockWebhookClient()
+	mock.ResponseFunc = func(targetURL string, form url.Values) (int, []byte, http.Header, error) {
+		if targetURL == "http://test/gather" {
+			return 200, []byte(`<Response><Gather timeout="600"></Gather></Response>`), make(http.Header), nil
+		}
+		return 200, []byte(`<Response><Dial><Conference>room</Conference></Dial></Response>`), make(http.Header), nil
+	}
+	e := engine.NewEngine(engine.WithManualClock(), engine.WithWebhookClient(mock))
+	defer e.Close()
+
+	subAccount := createTestSubAccount(t, e, "Subscribe")
+	mustProvisionNumbers(t, e, subAccount.SID, "+15550001111")
+
+	gathers, cancelGathers := e.Subscribe(engine.EventFilter{TypePrefixes: []string{"twiml.gather"}})
+	joins, cancelJoins := e.Subscribe(engine.EventFilter{AccountSID: subAccount.SID, TypePrefixes: []string{"participant.joined"}})
+	defer cancelJoins()
+
+	gatherCall := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/gather"))
+	ringing, cancelRinging := e.Subscribe(engine.EventFilter{CallSID: gatherCall.SID, TypePrefixes: []string{"status.changed"}})
+	answerCall(t, e, subAccount.SID, gatherCall.SID)
+	ev := nextEvent(t, gathers)
+	if ev.CallSID != gatherCall.SID || ev.AccountSID != subAccount.SID {
+		t.Errorf("expected the gather of %s, got %+v", gatherCall.SID, ev)
+	}
+	if ev := nextEvent(t, ringing); ev.Detail["to"] == nil {
+		t.Errorf("expected a status change, got %+v", ev)
+	}
+	cancelRinging()
+
+	// Canceling closes the channel
+	cancelGathers()
+	for range gathers {
+	}
+
+	first := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15554445555", "http://test/answer"))
+	second := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15556667777", "http://test/answer"))
+	answerCall(t, e, subAccount.SID, first.SID)
+	answerCall(t, e, subAccount.SID, second.SID)
+
+	conf, ok := e.GetConference(subAccount.SID, "room")
+	if !ok {
+		t.Fatal("conference not found")
+	}
+	for _, call := range []string{string(first.SID), string(second.SID)} {
+		ev := nextEvent(t, joins)
+		if string(ev.CallSID) != call || ev.ConferenceSID != conf.SID {
+			t.Errorf("expected %s to join %s, got %+v", call, conf.SID, ev)
+		}
+	}
+}
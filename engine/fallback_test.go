@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+
+	"github.com/sprucehealth/twimulator/engine"
+	"github.com/sprucehealth/twimulator/httpstub"
+	"github.com/sprucehealth/twimulator/model"
+	"github.com/sprucehealth/twimulator/twimtest"
+)
+
+// newFallbackClient serves a menu and a fallback, and fails http://test/down
+// with a 503 and http://test/garbled with a document that does not parse
+func newFallbackClient() (*httpstub.FaultInjectingClient, *httpstub.MockWebhookClient) {
+	mock := newTwiMLMock(map[string]string{
+		"http://test/menu":     `<Response><Gather numDigits="1" action="http://test/down"><Say>Press 1</Say></Gather></Response>`,
+		"http://test/fallback": `<Response><Say>All agents are unavailable</Say></Response>`,
+	})
+	client := httpstub.NewFaultInjectingClient(mock).
+		AddRule(httpstub.FaultRule{URLPattern: "http://test/down", Fault: httpstub.FaultStatus, Status: 503}).
+		AddRule(httpstub.FaultRule{URLPattern: "http://test/garbled", Fault: httpstub.FaultInvalidTwiML})
+	return client, mock
+}
+
+func TestCallFallbackURL(t *testing.T) {
+	client, mock := newFallbackClient()
+	e, _, subAccount := newTestEngine(t, nil, engine.WithWebhookClient(client))
+
+	params := newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/down")
+	params.SetFallbackUrl("http://test/fallback")
+	call := mustCreateCall(t, e, params)
+	ctx := context.Background()
+	if err := e.Settle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AnswerCall(subAccount.SID, call.SID); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Settle(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	twimtest.ExpectCall(t, e, call.SID).
+		Said("All agents are unavailable").
+		EndedWith(model.CallCompleted)
+	twimtest.ExpectWebhook(t, mock, "http://test/fallback").
+		WithFormValues(url.Values{
+			"ErrorCode": {"11200"},
+			"ErrorUrl":  {"http://test/down"},
+			"CallSid":   {string(call.SID)},
+		}).
+		Times(1)
+
+	got, _ := e.GetCallState(subAccount.SID, call.SID)
+	if !hasEvent(got, "webhook.fallback") {
+		t.Error("expected the fallback to be recorded on the timeline")
+	}
+	var responses []any
+	for _, ev := range got.Timeline {
+		if ev.Type == "webhook.response" {
+			responses = append(responses, ev.Detail["url"])
+		}
+	}
+	if len(responses) != 2 || responses[0] != "http://test/down" || responses[1] != "http://test/fallback" {
+		t.Errorf("expected both attempts on the timeline, got %v", responses)
+	}
+
+	// Without a fallback URL the call fails
+	failing := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/down"))
+	if err := e.Settle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AnswerCall(subAccount.SID, failing.SID); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Settle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := e.GetCallState(subAccount.SID, failing.SID); got.Status != model.CallFailed {
+		t.Errorf("expected the call without a fallback to fail, got %s", got.Status)
+	}
+}
+
+func TestApplicationFallbackURL(t *testing.T) {
+	client, mock := newFallbackClient()
+	e, _, subAccount := newTestEngine(t, nil, engine.WithWebhookClient(client))
+	accountSID := string(subAccount.SID)
+
+	app, err := e.CreateApplication((&twilioopenapi.CreateApplicationParams{}).
+		SetPathAccountSid(accountSID).
+		SetVoiceUrl("http://test/garbled").
+		SetVoiceFallbackUrl("http://test/fallback").
+		SetVoiceFallbackMethod("GET"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.VoiceFallbackUrl == nil || *app.VoiceFallbackUrl != "http://test/fallback" {
+		t.Errorf("expected the fallback URL in the response, got %v", app.VoiceFallbackUrl)
+	}
+	number, err := e.CreateIncomingPhoneNumber((&twilioopenapi.CreateIncomingPhoneNumberParams{}).
+		SetPathAccountSid(accountSID).
+		SetPhoneNumber("+15550002222").
+		SetVoiceApplicationSid(*app.Sid).
+		SetVoiceFallbackUrl("http://test/number-fallback"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if number.VoiceFallbackUrl == nil || *number.VoiceFallbackUrl != "http://test/number-fallback" {
+		t.Errorf("expected the number's fallback URL in the response, got %v", number.VoiceFallbackUrl)
+	}
+
+	apiCall, err := e.CreateIncomingCall(subAccount.SID, "+15552223333", "+15550002222")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Settle(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The application's fallback takes precedence over the number's
+	twimtest.ExpectCall(t, e, model.SID(*apiCall.Sid)).Said("All agents are unavailable")
+	calls := mock.Calls
+	last := calls[len(calls)-1]
+	fallback, err := url.Parse(last.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := fallback.Query()
+	if fallback.Path != "/fallback" || query.Get("ErrorCode") != "12100" || query.Get("ErrorUrl") != "http://test/garbled" {
+		t.Errorf("expected a GET to the fallback URL with the parse error, got %s", last.URL)
+	}
+}
+
+func TestActionFallbackURL(t *testing.T) {
+	client, mock := newFallbackClient()
+	e, _, subAccount := newTestEngine(t, nil, engine.WithWebhookClient(client))
+
+	params := newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/menu")
+	params.SetFallbackUrl("http://test/fallback")
+	call := mustCreateCall(t, e, params)
+	settle(t, e)
+	if err := e.AnswerCall(subAccount.SID, call.SID); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	if err := e.SendDigits(subAccount.SID, call.SID, "1"); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	// The gather action fails and the call retries against its fallback URL
+	twimtest.ExpectCall(t, e, call.SID).
+		Said("All agents are unavailable").
+		EndedWith(model.CallCompleted)
+	twimtest.ExpectWebhook(t, mock, "http://test/fallback").
+		WithFormValues(url.Values{
+			"ErrorCode": {"11200"},
+			"ErrorUrl":  {"http://test/down"},
+			"Digits":    {"1"},
+		}).
+		Times(1)
+}
@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sprucehealth/twimulator/engine"
+	"github.com/sprucehealth/twimulator/httpstub"
+	"github.com/sprucehealth/twimulator/model"
+	"github.com/sprucehealth/twimulator/twimtest"
+)
+
+// welcomeRoutes answers calls to http://test/answer for the fault tests
+var welcomeRoutes = map[string]string{
+	"http://test/answer": `<Response><Say>Welcome to the clinic</Say></Response>`,
+}
+
+func TestFaultInjectingClientLatency(t *testing.T) {
+	client := httpstub.NewFaultInjectingClient(newTwiMLMock(welcomeRoutes)).AddRule(httpstub.FaultRule{
+		URLPattern: "http://test/*",
+		Fault:      httpstub.FaultLatency,
+		Latency:    5 * time.Second,
+		Nth:        1,
+	})
+	e, _, subAccount := newTestEngine(t, nil, engine.WithWebhookClient(client))
+	sid := startCall(t, e, subAccount.SID).SID
+
+	// The response waits on the engine clock
+	call, _ := e.GetCallState(subAccount.SID, sid)
+	if hasEvent(call, "twiml.say") {
+		t.Fatal("expected the TwiML to wait for the injected latency")
+	}
+	if ev := findEvent(call, "webhook.fault"); ev == nil || ev.Detail["latency"] != "5s" {
+		t.Errorf("expected the latency on the timeline, got %+v", ev)
+	}
+	e.Advance(5 * time.Second)
+	settle(t, e)
+	twimtest.ExpectCall(t, e, sid).Said("Welcome to the clinic").EndedWith(model.CallCompleted)
+
+	// Only the first matching request is delayed
+	twimtest.ExpectCall(t, e, startCall(t, e, subAccount.SID).SID).Said("Welcome to the clinic")
+}
+
+func TestFaultInjectingClientTimeout(t *testing.T) {
+	client := httpstub.NewFaultInjectingClient(newTwiMLMock(welcomeRoutes))
+	e, _, subAccount := newTestEngine(t, nil, engine.WithWebhookClient(client))
+	sid := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/answer")).SID
+	client.AddRule(httpstub.FaultRule{CallSID: string(sid), Fault: httpstub.FaultTimeout})
+	answerCall(t, e, subAccount.SID, sid)
+
+	// Other calls are not affected
+	twimtest.ExpectCall(t, e, startCall(t, e, subAccount.SID).SID).Said("Welcome to the clinic")
+
+	call, _ := e.GetCallState(subAccount.SID, sid)
+	if call.Status != model.CallInProgress {
+		t.Fatalf("expected the call to wait for the timeout, got %s", call.Status)
+	}
+
+	e.Advance(time.Minute)
+	settle(t, e)
+	call, _ = e.GetCallState(subAccount.SID, sid)
+	if call.Status != model.CallFailed {
+		t.Errorf("expected the timed out call to fail, got %s", call.Status)
+	}
+	if findEvent(call, "webhook.fault") == nil {
+		t.Error("expected the timeout on the timeline")
+	}
+}
+
+func TestFaultInjectingClientFaults(t *testing.T) {
+	for _, fault := range []httpstub.Fault{
+		httpstub.FaultStatus,
+		httpstub.FaultConnectionReset,
+		httpstub.FaultTruncatedBody,
+		httpstub.FaultInvalidTwiML,
+	} {
+		t.Run(string(fault), func(t *testing.T) {
+			client := httpstub.NewFaultInjectingClient(newTwiMLMock(welcomeRoutes)).
+				AddRule(httpstub.FaultRule{URLPattern: "http://test/answer", Fault: fault})
+			e, _, subAccount := newTestEngine(t, nil, engine.WithWebhookClient(client))
+			sid := startCall(t, e, subAccount.SID).SID
+
+			call, _ := e.GetCallState(subAccount.SID, sid)
+			if call.Status != model.CallFailed {
+				t.Errorf("expected the call to fail, got %s", call.Status)
+			}
+			if ev := findEvent(call, "webhook.fault"); ev == nil || ev.Detail["fault"] != string(fault) {
+				t.Errorf("expected the fault on the timeline, got %+v", ev)
+			}
+		})
+	}
+}
+
+func TestFaultInjectingClientProbability(t *testing.T) {
+	count := func(seed uint64) int {
+		client := httpstub.NewFaultInjectingClient(httpstub.NewMockWebhookClient()).
+			Seed(seed).
+			AddRule(httpstub.FaultRule{Fault: httpstub.FaultStatus, Status: 502, Probability: 0.5})
+		failed := 0
+		for range 50 {
+			status, _, _, err := client.POST(context.Background(), "http://test/status", url.Values{}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status == 502 {
+				failed++
+			}
+		}
+		return failed
+	}
+	failed := count(7)
+	if failed == 0 || failed == 50 {
+		t.Errorf("expected some requests to fail, got %d of 50", failed)
+	}
+	if again := count(7); again != failed {
+		t.Errorf("expected the same seed to fail the same requests, got %d and %d", failed, again)
+	}
+
+	_, _, _, err := httpstub.NewFaultInjectingClient(httpstub.NewMockWebhookClient()).
+		AddRule(httpstub.FaultRule{Fault: httpstub.FaultConnectionReset}).
+		GET(context.Background(), "http://test/media.mp3", nil)
+	if err == nil || !strings.Contains(err.Error(), "connection reset") {
+		t.Errorf("expected a connection reset, got %v", err)
+	}
+}
@@ -163,11 +163,12 @@ func TestTwiMLWithGatherSimpleComparison(t *testing.T) {
 	expected := []any{
 		&twiml.Say{Loop: 1, Text: "Welcome", Voice: "", Language: ""},
 		&twiml.Gather{
-			Input:     "dtmf",
-			Timeout:   "5",
-			NumDigits: 1,
-			Action:    "http://test/gather",
-			Method:    "POST",
+			Input:       "dtmf",
+			Timeout:     "5",
+			NumDigits:   1,
+			FinishOnKey: "#",
+			Action:      "http://test/gather",
+			Method:      "POST",
 			Children: []twiml.Node{
 				&twiml.Say{Loop: 1, Text: "Press 1 for sales", Voice: "", Language: ""},
 				&twiml.Say{Loop: 1, Text: "Press 2 for support", Voice: "", Language: ""},
@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sprucehealth/twimulator/model"
+	"github.com/sprucehealth/twimulator/twimtest"
+)
+
+// gatherRoutes answer calls with answer and the menu action with a prompt
+func gatherRoutes(answer string) map[string]string {
+	return map[string]string{
+		"http://test/answer": answer,
+		"http://test/menu":   `<Response><Say>Menu</Say></Response>`,
+	}
+}
+
+func TestGatherInterDigitTimeout(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, gatherRoutes(`<Response><Gather timeout="5" action="http://test/menu"><Say>Enter your account number</Say></Gather></Response>`))
+	sid := startCall(t, e, subAccount.SID).SID
+
+	// Each digit restarts the timeout
+	sendDigits(t, e, subAccount.SID, sid, "1")
+	e.Advance(4 * time.Second)
+	settle(t, e)
+	sendDigits(t, e, subAccount.SID, sid, "2")
+	e.Advance(4 * time.Second)
+	settle(t, e)
+	if len(mock.Calls) != 1 {
+		t.Fatalf("expected the gather to wait for more digits, got requests %v", mock.Calls)
+	}
+
+	e.Advance(time.Second)
+	settle(t, e)
+	twimtest.ExpectWebhook(t, mock, "http://test/menu").WithForm("Digits", "12").Times(1)
+	twimtest.ExpectCall(t, e, sid).Gathered().ThenSaid("Menu")
+}
+
+func TestGatherDefaultFinishOnKey(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, gatherRoutes(`<Response><Gather action="http://test/menu"/></Response>`))
+	sid := startCall(t, e, subAccount.SID).SID
+	sendDigits(t, e, subAccount.SID, sid, "34#")
+	twimtest.ExpectWebhook(t, mock, "http://test/menu").WithForm("Digits", "34").Times(1)
+	twimtest.ExpectCall(t, e, sid).Gathered(twimtest.WithFinishOnKey("#")).ThenSaid("Menu")
+
+	// An empty finishOnKey disables it
+	e, mock, subAccount = newTestEngine(t, gatherRoutes(`<Response><Gather finishOnKey="" timeout="5" action="http://test/menu"/></Response>`))
+	sid = startCall(t, e, subAccount.SID).SID
+	sendDigits(t, e, subAccount.SID, sid, "34#")
+	e.Advance(5 * time.Second)
+	settle(t, e)
+	twimtest.ExpectWebhook(t, mock, "http://test/menu").WithForm("Digits", "34#").Times(1)
+}
+
+func TestGatherNoInput(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, gatherRoutes(`<Response><Gather timeout="3" action="http://test/menu"><Say>Press 1 for billing</Say></Gather><Say>We did not receive any input</Say></Response>`))
+	sid := startCall(t, e, subAccount.SID).SID
+	e.Advance(3 * time.Second)
+	settle(t, e)
+
+	// Without input the call falls through to the next verb
+	twimtest.ExpectCall(t, e, sid).
+		Said("Press 1 for billing").
+		ThenGathered().
+		ThenSaid("We did not receive any input").
+		EndedWith(model.CallCompleted)
+	for _, req := range mock.Calls {
+		if req.URL == "http://test/menu" {
+			t.Error("expected no action request without input")
+		}
+	}
+	call, _ := e.GetCallState(subAccount.SID, sid)
+	if !hasEvent(call, "gather.no_input") {
+		t.Error("expected the missing input on the timeline")
+	}
+}
+
+func TestGatherActionOnEmptyResult(t *testing.T) {
+	const answer = `<Response><Gather timeout="3" actionOnEmptyResult="true" action="http://test/menu"/><Say>Unreachable</Say></Response>`
+
+	e, mock, subAccount := newTestEngine(t, gatherRoutes(answer))
+	sid := startCall(t, e, subAccount.SID).SID
+	e.Advance(3 * time.Second)
+	settle(t, e)
+	twimtest.ExpectWebhook(t, mock, "http://test/menu").WithForm("Digits", "").Times(1)
+	twimtest.ExpectCall(t, e, sid).Gathered().ThenSaid("Menu").EndedWith(model.CallCompleted)
+
+	// Pressing only the finish key is an empty result too
+	e, mock, subAccount = newTestEngine(t, gatherRoutes(answer))
+	sid = startCall(t, e, subAccount.SID).SID
+	sendDigits(t, e, subAccount.SID, sid, "#")
+	twimtest.ExpectWebhook(t, mock, "http://test/menu").WithForm("Digits", "").Times(1)
+	twimtest.ExpectCall(t, e, sid).Gathered().ThenSaid("Menu")
+}
@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/sprucehealth/twimulator/engine"
+	"github.com/sprucehealth/twimulator/httpstub"
+	"github.com/sprucehealth/twimulator/model"
+	"github.com/sprucehealth/twimulator/twimtest"
+)
+
+func TestHandlerWebhookClient(t *testing.T) {
+	var authToken string
+	app := http.NewServeMux()
+	app.HandleFunc("POST /voice", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !httpstub.ValidateSignature(authToken, "http://app.test"+r.RequestURI, r.PostForm, r.Header.Get(httpstub.SignatureHeader)) {
+			http.Error(w, "invalid signature", http.StatusForbidden)
+			return
+		}
+		fmt.Fprintf(w, `<Response><Play>http://media.test/hello.mp3</Play><Gather numDigits="1" action="/menu?caller=%s" timeout="600"></Gather></Response>`, url.QueryEscape(r.PostForm.Get("From")))
+	})
+	app.HandleFunc("POST /menu", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<Response><Say>Hello %s, you pressed %s</Say><Hangup/></Response>`, r.URL.Query().Get("caller"), r.PostFormValue("Digits"))
+	})
+	media := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "audio/mpeg")
+	})
+
+	client := httpstub.NewHandlerWebhookClient().
+		Handle("app.test", app).
+		Handle("media.test/", media)
+	e := engine.NewEngine(engine.WithManualClock(), engine.WithWebhookClient(client))
+	defer e.Close()
+
+	subAccount := createTestSubAccount(t, e, "Handlers")
+	authToken = subAccount.AuthToken
+	mustProvisionNumbers(t, e, subAccount.SID, "+15550001111")
+
+	call := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://app.test/voice"))
+	ctx := context.Background()
+	if err := e.Settle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AnswerCall(subAccount.SID, call.SID); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Settle(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SendDigits(subAccount.SID, call.SID, "7"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Settle(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	twimtest.ExpectCall(t, e, call.SID).
+		Played("http://media.test/hello.mp3").
+		ThenGathered(twimtest.WithNumDigits(1)).
+		ThenSaid("Hello +15550001111, you pressed 7").
+		ThenHungUp().
+		EndedWith(model.CallCompleted)
+
+	var methods []string
+	for _, served := range client.Calls() {
+		methods = append(methods, served.Request.Method+" "+served.Request.URL.String())
+		if served.Request.URL.Path == "/voice" && served.Recorder.Code != http.StatusOK {
+			t.Errorf("expected the voice handler to accept the signed request, got %d: %s", served.Recorder.Code, served.Recorder.Body)
+		}
+	}
+	want := []string{
+		"POST http://app.test/voice",
+		"HEAD http://media.test/hello.mp3",
+		"POST http://app.test/menu?caller=%2B15550001111",
+	}
+	if len(methods) < len(want) || fmt.Sprint(methods[:len(want)]) != fmt.Sprint(want) {
+		t.Errorf("expected requests %q, got %q", want, methods)
+	}
+
+	if _, _, _, err := client.POST(ctx, "http://unknown.test/voice", nil, nil); err == nil {
+		t.Error("expected an error for a URL with no handler")
+	}
+}
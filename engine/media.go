@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sprucehealth/twimulator/twiml"
+)
+
+const (
+	// defaultSpeechRate is how many characters per second <Say> speaks
+	defaultSpeechRate = 15.0
+)
+
+// speechRates holds the characters per second of voices that speak slower than
+// the default, such as the legacy man and woman voices
+var speechRates = map[string]float64{
+	"man":   12.0,
+	"woman": 12.0,
+	"alice": 13.0,
+}
+
+// mp3Bitrates holds the Layer III bitrates in kbps by bitrate index for MPEG-1
+// and for MPEG-2/2.5
+var mp3Bitrates = [2][16]int{
+	{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+	{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+}
+
+// WithTimedMedia makes <Say>, <Play> and <Pause> take as long on the engine
+// clock as they would on a real call. <Say> is timed from its text and voice and
+// <Play> from the WAV or MP3 headers of the media. Media in wait and hold music
+// TwiML is not timed.
+func WithTimedMedia() EngineOption {
+	return func(e *EngineImpl) {
+		e.timedMedia = true
+	}
+}
+
+// sayDuration estimates how long a <Say> takes to speak its text once
+func sayDuration(say *twiml.Say) time.Duration {
+	rate, ok := speechRates[strings.ToLower(say.Voice)]
+	if !ok {
+		rate = defaultSpeechRate
+	}
+	chars := utf8.RuneCountInString(strings.TrimSpace(say.Text))
+	return time.Duration(float64(chars) / rate * float64(time.Second)).Round(time.Millisecond)
+}
+
+// mediaDuration returns how long an audio file plays, reading the headers of
+// WAV and MP3 files. It returns false for other formats.
+func mediaDuration(b []byte) (time.Duration, bool) {
+	if d, ok := wavDuration(b); ok {
+		return d, true
+	}
+	return mp3Duration(b)
+}
+
+// wavDuration returns the length of the data chunk of a RIFF WAVE file
+func wavDuration(b []byte) (time.Duration, bool) {
+	if len(b) < 12 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "WAVE" {
+		return 0, false
+	}
+	var byteRate uint32
+	for off := 12; off+8 <= len(b); {
+		id := string(b[off : off+4])
+		size := binary.LittleEndian.Uint32(b[off+4 : off+8])
+		switch id {
+		case "fmt ":
+			if off+20 > len(b) {
+				return 0, false
+			}
+			byteRate = binary.LittleEndian.Uint32(b[off+16 : off+20])
+		case "data":
+			if byteRate == 0 {
+				return 0, false
+			}
+			return time.Duration(float64(size) / float64(byteRate) * float64(time.Second)), true
+		}
+		// Chunks are padded to an even size
+		off += 8 + int(size) + int(size%2)
+	}
+	return 0, false
+}
+
+// mp3Duration estimates the length of an MP3 file from the bitrate of its first
+// frame, assuming a constant bitrate
+func mp3Duration(b []byte) (time.Duration, bool) {
+	start := 0
+	if len(b) >= 10 && bytes.HasPrefix(b, []byte("ID3")) {
+		// The ID3v2 tag size is a 28 bit syncsafe integer
+		size := int(b[6]&0x7f)<<21 | int(b[7]&0x7f)<<14 | int(b[8]&0x7f)<<7 | int(b[9]&0x7f)
+		start = 10 + size
+	}
+	for i := start; i+4 <= len(b); i++ {
+		if b[i] != 0xFF || b[i+1]&0xE0 != 0xE0 {
+			continue
+		}
+		version := (b[i+1] >> 3) & 0x03
+		layer := (b[i+1] >> 1) & 0x03
+		if version == 1 || layer != 1 {
+			// Reserved version, or not Layer III
+			continue
+		}
+		table := 1
+		if version == 3 {
+			table = 0
+		}
+		bitrate := mp3Bitrates[table][b[i+2]>>4]
+		if bitrate == 0 {
+			continue
+		}
+		bits := float64(len(b)-i) * 8
+		return time.Duration(bits / float64(bitrate*1000) * float64(time.Second)), true
+	}
+	return 0, false
+}
+
+// playMedia waits for media of length d to finish playing. Hanging up or
+// updating the call URL interrupts it.
+func (r *CallRunner) playMedia(ctx context.Context, verbName string, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	played, stop := r.engine.after(r.clock, d)
+	defer stop()
+	r.engine.park(r.hangupCh, r.urlUpdateCh, r.bargeInCh(), played)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-r.hangupCh:
+		r.addCallEvent(verbName+".interrupted", map[string]any{"reason": "hangup"})
+		return nil
+	case <-r.urlUpdateCh:
+		r.addCallEvent(verbName+".interrupted", map[string]any{"reason": "url_updated"})
+		return ErrURLUpdated
+	case <-r.bargeInCh():
+		return r.interruptPrompt(verbName)
+	case <-played:
+		return nil
+	}
+}
@@ -0,0 +1,148 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sprucehealth/twimulator/engine"
+	"github.com/sprucehealth/twimulator/model"
+)
+
+// testWAV builds an 8kHz 8-bit mono WAV file that plays for the given seconds
+func testWAV(seconds int) []byte {
+	data := make([]byte, 8000*seconds)
+	b := make([]byte, 44, 44+len(data))
+	copy(b[0:], "RIFF")
+	binary.LittleEndian.PutUint32(b[4:], uint32(36+len(data)))
+	copy(b[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(b[16:], 16)
+	binary.LittleEndian.PutUint16(b[20:], 7) // mu-law
+	binary.LittleEndian.PutUint16(b[22:], 1)
+	binary.LittleEndian.PutUint32(b[24:], 8000)
+	binary.LittleEndian.PutUint32(b[28:], 8000)
+	binary.LittleEndian.PutUint16(b[32:], 1)
+	binary.LittleEndian.PutUint16(b[34:], 8)
+	copy(b[36:], "data")
+	binary.LittleEndian.PutUint32(b[40:], uint32(len(data)))
+	return append(b, data...)
+}
+
+// testMP3 builds a 128kbps MPEG-1 Layer III file that plays for the given seconds
+func testMP3(seconds int) []byte {
+	b := make([]byte, 16000*seconds)
+	copy(b, []byte{0xFF, 0xFB, 0x90, 0x00})
+	return b
+}
+
+// timedMediaRoutes answer calls with body and serve a 3 second WAV and a 2
+// second MP3 greeting
+func timedMediaRoutes(body string) map[string]string {
+	return map[string]string{
+		"http://test/answer":       `<Response>` + body + `</Response>`,
+		"http://test/greeting.wav": string(testWAV(3)),
+		"http://test/greeting.mp3": string(testMP3(2)),
+	}
+}
+
+func callStatus(t *testing.T, e *engine.EngineImpl, call *model.Call) model.CallStatus {
+	t.Helper()
+	settle(t, e)
+	got, ok := e.GetCallState(call.AccountSID, call.SID)
+	if !ok {
+		t.Fatalf("call %s not found", call.SID)
+	}
+	return got.Status
+}
+
+func TestTimedMediaSayHangupDuringGreeting(t *testing.T) {
+	// 150 characters take 10 seconds to say
+	greeting := strings.Repeat("Thank you. ", 14) + "Goodbye"
+	e, _, subAccount := newTestEngine(t, timedMediaRoutes(`<Say>`+greeting[:150]+`</Say><Say>Press 1 for the pharmacy</Say>`), engine.WithTimedMedia())
+	call := startCall(t, e, subAccount.SID)
+
+	e.Advance(5 * time.Second)
+	if status := callStatus(t, e, call); status != model.CallInProgress {
+		t.Fatalf("expected the call in progress during the greeting, got %s", status)
+	}
+	if err := e.Hangup(call.AccountSID, call.SID); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	got, _ := e.GetCallState(call.AccountSID, call.SID)
+	if !hasEvent(got, "say.interrupted") {
+		t.Error("expected the greeting to be interrupted")
+	}
+	says := 0
+	for _, event := range got.Timeline {
+		if event.Type == "twiml.say" {
+			says++
+		}
+	}
+	if says != 1 {
+		t.Errorf("expected only the greeting to be said, got %d says", says)
+	}
+}
+
+func TestTimedMediaSayLoop(t *testing.T) {
+	// 15 characters take 1 second to say, twice with loop="2"
+	e, _, subAccount := newTestEngine(t, timedMediaRoutes(`<Say loop="2">Your visit is n</Say>`), engine.WithTimedMedia())
+	call := startCall(t, e, subAccount.SID)
+
+	e.Advance(1500 * time.Millisecond)
+	if status := callStatus(t, e, call); status != model.CallInProgress {
+		t.Fatalf("expected the call in progress after one loop, got %s", status)
+	}
+	e.Advance(500 * time.Millisecond)
+	if status := callStatus(t, e, call); status != model.CallCompleted {
+		t.Fatalf("expected the call completed after two loops, got %s", status)
+	}
+}
+
+func TestTimedMediaPlay(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, timedMediaRoutes(`<Play>http://test/greeting.wav</Play><Play>http://test/greeting.mp3</Play>`), engine.WithTimedMedia())
+	call := startCall(t, e, subAccount.SID)
+
+	if len(mock.GetCallsTo("http://test/greeting.wav")) != 1 {
+		t.Fatal("expected the WAV file to be downloaded")
+	}
+	e.Advance(2 * time.Second)
+	settle(t, e)
+	if len(mock.GetCallsTo("http://test/greeting.mp3")) != 0 {
+		t.Fatal("expected the MP3 to wait for the 3 second WAV")
+	}
+	e.Advance(1 * time.Second)
+	settle(t, e)
+	if len(mock.GetCallsTo("http://test/greeting.mp3")) != 1 {
+		t.Fatal("expected the MP3 after the WAV finished")
+	}
+
+	e.Advance(1 * time.Second)
+	if status := callStatus(t, e, call); status != model.CallInProgress {
+		t.Fatalf("expected the call in progress during the 2 second MP3, got %s", status)
+	}
+	e.Advance(1 * time.Second)
+	if status := callStatus(t, e, call); status != model.CallCompleted {
+		t.Fatalf("expected the call completed after the MP3, got %s", status)
+	}
+}
+
+func TestTimedMediaPause(t *testing.T) {
+	e, _, subAccount := newTestEngine(t, timedMediaRoutes(`<Pause length="4"/>`), engine.WithTimedMedia())
+	call := startCall(t, e, subAccount.SID)
+
+	e.Advance(3 * time.Second)
+	if status := callStatus(t, e, call); status != model.CallInProgress {
+		t.Fatalf("expected the call in progress during the pause, got %s", status)
+	}
+	e.Advance(1 * time.Second)
+	if status := callStatus(t, e, call); status != model.CallCompleted {
+		t.Fatalf("expected the call completed after the pause, got %s", status)
+	}
+}
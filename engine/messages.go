@@ -0,0 +1,816 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+
+	"github.com/sprucehealth/twimulator/model"
+	"github.com/sprucehealth/twimulator/twiml"
+)
+
+const (
+	// messageHopDelay is the simulated time between sending → sent and sent → delivered
+	messageHopDelay = time.Second
+	// maxMessagingRedirects bounds <Redirect> chains in messaging TwiML
+	maxMessagingRedirects = 10
+
+	messageDirectionInbound       = "inbound"
+	messageDirectionOutboundAPI   = "outbound-api"
+	messageDirectionOutboundCall  = "outbound-call"
+	messageDirectionOutboundReply = "outbound-reply"
+)
+
+// messageOutcome is the simulated final delivery status for a destination
+type messageOutcome struct {
+	status    model.MessageStatus
+	errorCode int
+}
+
+// messageErrorMessages maps common Twilio messaging error codes to their descriptions
+var messageErrorMessages = map[int]string{
+	30003: "Unreachable destination handset",
+	30004: "Message blocked",
+	30005: "Unknown destination handset",
+	30006: "Landline or unreachable carrier",
+	30007: "Message filtered",
+	30008: "Unknown error",
+}
+
+// SetMessageDelivery chooses how outbound messages to a number finish. Status
+// must be delivered, undelivered or failed; messages are delivered by default.
+func (e *EngineImpl) SetMessageDelivery(accountSID model.SID, to string, status model.MessageStatus, errorCode int) error {
+	switch status {
+	case model.MessageDelivered, model.MessageUndelivered, model.MessageFailed:
+	default:
+		return fmt.Errorf("invalid delivery status %q: must be delivered, undelivered or failed", status)
+	}
+
+	// Get subaccount state
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[accountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return notFoundError(accountSID)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.messageOutcomes[to] = messageOutcome{status: status, errorCode: errorCode}
+	return nil
+}
+
+// CreateMessage sends an outbound SMS or MMS
+func (e *EngineImpl) CreateMessage(params *twilioopenapi.CreateMessageParams) (*twilioopenapi.ApiV2010Message, error) {
+	if params == nil || params.PathAccountSid == nil || *params.PathAccountSid == "" {
+		return nil, fmt.Errorf("PathAccountSid is required")
+	}
+	accountSID := model.SID(*params.PathAccountSid)
+
+	to := ""
+	if params.To != nil {
+		to = *params.To
+	}
+	if to == "" {
+		return nil, fmt.Errorf("To is required")
+	}
+	from := ""
+	if params.From != nil {
+		from = *params.From
+	}
+	if from == "" {
+		if params.MessagingServiceSid != nil && *params.MessagingServiceSid != "" {
+			return nil, fmt.Errorf("MessagingServiceSid is not supported, From is required")
+		}
+		return nil, fmt.Errorf("From is required")
+	}
+	body := ""
+	if params.Body != nil {
+		body = *params.Body
+	}
+	var mediaURLs []string
+	if params.MediaUrl != nil {
+		mediaURLs = append(mediaURLs, *params.MediaUrl...)
+	}
+	if body == "" && len(mediaURLs) == 0 {
+		return nil, fmt.Errorf("Body or MediaUrl is required")
+	}
+
+	var sendAt *time.Time
+	if params.ScheduleType != nil && *params.ScheduleType != "" {
+		if *params.ScheduleType != "fixed" {
+			return nil, fmt.Errorf("invalid ScheduleType %q: must be fixed", *params.ScheduleType)
+		}
+		if params.SendAt == nil {
+			return nil, fmt.Errorf("SendAt is required when ScheduleType is fixed")
+		}
+		t := *params.SendAt
+		sendAt = &t
+	}
+
+	// Get subaccount state
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[accountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return nil, notFoundError(accountSID)
+	}
+
+	state.mu.Lock()
+	if state.incomingNumbers[from] == nil {
+		state.mu.Unlock()
+		return nil, fmt.Errorf("from number %s not provisioned for account %s", from, accountSID)
+	}
+	if sendAt != nil && !sendAt.After(state.clock.Now()) {
+		state.mu.Unlock()
+		return nil, fmt.Errorf("SendAt must be in the future")
+	}
+	var app *applicationRecord
+	if params.ApplicationSid != nil && *params.ApplicationSid != "" {
+		app = state.applications[model.SID(*params.ApplicationSid)]
+		if app == nil {
+			state.mu.Unlock()
+			return nil, fmt.Errorf("application %s not found for account %s", *params.ApplicationSid, accountSID)
+		}
+	}
+	msg := e.newOutboundMessageLocked(state, from, to, body, mediaURLs, messageDirectionOutboundAPI)
+	if app != nil {
+		// The application's SmsStatusCallback replaces StatusCallback
+		msg.StatusCallback = app.SmsStatusCallback
+	} else if params.StatusCallback != nil && *params.StatusCallback != "" {
+		msg.StatusCallback = *params.StatusCallback
+	}
+	if sendAt != nil {
+		msg.Status = model.MessageScheduled
+		msg.SendAt = sendAt
+	}
+	resp := buildAPIMessageResponse(msg, e.apiVersion)
+	state.mu.Unlock()
+
+	e.startMessageLifecycle(state, msg)
+	return resp, nil
+}
+
+// FetchMessage returns a message by SID
+func (e *EngineImpl) FetchMessage(sid string, params *twilioopenapi.FetchMessageParams) (*twilioopenapi.ApiV2010Message, error) {
+	if params == nil || params.PathAccountSid == nil || *params.PathAccountSid == "" {
+		return nil, fmt.Errorf("PathAccountSid is required")
+	}
+	accountSID := model.SID(*params.PathAccountSid)
+
+	// Get subaccount state
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[accountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return nil, notFoundError(accountSID)
+	}
+
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+	msg, exists := state.messages[model.SID(sid)]
+	if !exists {
+		return nil, notFoundError(model.SID(sid))
+	}
+	return buildAPIMessageResponse(msg, e.apiVersion), nil
+}
+
+// ListMessage returns messages for an account, newest first
+func (e *EngineImpl) ListMessage(params *twilioopenapi.ListMessageParams) ([]twilioopenapi.ApiV2010Message, error) {
+	if params == nil || params.PathAccountSid == nil || *params.PathAccountSid == "" {
+		return nil, fmt.Errorf("PathAccountSid is required")
+	}
+	accountSID := model.SID(*params.PathAccountSid)
+
+	// Get subaccount state
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[accountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return nil, notFoundError(accountSID)
+	}
+
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	messages := make([]*model.Message, 0, len(state.messages))
+	for _, msg := range state.messages {
+		if params.To != nil && *params.To != "" && msg.To != *params.To {
+			continue
+		}
+		if params.From != nil && *params.From != "" && msg.From != *params.From {
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].CreatedAt.Equal(messages[j].CreatedAt) {
+			return messages[i].SID > messages[j].SID
+		}
+		return messages[i].CreatedAt.After(messages[j].CreatedAt)
+	})
+
+	result := make([]twilioopenapi.ApiV2010Message, 0, len(messages))
+	for _, msg := range messages {
+		result = append(result, *buildAPIMessageResponse(msg, e.apiVersion))
+	}
+	return result, nil
+}
+
+// UpdateMessage redacts a message body or cancels a scheduled message
+func (e *EngineImpl) UpdateMessage(sid string, params *twilioopenapi.UpdateMessageParams) (*twilioopenapi.ApiV2010Message, error) {
+	if params == nil || params.PathAccountSid == nil || *params.PathAccountSid == "" {
+		return nil, fmt.Errorf("PathAccountSid is required")
+	}
+	accountSID := model.SID(*params.PathAccountSid)
+
+	// Get subaccount state
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[accountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return nil, notFoundError(accountSID)
+	}
+
+	state.mu.Lock()
+	msg, exists := state.messages[model.SID(sid)]
+	if !exists {
+		state.mu.Unlock()
+		return nil, notFoundError(model.SID(sid))
+	}
+	if params.Body != nil {
+		if *params.Body != "" {
+			state.mu.Unlock()
+			return nil, fmt.Errorf("Body can only be updated to an empty string to redact a message")
+		}
+		if !msg.Status.IsTerminal() {
+			state.mu.Unlock()
+			return nil, fmt.Errorf("cannot redact message %s while it is %s", msg.SID, msg.Status)
+		}
+		msg.Body = ""
+		e.addMessageEventLocked(state, msg, "message.redacted", nil)
+	}
+	canceled := false
+	if params.Status != nil {
+		if *params.Status != string(model.MessageCanceled) {
+			state.mu.Unlock()
+			return nil, fmt.Errorf("invalid Status %q: only canceled is supported", *params.Status)
+		}
+		if msg.Status != model.MessageScheduled {
+			state.mu.Unlock()
+			return nil, fmt.Errorf("only scheduled messages can be canceled, message %s is %s", msg.SID, msg.Status)
+		}
+		e.setMessageStatusLocked(state, msg, model.MessageCanceled)
+		canceled = true
+	}
+	msg.UpdatedAt = state.clock.Now()
+	resp := buildAPIMessageResponse(msg, e.apiVersion)
+	state.mu.Unlock()
+
+	if canceled {
+		e.wg.Add(1)
+		e.goTracked(func() {
+			defer e.wg.Done()
+			e.sendMessageStatusCallback(state, msg)
+		})
+	}
+	return resp, nil
+}
+
+// CreateIncomingMessage simulates an SMS or MMS arriving at a provisioned number.
+// The message is delivered to the SmsUrl of the number or its SMS application,
+// and any <Message> replies in the returned TwiML are sent.
+func (e *EngineImpl) CreateIncomingMessage(accountSID model.SID, from string, to string, body string, mediaURLs ...string) (*twilioopenapi.ApiV2010Message, error) {
+	if from == "" {
+		return nil, fmt.Errorf("from is required")
+	}
+	if to == "" {
+		return nil, fmt.Errorf("to is required")
+	}
+
+	// Get subaccount state
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[accountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return nil, notFoundError(accountSID)
+	}
+
+	state.mu.Lock()
+	number := state.incomingNumbers[to]
+	if number == nil {
+		state.mu.Unlock()
+		return nil, fmt.Errorf("to number %s not provisioned for account %s", to, accountSID)
+	}
+	smsURL, smsMethod := number.SmsURL, number.SmsMethod
+	if number.SmsApplication != nil {
+		app := state.applications[*number.SmsApplication]
+		if app == nil {
+			state.mu.Unlock()
+			return nil, fmt.Errorf("application %s not found for account %s", *number.SmsApplication, accountSID)
+		}
+		smsURL, smsMethod = app.SmsURL, app.SmsMethod
+	}
+	if smsURL == "" {
+		state.mu.Unlock()
+		return nil, fmt.Errorf("number %s does not have a messaging URL configured", to)
+	}
+	if smsMethod == "" {
+		smsMethod = "POST"
+	}
+
+	now := state.clock.Now()
+	msg := &model.Message{
+		SID:        e.ids.MessageSID(len(mediaURLs) > 0),
+		AccountSID: accountSID,
+		From:       from,
+		To:         to,
+		Body:       body,
+		MediaURLs:  append([]string(nil), mediaURLs...),
+		Direction:  messageDirectionInbound,
+		Status:     model.MessageReceived,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		SentAt:     &now,
+	}
+	state.messages[msg.SID] = msg
+	e.addMessageEventLocked(state, msg, "message.received", map[string]any{
+		"from": from,
+		"to":   to,
+		"url":  smsURL,
+	})
+	resp := buildAPIMessageResponse(msg, e.apiVersion)
+	state.mu.Unlock()
+
+	e.wg.Add(1)
+	e.goTracked(func() {
+		defer e.wg.Done()
+		e.deliverIncomingMessage(e.ctx, state, msg, smsMethod, smsURL)
+	})
+	return resp, nil
+}
+
+// newOutboundMessageLocked stores a queued outbound message. Its status
+// callback defaults to the SmsStatusCallback of the sending number's SMS
+// application. state.mu must be held.
+func (e *EngineImpl) newOutboundMessageLocked(state *subAccountState, from, to, body string, mediaURLs []string, direction string) *model.Message {
+	now := state.clock.Now()
+	msg := &model.Message{
+		SID:        e.ids.MessageSID(len(mediaURLs) > 0),
+		AccountSID: state.account.SID,
+		From:       from,
+		To:         to,
+		Body:       body,
+		MediaURLs:  mediaURLs,
+		Direction:  direction,
+		Status:     model.MessageQueued,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if number := state.incomingNumbers[from]; number != nil && number.SmsApplication != nil {
+		if app := state.applications[*number.SmsApplication]; app != nil {
+			msg.StatusCallback = app.SmsStatusCallback
+		}
+	}
+	state.messages[msg.SID] = msg
+	e.addMessageEventLocked(state, msg, "message.created", map[string]any{
+		"from":      from,
+		"to":        to,
+		"direction": direction,
+	})
+	return msg
+}
+
+// startMessageLifecycle moves an outbound message through its delivery statuses
+// in the background
+func (e *EngineImpl) startMessageLifecycle(state *subAccountState, msg *model.Message) {
+	e.wg.Add(1)
+	e.goTracked(func() {
+		defer e.wg.Done()
+		e.runMessageLifecycle(e.ctx, state, msg)
+	})
+}
+
+func (e *EngineImpl) runMessageLifecycle(ctx context.Context, state *subAccountState, msg *model.Message) {
+	state.mu.RLock()
+	sendAt := msg.SendAt
+	clock := state.clock
+	state.mu.RUnlock()
+
+	if sendAt != nil {
+		if !e.sleep(ctx, clock, sendAt.Sub(clock.Now())) {
+			return
+		}
+		state.mu.Lock()
+		if msg.Status != model.MessageScheduled {
+			// Canceled while waiting
+			state.mu.Unlock()
+			return
+		}
+		e.setMessageStatusLocked(state, msg, model.MessageQueued)
+		state.mu.Unlock()
+	}
+
+	// Status callbacks start once the message is sent, or fails
+	state.mu.Lock()
+	outcome, ok := state.messageOutcomes[msg.To]
+	if !ok {
+		outcome = messageOutcome{status: model.MessageDelivered}
+	}
+	e.setMessageStatusLocked(state, msg, model.MessageSending)
+	state.mu.Unlock()
+
+	if !e.sleep(ctx, clock, messageHopDelay) {
+		return
+	}
+
+	state.mu.Lock()
+	if outcome.status == model.MessageFailed {
+		e.failMessageLocked(state, msg, model.MessageFailed, outcome.errorCode)
+		state.mu.Unlock()
+		e.sendMessageStatusCallback(state, msg)
+		return
+	}
+	now := clock.Now()
+	msg.SentAt = &now
+	e.setMessageStatusLocked(state, msg, model.MessageSent)
+	state.mu.Unlock()
+	e.sendMessageStatusCallback(state, msg)
+
+	if !e.sleep(ctx, clock, messageHopDelay) {
+		return
+	}
+
+	state.mu.Lock()
+	if outcome.status == model.MessageUndelivered {
+		e.failMessageLocked(state, msg, model.MessageUndelivered, outcome.errorCode)
+	} else {
+		e.setMessageStatusLocked(state, msg, model.MessageDelivered)
+	}
+	state.mu.Unlock()
+	e.sendMessageStatusCallback(state, msg)
+}
+
+func (e *EngineImpl) failMessageLocked(state *subAccountState, msg *model.Message, status model.MessageStatus, errorCode int) {
+	if errorCode == 0 {
+		errorCode = 30008
+	}
+	msg.ErrorCode = errorCode
+	msg.ErrorMessage = messageErrorMessages[errorCode]
+	e.setMessageStatusLocked(state, msg, status)
+}
+
+func (e *EngineImpl) setMessageStatusLocked(state *subAccountState, msg *model.Message, status model.MessageStatus) {
+	from := msg.Status
+	msg.Status = status
+	msg.UpdatedAt = state.clock.Now()
+	detail := map[string]any{
+		"from": string(from),
+		"to":   string(status),
+	}
+	if msg.ErrorCode != 0 {
+		detail["error_code"] = msg.ErrorCode
+	}
+	e.addMessageEventLocked(state, msg, "message.status_changed", detail)
+}
+
+func (e *EngineImpl) addMessageEventLocked(state *subAccountState, msg *model.Message, eventType string, detail map[string]any) {
+	msg.Timeline = append(msg.Timeline, model.NewEvent(
+		state.clock.Now(),
+		eventType,
+		detail,
+	))
+}
+
+func (e *EngineImpl) addMessageEvent(state *subAccountState, msg *model.Message, eventType string, detail map[string]any) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	e.addMessageEventLocked(state, msg, eventType, detail)
+}
+
+// sendMessageStatusCallback posts the current message status to its StatusCallback
+func (e *EngineImpl) sendMessageStatusCallback(state *subAccountState, msg *model.Message) {
+	state.mu.RLock()
+	callbackURL := msg.StatusCallback
+	form := url.Values{}
+	form.Set("MessageSid", string(msg.SID))
+	form.Set("SmsSid", string(msg.SID))
+	form.Set("AccountSid", string(msg.AccountSID))
+	form.Set("From", msg.From)
+	form.Set("To", msg.To)
+	form.Set("MessageStatus", string(msg.Status))
+	form.Set("SmsStatus", string(msg.Status))
+	form.Set("ApiVersion", e.apiVersion)
+	if msg.ErrorCode != 0 {
+		form.Set("ErrorCode", strconv.Itoa(msg.ErrorCode))
+	}
+	state.mu.RUnlock()
+
+	if callbackURL == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+
+	status, _, _, err := e.webhook.POST(ctx, callbackURL, form, e.signedHeaders(state, callbackURL, form))
+	if err == nil && (status < 200 || status >= 300) {
+		err = fmt.Errorf("returned status %d", status)
+	}
+	if err != nil {
+		e.addMessageEvent(state, msg, "webhook.status_callback.error", map[string]any{
+			"url":   callbackURL,
+			"error": err.Error(),
+		})
+		e.recordError(state, fmt.Errorf("failed to fetch URL %s: %w", callbackURL, err))
+		return
+	}
+	e.addMessageEvent(state, msg, "webhook.status_callback", map[string]any{
+		"url":    callbackURL,
+		"status": form.Get("MessageStatus"),
+	})
+}
+
+// deliverIncomingMessage posts an inbound message to its messaging webhook and
+// executes the TwiML response
+func (e *EngineImpl) deliverIncomingMessage(ctx context.Context, state *subAccountState, msg *model.Message, method, targetURL string) {
+	state.mu.RLock()
+	form := url.Values{}
+	form.Set("MessageSid", string(msg.SID))
+	form.Set("SmsSid", string(msg.SID))
+	form.Set("SmsMessageSid", string(msg.SID))
+	form.Set("AccountSid", string(msg.AccountSID))
+	form.Set("From", msg.From)
+	form.Set("To", msg.To)
+	form.Set("Body", msg.Body)
+	form.Set("NumSegments", "1")
+	form.Set("NumMedia", strconv.Itoa(len(msg.MediaURLs)))
+	for i, mediaURL := range msg.MediaURLs {
+		form.Set(fmt.Sprintf("MediaUrl%d", i), mediaURL)
+	}
+	form.Set("SmsStatus", string(msg.Status))
+	form.Set("ApiVersion", e.apiVersion)
+	state.mu.RUnlock()
+
+	for hops := 0; targetURL != ""; hops++ {
+		if hops > maxMessagingRedirects {
+			e.recordError(state, fmt.Errorf("too many redirects handling message %s", msg.SID))
+			return
+		}
+		resp, err := e.fetchMessagingTwiML(ctx, state, msg, method, targetURL, form)
+		if err != nil {
+			e.recordError(state, err)
+			return
+		}
+		targetURL, method, form, err = e.executeMessagingTwiML(state, msg, resp, targetURL, form)
+		if err != nil {
+			e.recordError(state, err)
+			return
+		}
+	}
+}
+
+// fetchMessagingTwiML requests a messaging webhook and parses its TwiML. An
+// empty response body is treated as an empty <Response>.
+func (e *EngineImpl) fetchMessagingTwiML(ctx context.Context, state *subAccountState, msg *model.Message, method, targetURL string, form url.Values) (*twiml.Response, error) {
+	e.addMessageEvent(state, msg, "webhook.request", map[string]any{
+		"url":  targetURL,
+		"form": form,
+	})
+
+	reqCtx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	var status int
+	var body []byte
+	var err error
+	if method == "GET" {
+		u, parseErr := url.Parse(targetURL)
+		if parseErr != nil {
+			return nil, fmt.Errorf("failed to parse URL %s: %w", targetURL, parseErr)
+		}
+		q := u.Query()
+		for k, v := range form {
+			q[k] = v
+		}
+		u.RawQuery = q.Encode()
+		signedURL := u.String()
+		status, body, _, err = e.webhook.GET(reqCtx, signedURL, e.signedHeaders(state, signedURL, nil))
+	} else {
+		status, body, _, err = e.webhook.POST(reqCtx, targetURL, form, e.signedHeaders(state, targetURL, form))
+	}
+	if err != nil {
+		e.addMessageEvent(state, msg, "webhook.error", map[string]any{
+			"url":   targetURL,
+			"error": err.Error(),
+		})
+		return nil, fmt.Errorf("webhook request failed: %w", err)
+	}
+	e.addMessageEvent(state, msg, "webhook.response", map[string]any{
+		"url":    targetURL,
+		"status": status,
+		"body":   string(body),
+	})
+	if status < 200 || status >= 300 {
+		return nil, fmt.Errorf("webhook URL %s returned status %d", targetURL, status)
+	}
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return &twiml.Response{}, nil
+	}
+
+	resp, err := twiml.Parse(body)
+	if err != nil {
+		e.addMessageEvent(state, msg, "twiml.parse_error", map[string]any{
+			"error": err.Error(),
+			"body":  string(body),
+		})
+		return nil, fmt.Errorf("failed to parse TwiML at %s: %w", targetURL, err)
+	}
+	return resp, nil
+}
+
+// executeMessagingTwiML sends the replies in a messaging TwiML document. It
+// returns the next document to fetch when the response redirects.
+func (e *EngineImpl) executeMessagingTwiML(state *subAccountState, inbound *model.Message, resp *twiml.Response, documentURL string, form url.Values) (string, string, url.Values, error) {
+	for _, node := range resp.Children {
+		switch n := node.(type) {
+		case *twiml.Message:
+			reply, err := e.sendTwiMLMessage(state, n, inbound.To, inbound.From, messageDirectionOutboundReply, documentURL, nil)
+			if err != nil {
+				return "", "", nil, err
+			}
+			e.addMessageEvent(state, inbound, "message.reply", map[string]any{
+				"message_sid": reply.SID,
+				"to":          reply.To,
+			})
+			if n.Action != "" {
+				next, err := resolveURL(documentURL, n.Action)
+				if err != nil {
+					return "", "", nil, err
+				}
+				nextForm := cloneValues(form)
+				nextForm.Set("SmsSid", string(reply.SID))
+				nextForm.Set("SmsStatus", string(model.MessageQueued))
+				return next, n.Method, nextForm, nil
+			}
+		case *twiml.Redirect:
+			next, err := resolveURL(documentURL, n.URL)
+			if err != nil {
+				return "", "", nil, err
+			}
+			method := n.Method
+			if method == "" {
+				method = "POST"
+			}
+			e.addMessageEvent(state, inbound, "twiml.redirect", map[string]any{"url": next})
+			return next, method, form, nil
+		default:
+			e.addMessageEvent(state, inbound, "twiml.invalid_node", map[string]any{
+				"node": fmt.Sprintf("%T", node),
+			})
+			return "", "", nil, fmt.Errorf("unsupported verb %T in messaging TwiML from %s", node, documentURL)
+		}
+	}
+	return "", "", nil, nil
+}
+
+// sendTwiMLMessage queues an outbound message for a <Message> verb. From and
+// To default to the given addresses when the verb does not set them.
+func (e *EngineImpl) sendTwiMLMessage(state *subAccountState, verb *twiml.Message, defaultFrom, defaultTo, direction, documentURL string, callSID *model.SID) (*model.Message, error) {
+	from := verb.From
+	if from == "" {
+		from = defaultFrom
+	}
+	to := verb.To
+	if to == "" {
+		to = defaultTo
+	}
+	if verb.Body == "" && len(verb.Media) == 0 {
+		return nil, errors.New("<Message> requires a body or media")
+	}
+	statusCallback := ""
+	if verb.StatusCallback != "" {
+		resolved, err := resolveURL(documentURL, verb.StatusCallback)
+		if err != nil {
+			return nil, err
+		}
+		statusCallback = resolved
+	}
+
+	state.mu.Lock()
+	if state.incomingNumbers[from] == nil {
+		state.mu.Unlock()
+		return nil, fmt.Errorf("from number %s not provisioned for account %s", from, state.account.SID)
+	}
+	msg := e.newOutboundMessageLocked(state, from, to, verb.Body, append([]string(nil), verb.Media...), direction)
+	if statusCallback != "" {
+		msg.StatusCallback = statusCallback
+	}
+	if callSID != nil {
+		sid := *callSID
+		msg.CallSID = &sid
+	}
+	state.mu.Unlock()
+
+	e.startMessageLifecycle(state, msg)
+	return msg, nil
+}
+
+func cloneValues(v url.Values) url.Values {
+	out := make(url.Values, len(v))
+	for k, vals := range v {
+		out[k] = append([]string(nil), vals...)
+	}
+	return out
+}
+
+func buildAPIMessageResponse(msg *model.Message, apiVersion string) *twilioopenapi.ApiV2010Message {
+	sid := string(msg.SID)
+	accountSid := string(msg.AccountSID)
+	from := msg.From
+	to := msg.To
+	body := msg.Body
+	status := string(msg.Status)
+	direction := msg.Direction
+	numMedia := strconv.Itoa(len(msg.MediaURLs))
+	numSegments := "1"
+	dateCreated := msg.CreatedAt.UTC().Format(time.RFC1123Z)
+	dateUpdated := msg.UpdatedAt.UTC().Format(time.RFC1123Z)
+	uri := fmt.Sprintf("/%s/Accounts/%s/Messages/%s.json", apiVersion, msg.AccountSID, msg.SID)
+	resp := &twilioopenapi.ApiV2010Message{
+		Sid:         &sid,
+		AccountSid:  &accountSid,
+		From:        &from,
+		To:          &to,
+		Body:        &body,
+		Status:      &status,
+		Direction:   &direction,
+		NumMedia:    &numMedia,
+		NumSegments: &numSegments,
+		ApiVersion:  &apiVersion,
+		DateCreated: &dateCreated,
+		DateUpdated: &dateUpdated,
+		Uri:         &uri,
+	}
+	if msg.SentAt != nil {
+		sent := msg.SentAt.UTC().Format(time.RFC1123Z)
+		resp.DateSent = &sent
+	}
+	if msg.ErrorCode != 0 {
+		code := msg.ErrorCode
+		resp.ErrorCode = &code
+		errMsg := msg.ErrorMessage
+		resp.ErrorMessage = &errMsg
+	}
+	return resp
+}
+
+// setIncomingNumberSmsFields copies the messaging configuration of a number into an API response
+func setIncomingNumberSmsFields(resp *twilioopenapi.ApiV2010IncomingPhoneNumber, rec *incomingNumber) {
+	if rec.SmsApplication != nil {
+		appCopy := string(*rec.SmsApplication)
+		resp.SmsApplicationSid = &appCopy
+	}
+	if rec.SmsURL != "" {
+		smsURL := rec.SmsURL
+		resp.SmsUrl = &smsURL
+	}
+	if rec.SmsMethod != "" {
+		smsMethod := rec.SmsMethod
+		resp.SmsMethod = &smsMethod
+	}
+}
+
+func sidStringPtr(sid *model.SID) *string {
+	if sid == nil {
+		return nil
+	}
+	s := string(*sid)
+	return &s
+}
+
+// messageSendDefaults returns the Twilio-side and far-end addresses of a call,
+// used as the default From and To for <Message> during voice calls
+func messageSendDefaults(call *model.Call) (string, string) {
+	if call.Direction == model.Inbound {
+		return call.To, call.From
+	}
+	return call.From, call.To
+}
@@ -0,0 +1,378 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+
+	"github.com/sprucehealth/twimulator/httpstub"
+	"github.com/sprucehealth/twimulator/model"
+)
+
+func statusesPosted(mock *httpstub.MockWebhookClient, callbackURL string) []string {
+	var statuses []string
+	for _, c := range mock.GetCallsTo(callbackURL) {
+		statuses = append(statuses, c.Form.Get("MessageStatus"))
+	}
+	return statuses
+}
+
+func TestCreateMessageLifecycle(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, nil)
+
+	params := (&twilioopenapi.CreateMessageParams{}).
+		SetPathAccountSid(string(subAccount.SID)).
+		SetFrom("+15550001111").
+		SetTo("+15552223333").
+		SetBody("Your appointment is confirmed").
+		SetStatusCallback("http://test/sms-status")
+	msg, err := e.CreateMessage(params)
+	if err != nil {
+		t.Fatalf("create message failed: %v", err)
+	}
+	if msg.Status == nil || *msg.Status != "queued" {
+		t.Fatalf("expected queued, got %v", msg.Status)
+	}
+	if msg.Direction == nil || *msg.Direction != "outbound-api" {
+		t.Errorf("expected outbound-api direction, got %v", msg.Direction)
+	}
+
+	settle(t, e)
+	e.Advance(time.Second)
+	settle(t, e)
+	e.Advance(time.Second)
+	settle(t, e)
+
+	got := statusesPosted(mock, "http://test/sms-status")
+	want := []string{"sent", "delivered"}
+	if len(got) != len(want) {
+		t.Fatalf("expected callbacks %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("callback %d: expected %s, got %s", i, want[i], got[i])
+		}
+	}
+
+	fetched, err := e.FetchMessage(*msg.Sid, (&twilioopenapi.FetchMessageParams{}).SetPathAccountSid(string(subAccount.SID)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *fetched.Status != "delivered" {
+		t.Errorf("expected delivered, got %s", *fetched.Status)
+	}
+	if fetched.DateSent == nil {
+		t.Error("expected DateSent to be set")
+	}
+}
+
+func TestCreateMessageUndelivered(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, nil)
+
+	if err := e.SetMessageDelivery(subAccount.SID, "+15552223333", model.MessageUndelivered, 30003); err != nil {
+		t.Fatal(err)
+	}
+	params := (&twilioopenapi.CreateMessageParams{}).
+		SetPathAccountSid(string(subAccount.SID)).
+		SetFrom("+15550001111").
+		SetTo("+15552223333").
+		SetBody("Reminder").
+		SetStatusCallback("http://test/sms-status")
+	msg, err := e.CreateMessage(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	settle(t, e)
+	e.Advance(2 * time.Second)
+	settle(t, e)
+	e.Advance(2 * time.Second)
+	settle(t, e)
+
+	calls := mock.GetCallsTo("http://test/sms-status")
+	if len(calls) == 0 {
+		t.Fatal("expected status callbacks")
+	}
+	last := calls[len(calls)-1]
+	if last.Form.Get("MessageStatus") != "undelivered" || last.Form.Get("ErrorCode") != "30003" {
+		t.Errorf("expected undelivered with ErrorCode 30003, got %v", last.Form)
+	}
+
+	fetched, err := e.FetchMessage(*msg.Sid, (&twilioopenapi.FetchMessageParams{}).SetPathAccountSid(string(subAccount.SID)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fetched.ErrorCode == nil || *fetched.ErrorCode != 30003 {
+		t.Errorf("expected error code 30003, got %v", fetched.ErrorCode)
+	}
+}
+
+func TestCreateMessageValidation(t *testing.T) {
+	e, _, subAccount := newTestEngine(t, nil)
+
+	params := (&twilioopenapi.CreateMessageParams{}).
+		SetPathAccountSid(string(subAccount.SID)).
+		SetFrom("+15550001111").
+		SetTo("+15552223333")
+	if _, err := e.CreateMessage(params); err == nil {
+		t.Error("expected error without Body or MediaUrl")
+	}
+
+	params = (&twilioopenapi.CreateMessageParams{}).
+		SetPathAccountSid(string(subAccount.SID)).
+		SetFrom("+15559990000").
+		SetTo("+15552223333").
+		SetBody("hi")
+	if _, err := e.CreateMessage(params); err == nil {
+		t.Error("expected error for unprovisioned From number")
+	}
+}
+
+func TestIncomingMessageReply(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, nil)
+	mock.ResponseFunc = func(targetURL string, form url.Values) (int, []byte, http.Header, error) {
+		if targetURL == "http://test/sms" {
+			return 200, []byte(`<Response><Message statusCallback="/reply-status">Thanks, ` + form.Get("Body") + ` received</Message></Response>`), make(http.Header), nil
+		}
+		return 200, []byte("OK"), make(http.Header), nil
+	}
+
+	app, err := e.CreateApplication((&twilioopenapi.CreateApplicationParams{}).
+		SetPathAccountSid(string(subAccount.SID)).
+		SetFriendlyName("SMS").
+		SetSmsUrl("http://test/sms").
+		SetSmsMethod("POST"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.SmsUrl == nil || *app.SmsUrl != "http://test/sms" || app.SmsMethod == nil || *app.SmsMethod != "POST" {
+		t.Errorf("expected the SMS URL and method in the created application, got %v %v", app.SmsUrl, app.SmsMethod)
+	}
+	if _, err := e.CreateIncomingPhoneNumber((&twilioopenapi.CreateIncomingPhoneNumberParams{}).
+		SetPathAccountSid(string(subAccount.SID)).
+		SetPhoneNumber("+15550002222").
+		SetSmsApplicationSid(*app.Sid)); err != nil {
+		t.Fatal(err)
+	}
+
+	inbound, err := e.CreateIncomingMessage(subAccount.SID, "+15553334444", "+15550002222", "YES", "https://example.com/photo.jpg")
+	if err != nil {
+		t.Fatalf("incoming message failed: %v", err)
+	}
+	if *inbound.Status != "received" || *inbound.Direction != "inbound" {
+		t.Errorf("expected received inbound message, got %s %s", *inbound.Status, *inbound.Direction)
+	}
+	settle(t, e)
+
+	hooks := mock.GetCallsTo("http://test/sms")
+	if len(hooks) != 1 {
+		t.Fatalf("expected 1 messaging webhook, got %d", len(hooks))
+	}
+	if hooks[0].Form.Get("NumMedia") != "1" || hooks[0].Form.Get("MediaUrl0") != "https://example.com/photo.jpg" {
+		t.Errorf("expected media parameters, got %v", hooks[0].Form)
+	}
+	if hooks[0].Form.Get("MessageSid") != *inbound.Sid {
+		t.Errorf("expected MessageSid %s, got %s", *inbound.Sid, hooks[0].Form.Get("MessageSid"))
+	}
+
+	replies, err := e.ListMessage((&twilioopenapi.ListMessageParams{}).
+		SetPathAccountSid(string(subAccount.SID)).
+		SetTo("+15553334444"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replies) != 1 {
+		t.Fatalf("expected 1 reply, got %d", len(replies))
+	}
+	reply := replies[0]
+	if *reply.From != "+15550002222" || *reply.Direction != "outbound-reply" {
+		t.Errorf("expected reply from +15550002222 with outbound-reply, got %s %s", *reply.From, *reply.Direction)
+	}
+	if *reply.Body != "Thanks, YES received" {
+		t.Errorf("unexpected reply body %q", *reply.Body)
+	}
+	e.Advance(time.Second)
+	settle(t, e)
+	if got := statusesPosted(mock, "http://test/reply-status"); len(got) == 0 || got[0] != "sent" {
+		t.Errorf("expected reply status callbacks resolved against the webhook URL, got %v", got)
+	}
+}
+
+func TestIncomingMessageRequiresSmsURL(t *testing.T) {
+	e, _, subAccount := newTestEngine(t, nil)
+	if _, err := e.CreateIncomingMessage(subAccount.SID, "+15553334444", "+15550001111", "hello"); err == nil {
+		t.Error("expected error for number without a messaging URL")
+	}
+
+	sid := ""
+	numbers, err := e.ListIncomingPhoneNumber((&twilioopenapi.ListIncomingPhoneNumberParams{}).SetPathAccountSid(string(subAccount.SID)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sid = *numbers[0].Sid
+	updated, err := e.UpdateIncomingPhoneNumber(sid, (&twilioopenapi.UpdateIncomingPhoneNumberParams{}).
+		SetPathAccountSid(string(subAccount.SID)).
+		SetSmsUrl("http://test/sms"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.SmsUrl == nil || *updated.SmsUrl != "http://test/sms" {
+		t.Errorf("expected SmsUrl on number, got %v", updated.SmsUrl)
+	}
+	if _, err := e.CreateIncomingMessage(subAccount.SID, "+15553334444", "+15550001111", "hello"); err != nil {
+		t.Errorf("expected message to route through the number SmsUrl: %v", err)
+	}
+}
+
+func TestMessageVerbDuringCall(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, nil)
+	mock.ResponseFunc = func(targetURL string, form url.Values) (int, []byte, http.Header, error) {
+		switch targetURL {
+		case "http://test/voice":
+			return 200, []byte(`<Response><Say>Sending you a link</Say><Message action="/after">https://example.com/intake</Message><Say>unreachable</Say></Response>`), make(http.Header), nil
+		case "http://test/after":
+			return 200, []byte(`<Response><Hangup/></Response>`), make(http.Header), nil
+		}
+		return 200, []byte("OK"), make(http.Header), nil
+	}
+
+	call := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/voice"))
+	answerCall(t, e, subAccount.SID, call.SID)
+
+	messages, err := e.ListMessage((&twilioopenapi.ListMessageParams{}).SetPathAccountSid(string(subAccount.SID)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	if *messages[0].From != "+15550001111" || *messages[0].To != "+15552223333" {
+		t.Errorf("expected message from the Twilio number to the callee, got %s -> %s", *messages[0].From, *messages[0].To)
+	}
+	if *messages[0].Direction != "outbound-call" {
+		t.Errorf("expected outbound-call direction, got %s", *messages[0].Direction)
+	}
+
+	actions := mock.GetCallsTo("http://test/after")
+	if len(actions) != 1 {
+		t.Fatalf("expected 1 action callback, got %d", len(actions))
+	}
+	if actions[0].Form.Get("SmsSid") != *messages[0].Sid {
+		t.Errorf("expected SmsSid %s, got %s", *messages[0].Sid, actions[0].Form.Get("SmsSid"))
+	}
+
+	got, _ := e.GetCallState(subAccount.SID, call.SID)
+	if got.Status != model.CallCompleted {
+		t.Errorf("expected call completed by action TwiML, got %s", got.Status)
+	}
+}
+
+func TestScheduledMessageCancel(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, nil)
+
+	params := (&twilioopenapi.CreateMessageParams{}).
+		SetPathAccountSid(string(subAccount.SID)).
+		SetFrom("+15550001111").
+		SetTo("+15552223333").
+		SetBody("Later").
+		SetStatusCallback("http://test/sms-status").
+		SetScheduleType("fixed").
+		SetSendAt(e.Clock().Now().Add(time.Hour))
+	msg, err := e.CreateMessage(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *msg.Status != "scheduled" {
+		t.Fatalf("expected scheduled, got %s", *msg.Status)
+	}
+
+	updated, err := e.UpdateMessage(*msg.Sid, (&twilioopenapi.UpdateMessageParams{}).
+		SetPathAccountSid(string(subAccount.SID)).
+		SetStatus("canceled"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *updated.Status != "canceled" {
+		t.Errorf("expected canceled, got %s", *updated.Status)
+	}
+
+	settle(t, e)
+	e.Advance(2 * time.Hour)
+	settle(t, e)
+
+	for _, status := range statusesPosted(mock, "http://test/sms-status") {
+		if status == "sent" || status == "delivered" {
+			t.Fatalf("canceled message should not be sent, got %s callback", status)
+		}
+	}
+}
+
+func TestMessageApplicationStatusCallback(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, nil)
+
+	app, err := e.CreateApplication((&twilioopenapi.CreateApplicationParams{}).
+		SetPathAccountSid(string(subAccount.SID)).
+		SetSmsUrl("http://test/sms").
+		SetSmsStatusCallback("http://test/app-status"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.CreateIncomingPhoneNumber((&twilioopenapi.CreateIncomingPhoneNumberParams{}).
+		SetPathAccountSid(string(subAccount.SID)).
+		SetPhoneNumber("+15550002222").
+		SetSmsApplicationSid(*app.Sid)); err != nil {
+		t.Fatal(err)
+	}
+
+	// A message without StatusCallback reports to its number's SMS application
+	if _, err := e.CreateMessage((&twilioopenapi.CreateMessageParams{}).
+		SetPathAccountSid(string(subAccount.SID)).
+		SetFrom("+15550002222").
+		SetTo("+15552223333").
+		SetBody("Hello")); err != nil {
+		t.Fatal(err)
+	}
+	// ApplicationSid replaces StatusCallback
+	if _, err := e.CreateMessage((&twilioopenapi.CreateMessageParams{}).
+		SetPathAccountSid(string(subAccount.SID)).
+		SetFrom("+15550001111").
+		SetTo("+15552223333").
+		SetBody("Hello").
+		SetApplicationSid(*app.Sid).
+		SetStatusCallback("http://test/sms-status")); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AdvanceUntilIdle(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"sent", "sent", "delivered", "delivered"}
+	got := statusesPosted(mock, "http://test/app-status")
+	if len(got) != len(want) {
+		t.Fatalf("expected callbacks %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("callback %d: expected %s, got %s", i, want[i], got[i])
+		}
+	}
+	if n := len(mock.GetCallsTo("http://test/sms-status")); n != 0 {
+		t.Errorf("expected StatusCallback to be ignored with ApplicationSid, got %d callbacks", n)
+	}
+
+	_, err = e.CreateMessage((&twilioopenapi.CreateMessageParams{}).
+		SetPathAccountSid(string(subAccount.SID)).
+		SetMessagingServiceSid("MG00000000000000000000000000000000").
+		SetTo("+15552223333").
+		SetBody("Hello"))
+	if err == nil {
+		t.Error("expected MessagingServiceSid without From to fail")
+	}
+}
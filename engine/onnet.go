@@ -0,0 +1,195 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sprucehealth/twimulator/model"
+)
+
+// WithOnNetRouting delivers calls made with <Dial><Number> or CreateCall to a
+// number provisioned in any subaccount as an inbound call to that number's
+// voice application or URL, instead of leaving the outbound leg ringing until
+// AnswerCall. The two legs are connected: the outbound leg is answered, busy,
+// failed or unanswered as the inbound leg is, and once answered either leg
+// hanging up ends the other. A call that would be routed back to a number its
+// call chain has already reached fails as a dial loop.
+func WithOnNetRouting() EngineOption {
+	return func(e *EngineImpl) {
+		e.onNetRouting = true
+	}
+}
+
+// onNetRoute is where an outbound call to a number owned in the engine is delivered
+type onNetRoute struct {
+	accountSID model.SID // Account that owns the number
+	path       []string  // Numbers the call chain has already been routed through
+}
+
+// onNetOwner returns the account that owns a number when on-net routing is enabled
+func (e *EngineImpl) onNetOwner(number string) (model.SID, bool) {
+	if !e.onNetRouting || number == "" {
+		return "", false
+	}
+
+	e.subAccountsMu.RLock()
+	states := make(map[model.SID]*subAccountState, len(e.subAccounts))
+	for sid, state := range e.subAccounts {
+		states[sid] = state
+	}
+	e.subAccountsMu.RUnlock()
+
+	// Look at the accounts in order so a number provisioned twice always
+	// routes to the same one
+	sids := make([]model.SID, 0, len(states))
+	for sid := range states {
+		sids = append(sids, sid)
+	}
+	slices.Sort(sids)
+	for _, sid := range sids {
+		state := states[sid]
+		state.mu.RLock()
+		owned := state.incomingNumbers[number] != nil
+		state.mu.RUnlock()
+		if owned {
+			return sid, true
+		}
+	}
+	return "", false
+}
+
+// newOnNetRouteLocked builds the route of a call to a number owned by
+// accountSID. A child call carries the path of its parent, including the
+// number the parent was called on when it is inbound.
+func newOnNetRouteLocked(state *subAccountState, parentCallSID *model.SID, accountSID model.SID) *onNetRoute {
+	route := &onNetRoute{accountSID: accountSID}
+	if parentCallSID == nil {
+		return route
+	}
+	route.path = slices.Clone(state.onNetPaths[*parentCallSID])
+	if parent := state.calls[*parentCallSID]; parent != nil && parent.Direction == model.Inbound && !slices.Contains(route.path, parent.To) {
+		route.path = append(route.path, parent.To)
+	}
+	return route
+}
+
+// routeOnNet delivers the call as an inbound call to the account that owns the
+// number it is made to, or fails it when that would loop
+func (r *CallRunner) routeOnNet(ctx context.Context) {
+	route := r.onNet
+	if slices.Contains(route.path, r.call.To) {
+		err := fmt.Errorf("dial loop: %s is already in the call path %s", r.call.To, strings.Join(route.path, " -> "))
+		r.addCallEvent("onnet.loop_detected", map[string]any{
+			"to":   r.call.To,
+			"path": route.path,
+		})
+		r.recordError(err)
+		r.broadcast(&r.failedOnce, r.failedCh)
+		return
+	}
+
+	path := append(slices.Clone(route.path), r.call.To)
+	apiCall, err := r.engine.createIncomingCallWithParams(route.accountSID, r.call.From, r.call.To, map[string]string{}, func(state *subAccountState, call *model.Call) (*model.Call, error) {
+		if err := configureNumberCallLocked(state, call); err != nil {
+			return nil, err
+		}
+		state.onNetPaths[call.SID] = path
+		return call, nil
+	})
+	if err != nil {
+		r.addCallEvent("onnet.failed", map[string]any{
+			"account_sid": route.accountSID,
+			"to":          r.call.To,
+			"error":       err.Error(),
+		})
+		r.recordError(err)
+		r.broadcast(&r.failedOnce, r.failedCh)
+		return
+	}
+
+	legSID := model.SID(*apiCall.Sid)
+	r.engine.subAccountsMu.RLock()
+	owner := r.engine.subAccounts[route.accountSID]
+	r.engine.subAccountsMu.RUnlock()
+	owner.mu.RLock()
+	leg := owner.runners[legSID]
+	owner.mu.RUnlock()
+
+	r.addCallEvent("onnet.routed", map[string]any{
+		"account_sid": route.accountSID,
+		"call_sid":    legSID,
+	})
+	leg.addCallEvent("onnet.inbound", map[string]any{
+		"from_account_sid": r.call.AccountSID,
+		"from_call_sid":    r.call.SID,
+	})
+	r.engine.goTracked(func() { r.followOnNetLeg(ctx, leg) })
+}
+
+// followOnNetLeg ties the call to the inbound leg delivered for it. The call is
+// answered when the leg answers and ends like the leg when it does not. Once
+// both are answered, either hanging up ends the other.
+func (r *CallRunner) followOnNetLeg(ctx context.Context, leg *CallRunner) {
+	r.engine.park(r.done, leg.answerCh, leg.done)
+	select {
+	case <-ctx.Done():
+		return
+	case <-r.done:
+		// The caller stopped ringing before the leg answered
+		leg.Hangup()
+		return
+	case <-leg.answerCh:
+	case <-leg.done:
+		select {
+		case <-leg.answerCh:
+			// The leg answered and ended at once
+		default:
+			r.endUnansweredOnNet(leg)
+			return
+		}
+	}
+
+	r.addCallEvent("onnet.answered", map[string]any{
+		"call_sid": leg.call.SID,
+	})
+	r.broadcast(&r.answerOnce, r.answerCh)
+
+	r.engine.park(r.done, leg.done)
+	select {
+	case <-ctx.Done():
+	case <-r.done:
+		leg.Hangup()
+	case <-leg.done:
+		r.addCallEvent("onnet.hangup", map[string]any{
+			"call_sid": leg.call.SID,
+		})
+		r.Hangup()
+	}
+}
+
+// endUnansweredOnNet ends the call with the outcome of an inbound leg that
+// ended without answering
+func (r *CallRunner) endUnansweredOnNet(leg *CallRunner) {
+	leg.state.mu.RLock()
+	status := leg.call.Status
+	leg.state.mu.RUnlock()
+
+	r.addCallEvent("onnet.unanswered", map[string]any{
+		"call_sid": leg.call.SID,
+		"status":   status,
+	})
+	switch status {
+	case model.CallBusy:
+		r.broadcast(&r.busyOnce, r.busyCh)
+	case model.CallFailed:
+		r.broadcast(&r.failedOnce, r.failedCh)
+	default:
+		r.broadcast(&r.noAnswerOnce, r.noAnswerCh)
+	}
+}
@@ -0,0 +1,226 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+
+	"github.com/sprucehealth/twimulator/engine"
+	"github.com/sprucehealth/twimulator/model"
+	"github.com/sprucehealth/twimulator/twimtest"
+)
+
+// onNetRoutes answer the clinic line with clinic and the on-call line with onCall
+func onNetRoutes(clinic, onCall string) map[string]string {
+	return map[string]string{
+		"http://clinic.test/voice": `<Response>` + clinic + `</Response>`,
+		"http://oncall.test/voice": `<Response>` + onCall + `</Response>`,
+		"http://oncall.test/menu":  `<Response><Say>Goodbye</Say><Hangup/></Response>`,
+	}
+}
+
+// addOnNetLines points the clinic line on +15550001111 at the clinic TwiML and
+// provisions an on-call line on +15550002222 in a separate account
+func addOnNetLines(t *testing.T, e *engine.EngineImpl, clinic *model.SubAccount) *model.SubAccount {
+	t.Helper()
+	clinicSID := string(clinic.SID)
+	numbers, err := e.ListIncomingPhoneNumber((&twilioopenapi.ListIncomingPhoneNumberParams{}).
+		SetPathAccountSid(clinicSID).
+		SetPhoneNumber("+15550001111"))
+	if err != nil || len(numbers) != 1 {
+		t.Fatalf("expected the clinic line, got %v: %v", numbers, err)
+	}
+	if _, err := e.UpdateIncomingPhoneNumber(*numbers[0].Sid, (&twilioopenapi.UpdateIncomingPhoneNumberParams{}).
+		SetPathAccountSid(clinicSID).
+		SetVoiceUrl("http://clinic.test/voice")); err != nil {
+		t.Fatal(err)
+	}
+
+	onCallAccount := createTestSubAccount(t, e, "On Call")
+	app, err := e.CreateApplication((&twilioopenapi.CreateApplicationParams{}).
+		SetPathAccountSid(string(onCallAccount.SID)).
+		SetVoiceUrl("http://oncall.test/voice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.CreateIncomingPhoneNumber((&twilioopenapi.CreateIncomingPhoneNumberParams{}).
+		SetPathAccountSid(string(onCallAccount.SID)).
+		SetPhoneNumber("+15550002222").
+		SetVoiceApplicationSid(*app.Sid)); err != nil {
+		t.Fatal(err)
+	}
+	return onCallAccount
+}
+
+// onNetLeg returns the inbound call a call was routed to
+func onNetLeg(t *testing.T, e *engine.EngineImpl, accountSID, sid model.SID) model.SID {
+	t.Helper()
+	call, _ := e.GetCallState(accountSID, sid)
+	for _, ev := range call.Timeline {
+		if ev.Type == "onnet.routed" {
+			return model.SID(fmt.Sprint(ev.Detail["call_sid"]))
+		}
+	}
+	t.Fatalf("call %s was not routed on-net", sid)
+	return ""
+}
+
+func TestOnNetDial(t *testing.T) {
+	e, mock, clinic := newTestEngine(t, onNetRoutes(
+		`<Say>Connecting you to the on-call provider</Say><Dial action="http://clinic.test/dial-done"><Number>+15550002222</Number></Dial>`,
+		`<Say>You have reached the on-call provider</Say><Gather numDigits="1" action="http://oncall.test/menu" timeout="600"/>`),
+		engine.WithOnNetRouting())
+	onCall := addOnNetLines(t, e, clinic)
+
+	apiCall, err := e.CreateIncomingCall(clinic.SID, "+15559990000", "+15550001111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	callerSID := model.SID(*apiCall.Sid)
+	children := childCalls(t, e, clinic.SID, callerSID)
+	if len(children) != 1 {
+		t.Fatalf("expected one dialed call, got %d", len(children))
+	}
+
+	// The dialed leg is answered by the on-call line without AnswerCall
+	child, _ := e.GetCallState(clinic.SID, children[0])
+	if child.Status != model.CallInProgress {
+		t.Fatalf("expected the dialed call to be answered, got %s", child.Status)
+	}
+	legSID := onNetLeg(t, e, clinic.SID, children[0])
+	leg, ok := e.GetCallState(onCall.SID, legSID)
+	if !ok {
+		t.Fatalf("expected the inbound leg in the on-call account")
+	}
+	if leg.Direction != model.Inbound || leg.From != "+15559990000" || leg.To != "+15550002222" {
+		t.Errorf("expected an inbound call from the caller to the on-call line, got %s %s -> %s", leg.Direction, leg.From, leg.To)
+	}
+	twimtest.ExpectCall(t, e, legSID).Said("You have reached the on-call provider")
+
+	// The on-call provider hanging up ends the bridge
+	sendDigits(t, e, onCall.SID, legSID, "1")
+	twimtest.ExpectCall(t, e, legSID).Said("Goodbye").EndedWith(model.CallCompleted)
+	twimtest.ExpectCall(t, e, children[0]).EndedWith(model.CallCompleted)
+	twimtest.ExpectWebhook(t, mock, "http://clinic.test/dial-done").
+		WithFormValues(url.Values{
+			"DialCallStatus": {"completed"},
+			"DialCallSid":    {string(children[0])},
+		}).
+		Times(1)
+}
+
+func TestOnNetCreateCall(t *testing.T) {
+	e, _, clinic := newTestEngine(t, onNetRoutes(
+		`<Say>This is the clinic calling</Say>`,
+		`<Say>You have reached the on-call provider</Say><Gather numDigits="1" action="http://oncall.test/menu" timeout="600"/>`),
+		engine.WithOnNetRouting())
+	onCall := addOnNetLines(t, e, clinic)
+
+	call := mustCreateCall(t, e, newCreateCallParams(clinic.SID, "+15550001111", "+15550002222", "http://clinic.test/voice"))
+	settle(t, e)
+
+	// Both legs run their TwiML, and the outbound leg ending hangs up the other
+	legSID := onNetLeg(t, e, clinic.SID, call.SID)
+	twimtest.ExpectCall(t, e, call.SID).Said("This is the clinic calling").EndedWith(model.CallCompleted)
+	twimtest.ExpectCall(t, e, legSID).Said("You have reached the on-call provider").EndedWith(model.CallCompleted)
+	if leg, _ := e.GetCallState(onCall.SID, legSID); leg.From != "+15550001111" {
+		t.Errorf("expected the leg to come from the clinic line, got %s", leg.From)
+	}
+}
+
+func TestOnNetUnanswered(t *testing.T) {
+	for _, tc := range []struct {
+		onCall string
+		status string
+	}{
+		{onCall: `<Reject reason="busy"/>`, status: "busy"},
+		{onCall: `<Reject/>`, status: "no-answer"},
+	} {
+		t.Run(tc.status, func(t *testing.T) {
+			e, mock, clinic := newTestEngine(t, onNetRoutes(
+				`<Dial action="http://clinic.test/dial-done"><Number>+15550002222</Number></Dial>`,
+				tc.onCall),
+				engine.WithOnNetRouting())
+			addOnNetLines(t, e, clinic)
+
+			apiCall, err := e.CreateIncomingCall(clinic.SID, "+15559990000", "+15550001111")
+			if err != nil {
+				t.Fatal(err)
+			}
+			settle(t, e)
+
+			// The dial ends without waiting for its timeout
+			twimtest.ExpectWebhook(t, mock, "http://clinic.test/dial-done").
+				WithFormValues(url.Values{"DialCallStatus": {tc.status}}).
+				Times(1)
+			children := childCalls(t, e, clinic.SID, model.SID(*apiCall.Sid))
+			if child, _ := e.GetCallState(clinic.SID, children[0]); child.Status != model.CallStatus(tc.status) {
+				t.Errorf("expected the dialed call to end %s, got %s", tc.status, child.Status)
+			}
+		})
+	}
+}
+
+func TestOnNetDialLoop(t *testing.T) {
+	// The clinic forwards to the on-call line, which forwards back
+	e, _, clinic := newTestEngine(t, onNetRoutes(
+		`<Dial><Number>+15550002222</Number></Dial>`,
+		`<Dial action="http://oncall.test/dial-done"><Number>+15550001111</Number></Dial>`),
+		engine.WithOnNetRouting())
+	onCall := addOnNetLines(t, e, clinic)
+
+	apiCall, err := e.CreateIncomingCall(clinic.SID, "+15559990000", "+15550001111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	children := childCalls(t, e, clinic.SID, model.SID(*apiCall.Sid))
+	legSID := onNetLeg(t, e, clinic.SID, children[0])
+	forwarded := childCalls(t, e, onCall.SID, legSID)
+	if len(forwarded) != 1 {
+		t.Fatalf("expected the on-call line to dial once, got %d", len(forwarded))
+	}
+	loop, _ := e.GetCallState(onCall.SID, forwarded[0])
+	if loop.Status != model.CallFailed || !hasEvent(loop, "onnet.loop_detected") {
+		t.Errorf("expected the call back to the clinic to fail as a loop, got %s", loop.Status)
+	}
+	if n := len(e.ListCalls(engine.CallFilter{To: "+15550001111"})); n != 2 {
+		t.Errorf("expected only the original call and the looping call to the clinic, got %d", n)
+	}
+	snap, err := e.Snapshot(onCall.SID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Errors) == 0 {
+		t.Error("expected the loop to be recorded as an error")
+	}
+}
+
+func TestOnNetRoutingDisabled(t *testing.T) {
+	e, _, clinic := newTestEngine(t, onNetRoutes(`<Dial><Number>+15550002222</Number></Dial>`, `<Say>Unreachable</Say>`))
+	addOnNetLines(t, e, clinic)
+
+	apiCall, err := e.CreateIncomingCall(clinic.SID, "+15559990000", "+15550001111")
+	if err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	// Without the option the dialed leg rings until the test answers it
+	children := childCalls(t, e, clinic.SID, model.SID(*apiCall.Sid))
+	child, _ := e.GetCallState(clinic.SID, children[0])
+	if child.Status != model.CallRinging || hasEvent(child, "onnet.routed") {
+		t.Errorf("expected the dialed call to ring, got %s", child.Status)
+	}
+	if n := len(e.ListCalls(engine.CallFilter{To: "+15550002222"})); n != 1 {
+		t.Errorf("expected no inbound call to the on-call line, got %d calls", n)
+	}
+}
@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"strings"
+	"testing"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+
+	"github.com/sprucehealth/twimulator/engine"
+	"github.com/sprucehealth/twimulator/httpstub"
+	"github.com/sprucehealth/twimulator/model"
+)
+
+// conferenceHoldRoutes put answered calls in a conference and serve hold music
+// and announcements
+var conferenceHoldRoutes = map[string]string{
+	"http://test/answer":   `<Response><Dial><Conference statusCallback="http://test/conf-status" statusCallbackEvent="hold announcement">room</Conference></Dial></Response>`,
+	"http://test/hold":     `<Response><Say>Please wait</Say></Response>`,
+	"http://test/announce": `<Response><Say>Please wait</Say></Response>`,
+}
+
+// joinHoldConference answers two calls into the conference and returns it with
+// the first call
+func joinHoldConference(t *testing.T, e *engine.EngineImpl, accountSID model.SID) (*model.Conference, *model.Call) {
+	t.Helper()
+	mustProvisionNumbers(t, e, accountSID, "+15550003333")
+	call1 := mustCreateCall(t, e, newCreateCallParams(accountSID, "+15550001111", "+15552222222", "http://test/answer"))
+	call2 := mustCreateCall(t, e, newCreateCallParams(accountSID, "+15550003333", "+15554444444", "http://test/answer"))
+	answerCall(t, e, accountSID, call1.SID)
+	answerCall(t, e, accountSID, call2.SID)
+
+	conf, ok := e.GetConference(accountSID, "room")
+	if !ok || len(conf.Participants) != 2 {
+		t.Fatalf("expected both calls in the conference, got %+v", conf)
+	}
+	return conf, call1
+}
+
+func conferenceEvents(mock *httpstub.MockWebhookClient) []string {
+	var events []string
+	for _, c := range mock.GetCallsTo("http://test/conf-status") {
+		events = append(events, c.Form.Get("StatusCallbackEvent")+":"+c.Form.Get("CallSid"))
+	}
+	return events
+}
+
+func TestParticipantHold(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, conferenceHoldRoutes)
+	conf, call := joinHoldConference(t, e, subAccount.SID)
+
+	params := &twilioopenapi.UpdateParticipantParams{}
+	params.SetPathAccountSid(string(call.AccountSID))
+	params.SetHold(true)
+	params.SetHoldUrl("http://test/hold")
+	participant, err := e.UpdateParticipant(string(conf.SID), string(call.SID), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if participant.Hold == nil || !*participant.Hold {
+		t.Error("expected participant on hold")
+	}
+	settle(t, e)
+
+	// Hold URLs default to GET with the call parameters in the query string
+	var holdFetches int
+	for _, c := range mock.Calls {
+		if strings.HasPrefix(c.URL, "http://test/hold?") && strings.Contains(c.URL, "CallSid="+string(call.SID)) {
+			holdFetches++
+		}
+	}
+	if holdFetches != 1 {
+		t.Fatalf("expected hold TwiML fetched once, got %d", holdFetches)
+	}
+
+	params = &twilioopenapi.UpdateParticipantParams{}
+	params.SetPathAccountSid(string(call.AccountSID))
+	params.SetHold(false)
+	if _, err := e.UpdateParticipant(string(conf.SID), string(call.SID), params); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	got, _ := e.GetCallState(call.AccountSID, call.SID)
+	for _, eventType := range []string{"participant.hold", "twiml.say", "participant.unhold"} {
+		if !hasEvent(got, eventType) {
+			t.Errorf("expected %s event on timeline", eventType)
+		}
+	}
+	if got.Status != model.CallInProgress {
+		t.Errorf("expected call to stay in the conference, got %s", got.Status)
+	}
+	want := "participant-hold:" + string(call.SID) + ",participant-unhold:" + string(call.SID)
+	if events := strings.Join(conferenceEvents(mock), ","); events != want {
+		t.Errorf("expected %s, got %s", want, events)
+	}
+}
+
+func TestParticipantAnnounce(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, conferenceHoldRoutes)
+	conf, call := joinHoldConference(t, e, subAccount.SID)
+
+	params := &twilioopenapi.UpdateParticipantParams{}
+	params.SetPathAccountSid(string(call.AccountSID))
+	params.SetAnnounceUrl("http://test/announce")
+	if _, err := e.UpdateParticipant(string(conf.SID), string(call.SID), params); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	announces := mock.GetCallsTo("http://test/announce")
+	if len(announces) != 1 || announces[0].Form.Get("CallSid") != string(call.SID) {
+		t.Fatalf("expected announcement fetched for %s, got %+v", call.SID, announces)
+	}
+	want := "announcement-end:" + string(call.SID)
+	if events := strings.Join(conferenceEvents(mock), ","); events != want {
+		t.Errorf("expected %s, got %s", want, events)
+	}
+}
+
+func TestConferenceAnnounce(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, conferenceHoldRoutes)
+	conf, call := joinHoldConference(t, e, subAccount.SID)
+
+	params := &twilioopenapi.UpdateConferenceParams{}
+	params.SetPathAccountSid(string(call.AccountSID))
+	params.SetAnnounceUrl("http://test/announce")
+	if _, err := e.UpdateConference(string(conf.SID), params); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	if announces := mock.GetCallsTo("http://test/announce"); len(announces) != 2 {
+		t.Fatalf("expected announcement played to both participants, got %d", len(announces))
+	}
+	if events := strings.Join(conferenceEvents(mock), ","); events != "announcement-end:" {
+		t.Errorf("expected a single conference announcement-end, got %s", events)
+	}
+}
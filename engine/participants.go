@@ -0,0 +1,389 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+
+	"github.com/sprucehealth/twimulator/model"
+	"github.com/sprucehealth/twimulator/twiml"
+)
+
+// CreateParticipant dials a call straight into a conference. The conference is identified by its SID or, to
+// create it on demand, by its friendly name.
+func (e *EngineImpl) CreateParticipant(conferenceSid string, params *twilioopenapi.CreateParticipantParams) (*twilioopenapi.ApiV2010Participant, error) {
+	if params == nil || params.PathAccountSid == nil || *params.PathAccountSid == "" {
+		return nil, fmt.Errorf("PathAccountSid is required")
+	}
+	if conferenceSid == "" {
+		return nil, fmt.Errorf("ConferenceSid is required")
+	}
+	if params.From == nil || *params.From == "" {
+		return nil, fmt.Errorf("From is required")
+	}
+	if params.To == nil || *params.To == "" {
+		return nil, fmt.Errorf("To is required")
+	}
+	accountSID := model.SID(*params.PathAccountSid)
+
+	conference := &twiml.Conference{
+		Name:                   conferenceSid,
+		Beep:                   true,
+		StartConferenceOnEnter: true,
+		WaitMethod:             "POST",
+	}
+	if params.Beep != nil {
+		switch *params.Beep {
+		case "true", "onEnter", "onExit":
+		case "false":
+			conference.Beep = false
+		default:
+			return nil, fmt.Errorf("invalid Beep %q: must be true, false, onEnter or onExit", *params.Beep)
+		}
+	}
+	if params.Muted != nil {
+		conference.Muted = *params.Muted
+	}
+	if params.StartConferenceOnEnter != nil {
+		conference.StartConferenceOnEnter = *params.StartConferenceOnEnter
+	}
+	if params.EndConferenceOnExit != nil {
+		conference.EndConferenceOnExit = *params.EndConferenceOnExit
+	}
+	if params.WaitUrl != nil {
+		conference.WaitURL = *params.WaitUrl
+	}
+	if params.WaitMethod != nil && *params.WaitMethod != "" {
+		conference.WaitMethod = strings.ToUpper(*params.WaitMethod)
+	}
+	if params.ConferenceStatusCallback != nil {
+		conference.StatusCallback = *params.ConferenceStatusCallback
+	}
+	if params.ConferenceStatusCallbackEvent != nil {
+		conference.StatusCallbackEvent = strings.Join(*params.ConferenceStatusCallbackEvent, " ")
+	}
+	if params.ConferenceRecord != nil {
+		conference.Record = *params.ConferenceRecord
+	}
+	if params.ConferenceRecordingStatusCallback != nil {
+		conference.RecordingStatusCallback = *params.ConferenceRecordingStatusCallback
+	}
+	if params.Coaching != nil && *params.Coaching {
+		if params.CallSidToCoach == nil || *params.CallSidToCoach == "" {
+			return nil, fmt.Errorf("CallSidToCoach is required when Coaching is true")
+		}
+		conference.Coach = *params.CallSidToCoach
+	}
+	earlyMedia := params.EarlyMedia != nil && *params.EarlyMedia
+
+	// Get subaccount state
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[accountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return nil, notFoundError(accountSID)
+	}
+
+	state.mu.Lock()
+	if conf := conferenceBySIDLocked(state, conferenceSid); conf != nil {
+		conference.Name = conf.Name
+	} else if strings.HasPrefix(conferenceSid, "CF") {
+		state.mu.Unlock()
+		return nil, notFoundError(model.SID(conferenceSid))
+	}
+	conf := e.getOrCreateConferenceLocked(state, accountSID, conference)
+	if conference.Coach != "" && !slices.Contains(conf.Participants, model.SID(conference.Coach)) {
+		state.mu.Unlock()
+		return nil, fmt.Errorf("call %s is not a participant in conference %s", conference.Coach, conf.SID)
+	}
+	state.mu.Unlock()
+
+	callParams := &twilioopenapi.CreateCallParams{
+		PathAccountSid:                params.PathAccountSid,
+		From:                          params.From,
+		To:                            params.To,
+		Timeout:                       params.Timeout,
+		StatusCallback:                params.StatusCallback,
+		StatusCallbackMethod:          params.StatusCallbackMethod,
+		StatusCallbackEvent:           params.StatusCallbackEvent,
+		MachineDetection:              params.MachineDetection,
+		MachineDetectionTimeout:       params.MachineDetectionTimeout,
+		AsyncAmdStatusCallback:        params.AmdStatusCallback,
+		AsyncAmdStatusCallbackMethod:  params.AmdStatusCallbackMethod,
+		RecordingStatusCallback:       params.RecordingStatusCallback,
+		RecordingStatusCallbackMethod: params.RecordingStatusCallbackMethod,
+	}
+	if params.CallerId != nil && *params.CallerId != "" {
+		callParams.From = params.CallerId
+	}
+	participantTwiML := &twiml.Response{
+		Children: []twiml.Node{
+			&twiml.Dial{Children: []twiml.Node{conference}},
+		},
+	}
+	apiCall, err := e.createCall(callParams, nil, participantTwiML)
+	if err != nil {
+		return nil, err
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	call := state.calls[model.SID(*apiCall.Sid)]
+	e.addCallEventLocked(state, call, "participant.created", map[string]any{
+		"conference_sid": conf.SID,
+		"conference":     conf.Name,
+		"early_media":    earlyMedia,
+		"beep":           conference.Beep,
+		"coach":          conference.Coach,
+	})
+	ps := &model.ParticipantState{
+		Muted:                  conference.Muted,
+		StartConferenceOnEnter: conference.StartConferenceOnEnter,
+		EndConferenceOnExit:    conference.EndConferenceOnExit,
+		Coaching:               conference.Coach != "",
+		CallSidToCoach:         model.SID(conference.Coach),
+	}
+	return buildAPIParticipantResponse(conf, call, ps), nil
+}
+
+// ListParticipant lists the participants connected to a conference
+func (e *EngineImpl) ListParticipant(conferenceSid string, params *twilioopenapi.ListParticipantParams) ([]twilioopenapi.ApiV2010Participant, error) {
+	if params == nil || params.PathAccountSid == nil || *params.PathAccountSid == "" {
+		return nil, fmt.Errorf("PathAccountSid is required")
+	}
+	accountSID := model.SID(*params.PathAccountSid)
+	// Get subaccount state
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[accountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return nil, notFoundError(accountSID)
+	}
+
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	conf := conferenceBySIDLocked(state, conferenceSid)
+	if conf == nil {
+		return nil, notFoundError(model.SID(conferenceSid))
+	}
+
+	participants := []twilioopenapi.ApiV2010Participant{}
+	for _, callSID := range conf.Participants {
+		call := state.calls[callSID]
+		ps := state.participantStates[conf.SID][callSID]
+		if call == nil || ps == nil {
+			continue
+		}
+		if params.Muted != nil && ps.Muted != *params.Muted {
+			continue
+		}
+		if params.Hold != nil && ps.Hold != *params.Hold {
+			continue
+		}
+		if params.Coaching != nil && ps.Coaching != *params.Coaching {
+			continue
+		}
+		participants = append(participants, *buildAPIParticipantResponse(conf, call, ps))
+		if params.Limit != nil && len(participants) >= *params.Limit {
+			break
+		}
+	}
+	return participants, nil
+}
+
+// DeleteParticipant removes a participant from a conference. The call continues with the TwiML after its <Dial>.
+func (e *EngineImpl) DeleteParticipant(conferenceSid string, callSid string, params *twilioopenapi.DeleteParticipantParams) error {
+	if params == nil || params.PathAccountSid == nil || *params.PathAccountSid == "" {
+		return fmt.Errorf("PathAccountSid is required")
+	}
+	accountSID := model.SID(*params.PathAccountSid)
+	// Get subaccount state
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[accountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return notFoundError(accountSID)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	conf := conferenceBySIDLocked(state, conferenceSid)
+	if conf == nil {
+		return notFoundError(model.SID(conferenceSid))
+	}
+	callSID := model.SID(callSid)
+	if !slices.Contains(conf.Participants, callSID) {
+		return notFoundError(callSID)
+	}
+	runner, exists := state.runners[callSID]
+	if !exists {
+		return notFoundError(callSID)
+	}
+	// A pending signal means the participant is already being removed
+	notify(e, runner.kickCh, struct{}{})
+	return nil
+}
+
+// conferenceBySIDLocked returns the conference with the given SID. Caller must hold state.mu.
+func conferenceBySIDLocked(state *subAccountState, sid string) *model.Conference {
+	for _, conf := range state.conferences {
+		if string(conf.SID) == sid {
+			return conf
+		}
+	}
+	return nil
+}
+
+// buildAPIParticipantResponse builds the Participant resource of a call in a conference
+func buildAPIParticipantResponse(conf *model.Conference, call *model.Call, ps *model.ParticipantState) *twilioopenapi.ApiV2010Participant {
+	accountSid := string(conf.AccountSID)
+	callSid := string(call.SID)
+	conferenceSid := string(conf.SID)
+	dateCreated := call.StartAt.UTC().Format(time.RFC1123Z)
+	status := participantStatus(conf, call)
+	muted := ps.Muted
+	hold := ps.Hold
+	coaching := ps.Coaching
+	startConferenceOnEnter := ps.StartConferenceOnEnter
+	endConferenceOnExit := ps.EndConferenceOnExit
+	uri := fmt.Sprintf("/2010-04-01/Accounts/%s/Conferences/%s/Participants/%s.json", accountSid, conferenceSid, callSid)
+	resp := &twilioopenapi.ApiV2010Participant{
+		AccountSid:             &accountSid,
+		CallSid:                &callSid,
+		ConferenceSid:          &conferenceSid,
+		DateCreated:            &dateCreated,
+		DateUpdated:            &dateCreated,
+		Status:                 &status,
+		Muted:                  &muted,
+		Hold:                   &hold,
+		Coaching:               &coaching,
+		StartConferenceOnEnter: &startConferenceOnEnter,
+		EndConferenceOnExit:    &endConferenceOnExit,
+		Uri:                    &uri,
+	}
+	if ps.CallSidToCoach != "" {
+		callSidToCoach := string(ps.CallSidToCoach)
+		resp.CallSidToCoach = &callSidToCoach
+	}
+	return resp
+}
+
+// participantStatus maps the state of a participant's call to a Participant status
+func participantStatus(conf *model.Conference, call *model.Call) string {
+	if slices.Contains(conf.Participants, call.SID) {
+		return "connected"
+	}
+	switch call.Status {
+	case model.CallInitiated, model.CallQueued:
+		return "queued"
+	case model.CallRinging:
+		return "ringing"
+	case model.CallInProgress, model.CallAnswered:
+		return "connecting"
+	case model.CallBusy, model.CallFailed, model.CallNoAnswer, model.CallCanceled:
+		return "failed"
+	default:
+		return "complete"
+	}
+}
+
+// announcement is an AnnounceUrl to play to a conference participant
+type announcement struct {
+	url    string
+	method string
+	// done is called once the announcement has played
+	done func(currentTwimlDocumentURL string)
+}
+
+// participantHold returns the hold settings of the call in a conference
+func (r *CallRunner) participantHold(confSID model.SID) (hold bool, holdURL, holdMethod string) {
+	r.state.mu.RLock()
+	defer r.state.mu.RUnlock()
+	ps := r.state.participantStates[confSID][r.call.SID]
+	if ps == nil {
+		return false, "", ""
+	}
+	holdMethod = ps.HoldMethod
+	if holdMethod == "" {
+		holdMethod = "GET"
+	}
+	return ps.Hold, ps.HoldUrl, holdMethod
+}
+
+// notifyParticipantLocked signals a participant's runner that its hold settings changed. Caller must hold state.mu.
+func notifyParticipantLocked(state *subAccountState, callSID model.SID) {
+	runner, exists := state.runners[callSID]
+	if !exists {
+		return
+	}
+	// A pending signal is enough, the runner reads the latest state
+	notify(runner.engine, runner.participantCh, struct{}{})
+}
+
+// announceLocked plays an announcement to conference participants and sends the announcement-end
+// callback once all of them have heard it. Caller must hold state.mu.
+func (e *EngineImpl) announceLocked(state *subAccountState, conf *model.Conference, participants []model.SID, announceURL, announceMethod string, callSID *model.SID) {
+	if announceMethod == "" {
+		announceMethod = "POST"
+	}
+	remaining := 0
+	done := func(currentTwimlDocumentURL string) {
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		remaining--
+		if remaining == 0 {
+			e.queueConferenceStatusCallbackLocked(state, conf, "announcement-end", callSID, currentTwimlDocumentURL)
+		}
+	}
+	for _, participantSID := range participants {
+		runner, exists := state.runners[participantSID]
+		if !exists {
+			continue
+		}
+		if notify(e, runner.announceCh, announcement{url: announceURL, method: announceMethod, done: done}) {
+			remaining++
+		} else {
+			state.errors = append(state.errors, fmt.Errorf("too many pending announcements for call %s", participantSID))
+		}
+	}
+	e.addConferenceEventLocked(state, conf, "conference.announcement", map[string]any{
+		"announce_url":    announceURL,
+		"announce_method": announceMethod,
+		"participants":    remaining,
+	})
+	if remaining == 0 {
+		e.queueConferenceStatusCallbackLocked(state, conf, "announcement-end", callSID, "")
+	}
+}
+
+// queueConferenceStatusCallbackLocked queues a conference status callback for serial execution if the
+// conference has a StatusCallback subscribed to the event. Caller must hold state.mu.
+func (e *EngineImpl) queueConferenceStatusCallbackLocked(state *subAccountState, conf *model.Conference, eventType string, callSID *model.SID, currentTwimlDocumentURL string) {
+	// The callback queue is closed once the conference has ended
+	if conf.StatusCallback == "" || conf.Status == model.ConferenceCompleted {
+		return
+	}
+	if !e.shouldSendConferenceStatusCallback(conf, eventType) {
+		detail := map[string]any{"event": eventType}
+		if callSID != nil {
+			detail["call_sid"] = *callSID
+		}
+		e.addConferenceEventLocked(state, conf, "webhook.conference_status_callback_skipped", detail)
+		return
+	}
+	e.enqueue(conf.CallbackQueue, func() {
+		e.sendConferenceStatusCallback(state, conf, eventType, callSID, currentTwimlDocumentURL)
+	})
+}
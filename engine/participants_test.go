@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"testing"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+
+	"github.com/sprucehealth/twimulator/model"
+)
+
+// agentsRoutes answer calls into the agents conference
+var agentsRoutes = map[string]string{
+	"http://test/answer": `<Response><Dial><Conference>agents</Conference></Dial><Say>Goodbye</Say></Response>`,
+}
+
+func newCreateParticipantParams(accountSID model.SID, to string) *twilioopenapi.CreateParticipantParams {
+	params := &twilioopenapi.CreateParticipantParams{}
+	params.SetPathAccountSid(string(accountSID))
+	params.SetFrom("+15550001111")
+	params.SetTo(to)
+	return params
+}
+
+func TestCreateParticipant(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, agentsRoutes)
+
+	// The conference is created on demand from its friendly name
+	params := newCreateParticipantParams(subAccount.SID, "+15552220001")
+	params.SetEarlyMedia(true)
+	params.SetBeep("onEnter")
+	params.SetConferenceStatusCallback("http://test/conf-status")
+	params.SetConferenceStatusCallbackEvent([]string{"join"})
+	agent, err := e.CreateParticipant("agents", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if agent.ConferenceSid == nil || agent.Status == nil || *agent.Status != "queued" {
+		t.Fatalf("unexpected participant %+v", agent)
+	}
+	settle(t, e)
+	if err := e.AnswerCall(subAccount.SID, model.SID(*agent.CallSid)); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	conf, ok := e.GetConference(subAccount.SID, "agents")
+	if !ok || string(conf.SID) != *agent.ConferenceSid || len(conf.Participants) != 1 {
+		t.Fatalf("expected the agent in the conference, got %+v", conf)
+	}
+	if len(mock.GetCallsTo("http://test/conf-status")) != 1 {
+		t.Error("expected a participant-join callback")
+	}
+	// API participants join without fetching TwiML
+	if len(mock.Calls) != 1 {
+		t.Errorf("expected only the conference callback, got %d webhooks", len(mock.Calls))
+	}
+
+	// A supervisor coaching the agent, added by conference SID
+	params = newCreateParticipantParams(subAccount.SID, "+15552220002")
+	params.SetCoaching(true)
+	params.SetCallSidToCoach(*agent.CallSid)
+	params.SetMuted(true)
+	supervisor, err := e.CreateParticipant(*agent.ConferenceSid, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	if err := e.AnswerCall(subAccount.SID, model.SID(*supervisor.CallSid)); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	fetched, err := e.FetchParticipant(*agent.ConferenceSid, *supervisor.CallSid, &twilioopenapi.FetchParticipantParams{PathAccountSid: params.PathAccountSid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *fetched.Status != "connected" || !*fetched.Coaching || *fetched.CallSidToCoach != *agent.CallSid || !*fetched.Muted {
+		t.Errorf("unexpected supervisor %+v", fetched)
+	}
+}
+
+func TestCreateParticipantValidation(t *testing.T) {
+	e, _, subAccount := newTestEngine(t, agentsRoutes)
+
+	params := newCreateParticipantParams(subAccount.SID, "")
+	if _, err := e.CreateParticipant("agents", params); err == nil {
+		t.Error("expected error for missing To")
+	}
+	params = newCreateParticipantParams(subAccount.SID, "+15552220001")
+	if _, err := e.CreateParticipant("CFFAKE00000000000000000000000000", params); err == nil {
+		t.Error("expected error for unknown conference SID")
+	}
+	params.SetBeep("sometimes")
+	if _, err := e.CreateParticipant("agents", params); err == nil {
+		t.Error("expected error for invalid Beep")
+	}
+	params = newCreateParticipantParams(subAccount.SID, "+15552220001")
+	params.SetCoaching(true)
+	if _, err := e.CreateParticipant("agents", params); err == nil {
+		t.Error("expected error for Coaching without CallSidToCoach")
+	}
+	params.SetCallSidToCoach("CAFAKE00000000000000000000000000")
+	if _, err := e.CreateParticipant("agents", params); err == nil {
+		t.Error("expected error for coaching a call outside the conference")
+	}
+}
+
+func TestListAndDeleteParticipant(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, agentsRoutes)
+
+	// A caller joins through TwiML, an agent through the API
+	caller := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15553334444", "http://test/answer"))
+	settle(t, e)
+	if err := e.AnswerCall(subAccount.SID, caller.SID); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	conf, _ := e.GetConference(subAccount.SID, "agents")
+
+	agent, err := e.CreateParticipant(string(conf.SID), newCreateParticipantParams(subAccount.SID, "+15552220001"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	if err := e.AnswerCall(subAccount.SID, model.SID(*agent.CallSid)); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	update := &twilioopenapi.UpdateParticipantParams{}
+	update.SetPathAccountSid(string(subAccount.SID))
+	update.SetMuted(true)
+	if _, err := e.UpdateParticipant(string(conf.SID), string(caller.SID), update); err != nil {
+		t.Fatal(err)
+	}
+
+	list := &twilioopenapi.ListParticipantParams{}
+	list.SetPathAccountSid(string(subAccount.SID))
+	all, err := e.ListParticipant(string(conf.SID), list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected 2 participants, got %d", len(all))
+	}
+	list.SetMuted(true)
+	muted, _ := e.ListParticipant(string(conf.SID), list)
+	if len(muted) != 1 || *muted[0].CallSid != string(caller.SID) {
+		t.Errorf("expected only the caller muted, got %+v", muted)
+	}
+	list = &twilioopenapi.ListParticipantParams{}
+	list.SetPathAccountSid(string(subAccount.SID))
+	list.SetHold(true)
+	if held, _ := e.ListParticipant(string(conf.SID), list); len(held) != 0 {
+		t.Errorf("expected no participants on hold, got %d", len(held))
+	}
+
+	// Removing the caller continues its TwiML after <Dial>, the agent has none and hangs up
+	del := &twilioopenapi.DeleteParticipantParams{}
+	del.SetPathAccountSid(string(subAccount.SID))
+	if err := e.DeleteParticipant(string(conf.SID), string(caller.SID), del); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.DeleteParticipant(string(conf.SID), *agent.CallSid, del); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	got, _ := e.GetCallState(subAccount.SID, caller.SID)
+	if !hasEvent(got, "dial.conference.removed") || !hasEvent(got, "twiml.say") {
+		t.Error("expected the caller to be removed and continue with <Say>")
+	}
+	agentCall, _ := e.GetCallState(subAccount.SID, model.SID(*agent.CallSid))
+	if agentCall.Status != model.CallCompleted {
+		t.Errorf("expected agent call completed, got %s", agentCall.Status)
+	}
+	if len(mock.GetCallsTo("http://test/answer")) != 1 {
+		t.Error("expected only the caller to fetch TwiML")
+	}
+	if err := e.DeleteParticipant(string(conf.SID), string(caller.SID), del); err == nil {
+		t.Error("expected error removing a participant that already left")
+	}
+}
@@ -0,0 +1,132 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sprucehealth/twimulator/model"
+)
+
+// recordRoutes answer calls with record followed by a prompt, and hang up
+// from the record action
+func recordRoutes(record string) map[string]string {
+	return map[string]string{
+		"http://test/answer":   `<Response>` + record + `<Say>No recording</Say></Response>`,
+		"http://test/recorded": `<Response><Hangup/></Response>`,
+	}
+}
+
+func TestRecordFinishOnKey(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, recordRoutes(`<Record finishOnKey="#" action="/recorded" recordingStatusCallback="/rec-status" recordingStatusCallbackEvent="in-progress completed"/>`))
+	call := startCall(t, e, subAccount.SID)
+
+	if statuses := mock.GetCallsTo("http://test/rec-status"); len(statuses) != 1 || statuses[0].Form.Get("RecordingStatus") != "in-progress" {
+		t.Fatalf("expected an in-progress callback when recording starts, got %+v", statuses)
+	}
+
+	e.Advance(7 * time.Second)
+	// Digits outside finishOnKey are ignored
+	if err := e.SendDigits(call.AccountSID, call.SID, "5"); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	if len(mock.GetCallsTo("http://test/recorded")) != 0 {
+		t.Fatal("expected digit 5 not to end the recording")
+	}
+	if err := e.SendDigits(call.AccountSID, call.SID, "#"); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	actions := mock.GetCallsTo("http://test/recorded")
+	if len(actions) != 1 {
+		t.Fatalf("expected the action after #, got %d", len(actions))
+	}
+	form := actions[0].Form
+	if form.Get("Digits") != "#" || form.Get("RecordingDuration") != "7" || form.Get("RecordingStatus") != "completed" {
+		t.Errorf("unexpected action form %v", form)
+	}
+	statuses := mock.GetCallsTo("http://test/rec-status")
+	if len(statuses) != 2 || statuses[1].Form.Get("RecordingStatus") != "completed" || statuses[1].Form.Get("RecordingDuration") != "7" {
+		t.Fatalf("expected a completed callback, got %+v", statuses)
+	}
+
+	recording, err := e.GetRecording(call.AccountSID, model.SID(form.Get("RecordingSid")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recording.Duration != 7 || recording.Status != "completed" {
+		t.Errorf("unexpected recording %+v", recording)
+	}
+}
+
+func TestRecordSilenceTimeout(t *testing.T) {
+	for _, tc := range []struct {
+		trim     string
+		duration string
+	}{
+		{trim: "trim-silence", duration: "4"},
+		{trim: "do-not-trim", duration: "7"},
+	} {
+		t.Run(tc.trim, func(t *testing.T) {
+			e, mock, subAccount := newTestEngine(t, recordRoutes(`<Record timeout="3" trim="`+tc.trim+`" action="/recorded"/>`))
+			call := startCall(t, e, subAccount.SID)
+
+			e.Advance(4 * time.Second)
+			if err := e.SendSilence(call.AccountSID, call.SID); err != nil {
+				t.Fatal(err)
+			}
+			settle(t, e)
+			e.Advance(2 * time.Second)
+			settle(t, e)
+			if len(mock.GetCallsTo("http://test/recorded")) != 0 {
+				t.Fatal("expected the recording to continue until the silence timeout")
+			}
+			e.Advance(time.Second)
+			settle(t, e)
+
+			actions := mock.GetCallsTo("http://test/recorded")
+			if len(actions) != 1 {
+				t.Fatalf("expected the action after the silence timeout, got %d", len(actions))
+			}
+			if got := actions[0].Form.Get("RecordingDuration"); got != tc.duration {
+				t.Errorf("expected RecordingDuration %s, got %s", tc.duration, got)
+			}
+		})
+	}
+}
+
+func TestRecordAbsent(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, recordRoutes(`<Record timeout="2" action="/recorded" recordingStatusCallback="/rec-status" recordingStatusCallbackEvent="absent"/>`))
+	call := startCall(t, e, subAccount.SID)
+
+	if err := e.SendSilence(call.AccountSID, call.SID); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	e.Advance(2 * time.Second)
+	settle(t, e)
+
+	if len(mock.GetCallsTo("http://test/recorded")) != 0 {
+		t.Error("expected no action request for an empty recording")
+	}
+	statuses := mock.GetCallsTo("http://test/rec-status")
+	if len(statuses) != 1 || statuses[0].Form.Get("RecordingStatus") != "absent" {
+		t.Fatalf("expected an absent callback, got %+v", statuses)
+	}
+	got, _ := e.GetCallState(call.AccountSID, call.SID)
+	var said []string
+	for _, event := range got.Timeline {
+		if event.Type == "twiml.say" {
+			said = append(said, "say")
+		}
+	}
+	if strings.Join(said, ",") != "say" {
+		t.Error("expected the call to continue with the next verb")
+	}
+}
@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"testing"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+
+	"github.com/sprucehealth/twimulator/engine"
+	"github.com/sprucehealth/twimulator/model"
+	"github.com/sprucehealth/twimulator/twiml"
+	"github.com/sprucehealth/twimulator/twimtest"
+)
+
+// rejectRoutes reject calls as busy, reject them unanswered, or reject them
+// too late
+var rejectRoutes = map[string]string{
+	"http://test/spam":   `<Response><Reject reason="busy"/></Response>`,
+	"http://test/closed": `<Response><Reject/></Response>`,
+	"http://test/late":   `<Response><Say>Hello</Say><Reject/><Say>Unreachable</Say></Response>`,
+}
+
+// addRejectLine provisions +15550002222 with an application answered by voiceURL
+func addRejectLine(t *testing.T, e *engine.EngineImpl, accountSID model.SID, voiceURL string) {
+	t.Helper()
+	app, err := e.CreateApplication((&twilioopenapi.CreateApplicationParams{}).
+		SetPathAccountSid(string(accountSID)).
+		SetVoiceUrl(voiceURL).
+		SetStatusCallback("http://test/status"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustProvisionNumberWithApp(t, e, accountSID, "+15550002222", *app.Sid)
+}
+
+func TestRejectInboundCall(t *testing.T) {
+	for _, tc := range []struct {
+		url    string
+		reason string
+		status model.CallStatus
+	}{
+		{url: "http://test/spam", reason: "busy", status: model.CallBusy},
+		{url: "http://test/closed", reason: "rejected", status: model.CallNoAnswer},
+	} {
+		t.Run(string(tc.status), func(t *testing.T) {
+			e, mock, subAccount := newTestEngine(t, rejectRoutes)
+			addRejectLine(t, e, subAccount.SID, tc.url)
+			apiCall, err := e.CreateIncomingCall(subAccount.SID, "+15552223333", "+15550002222")
+			if err != nil {
+				t.Fatal(err)
+			}
+			settle(t, e)
+
+			sid := model.SID(*apiCall.Sid)
+			twimtest.ExpectCall(t, e, sid).
+				Rejected(tc.reason).
+				EndedWith(tc.status)
+			call, _ := e.GetCallState(subAccount.SID, sid)
+			if call.AnsweredAt != nil {
+				t.Errorf("expected the rejected call to never be answered, got %v", call.AnsweredAt)
+			}
+			if call.EndedAt == nil {
+				t.Error("expected the rejected call to end")
+			}
+			if !hasEvent(call, "twiml.reject") {
+				t.Error("expected the reject on the timeline")
+			}
+			twimtest.ExpectWebhook(t, mock, "http://test/status").
+				WithForm("CallStatus", string(tc.status)).
+				Times(1)
+		})
+	}
+}
+
+func TestRejectAnsweredCall(t *testing.T) {
+	e, _, subAccount := newTestEngine(t, rejectRoutes)
+	addRejectLine(t, e, subAccount.SID, "http://test/late")
+	apiCall, err := e.CreateIncomingCall(subAccount.SID, "+15552223333", "+15550002222")
+	if err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	sid := model.SID(*apiCall.Sid)
+	twimtest.ExpectCall(t, e, sid).
+		Said("Hello").
+		EndedWith(model.CallCompleted)
+	call, _ := e.GetCallState(subAccount.SID, sid)
+	if call.AnsweredAt == nil {
+		t.Error("expected the call to be answered")
+	}
+	if hasEvent(call, "twiml.reject") {
+		t.Error("expected an answered call not to be rejected")
+	}
+	if rejectErr := findEvent(call, "twiml.reject.error"); rejectErr == nil || rejectErr.Detail["error_code"] != engine.ErrorCodeSchemaValidation {
+		t.Errorf("expected a schema validation error on the timeline, got %+v", rejectErr)
+	}
+	for _, node := range call.ExecutedTwiML {
+		if say, ok := node.(*twiml.Say); ok && say.Text == "Unreachable" {
+			t.Error("expected the call to end at <Reject>")
+		}
+	}
+}
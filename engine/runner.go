@@ -11,6 +11,7 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -33,6 +34,14 @@ type dequeueResult struct {
 	partnerSID model.SID // SID of the bridged partner (if bridged)
 }
 
+// autoSpeechTimeout is the silence after which speechTimeout="auto" ends speech input
+const autoSpeechTimeout = time.Second
+
+type speechInput struct {
+	transcript string
+	confidence float64
+}
+
 // CallRunner executes TwiML for a call
 type CallRunner struct {
 	call    *model.Call
@@ -42,7 +51,14 @@ type CallRunner struct {
 	timeout time.Duration
 
 	// State for gather
-	gatherCh             chan string
+	dtmfCh               chan struct{}    // ready while dtmf holds digits
+	dtmf                 string           // digits sent but not yet read, guarded by state.mu
+	bargeIn              bool             // digits interrupt the prompt that is playing
+	speechCh             chan speechInput // recognized speech for the gather that is listening
+	gathering            bool             // a gather is listening for speech, guarded by state.mu
+	answeredByCh         chan string      // simulated answering machine detection outcome
+	amd                  *amdConfig       // nil unless MachineDetection was requested
+	unansweredStatus     model.CallStatus // ends a caller that answerOnBridge left unanswered
 	hangupCh             chan struct{}
 	hangupOnce           sync.Once // Ensures hangupCh is closed only once
 	answerCh             chan struct{}
@@ -50,11 +66,20 @@ type CallRunner struct {
 	busyCh               chan struct{}
 	busyOnce             sync.Once // Ensures busyCh is closed only once
 	failedCh             chan struct{}
-	failedOnce           sync.Once          // Ensures failedCh is closed only once
-	dequeueCh            chan dequeueResult // for explicit dequeue with result and partner info
-	urlUpdateCh          chan string        // signals URL update with new URL
-	conferenceCompleteCh chan struct{}      // signals conference completion via API
-	bridgeEndCh          chan struct{}      // signals bridge partner has hung up
+	failedOnce           sync.Once // Ensures failedCh is closed only once
+	noAnswerCh           chan struct{}
+	noAnswerOnce         sync.Once                  // Ensures noAnswerCh is closed only once
+	onNet                *onNetRoute                // set when the call is delivered to a number owned in the engine
+	dequeueCh            chan dequeueResult         // for explicit dequeue with result and partner info
+	urlUpdateCh          chan string                // signals URL update with new URL
+	conferenceCompleteCh chan struct{}              // signals conference completion via API
+	bridgeEndCh          chan struct{}              // signals bridge partner has hung up
+	participantCh        chan struct{}              // signals conference participant hold changes
+	kickCh               chan struct{}              // signals removal from a conference via API
+	silenceCh            chan struct{}              // signals the caller went silent
+	announceCh           chan announcement          // announcements to play to a conference participant
+	streams              map[model.SID]*mediaStream // active media streams, guarded by state.mu
+	participantTwiML     *twiml.Response            // joins a participant created through the API to its conference
 	done                 chan struct{}
 }
 
@@ -66,22 +91,32 @@ func NewCallRunner(call *model.Call, state *subAccountState, engine *EngineImpl,
 		state:                state,
 		engine:               engine,
 		timeout:              timeout,
-		gatherCh:             make(chan string, 1),
+		dtmfCh:               make(chan struct{}, 1),
+		speechCh:             make(chan speechInput, 1),
+		answeredByCh:         make(chan string, 1),
 		hangupCh:             make(chan struct{}), // No buffer - will be closed to broadcast
 		answerCh:             make(chan struct{}), // No buffer - will be closed to broadcast
 		busyCh:               make(chan struct{}), // No buffer - will be closed to broadcast
 		failedCh:             make(chan struct{}), // No buffer - will be closed to broadcast
+		noAnswerCh:           make(chan struct{}), // No buffer - will be closed to broadcast
 		dequeueCh:            make(chan dequeueResult, 1),
 		urlUpdateCh:          make(chan string, 1),
 		conferenceCompleteCh: make(chan struct{}, 1),
 		bridgeEndCh:          make(chan struct{}, 1),
+		participantCh:        make(chan struct{}, 1),
+		kickCh:               make(chan struct{}, 1),
+		silenceCh:            make(chan struct{}, 1),
+		announceCh:           make(chan announcement, 10),
+		streams:              make(map[model.SID]*mediaStream),
 		done:                 make(chan struct{}),
 	}
 }
 
 // Run executes the call lifecycle
 func (r *CallRunner) Run(ctx context.Context) {
-	defer close(r.done)
+	defer r.engine.signal(r.done, func() {
+		close(r.done)
+	})
 	// Transition to ringing
 	r.updateStatus(model.CallRinging)
 
@@ -91,7 +126,15 @@ func (r *CallRunner) Run(ctx context.Context) {
 		return
 	}
 
+	if r.onNet != nil {
+		// The inbound leg in the owning account answers or rejects the call
+		r.routeOnNet(ctx)
+	}
+
 	// Wait for explicit answer, busy, failed, or timeout
+	timeout, stopTimeout := r.engine.after(r.clock, r.timeout)
+	defer stopTimeout()
+	r.engine.park(r.hangupCh, r.busyCh, r.failedCh, r.noAnswerCh, timeout, r.answerCh)
 	select {
 	case <-ctx.Done():
 		return
@@ -104,26 +147,70 @@ func (r *CallRunner) Run(ctx context.Context) {
 	case <-r.failedCh:
 		r.updateStatus(model.CallFailed)
 		return
-	case <-r.clock.After(r.timeout):
+	case <-r.noAnswerCh:
+		r.updateStatus(model.CallNoAnswer)
+		return
+	case <-timeout:
 		r.updateStatus(model.CallNoAnswer)
 		return
 	case <-r.answerCh:
 		// Answer the call
+		stopTimeout()
 		r.answer(ctx)
 	}
 }
 
+// answerNow moves the call in progress
+func (r *CallRunner) answerNow() {
+	r.updateStatus(model.CallInProgress)
+	now := r.clock.Now()
+	r.state.mu.Lock()
+	r.call.AnsweredAt = &now
+	r.state.mu.Unlock()
+	r.broadcast(&r.answerOnce, r.answerCh)
+}
+
+// awaitingAnswer reports whether the call is an inbound call that has not been
+// answered yet
+func (r *CallRunner) awaitingAnswer() bool {
+	r.state.mu.RLock()
+	defer r.state.mu.RUnlock()
+	return r.call.Direction == model.Inbound && r.call.AnsweredAt == nil
+}
+
 func (r *CallRunner) answer(ctx context.Context) {
-	answerNow := func() {
-		r.updateStatus(model.CallInProgress)
-		now := r.clock.Now()
-		r.state.mu.Lock()
-		r.call.AnsweredAt = &now
-		r.state.mu.Unlock()
-	}
 	if r.call.Direction != model.Inbound && r.call.Status != model.CallInProgress {
 		// an outbound call is answered first, and then it's url is fetched
-		answerNow()
+		r.answerNow()
+
+		if r.amd != nil {
+			if r.amd.async {
+				// TwiML executes right away while detection runs alongside it
+				r.state.mu.RLock()
+				callURL := r.call.Url
+				r.state.mu.RUnlock()
+				r.engine.goTracked(func() { r.runAsyncAMD(ctx, callURL) })
+			} else {
+				// The URL fetch waits for detection and carries its result
+				_, duration, ok := r.detectMachine(ctx)
+				if !ok {
+					if ctx.Err() == nil {
+						r.updateStatus(model.CallCompleted)
+						now := r.clock.Now()
+						r.state.mu.Lock()
+						r.call.EndedAt = &now
+						r.state.mu.Unlock()
+					}
+					return
+				}
+				r.state.mu.Lock()
+				if r.call.InitialParams == nil {
+					r.call.InitialParams = make(map[string]string)
+				}
+				r.call.InitialParams["MachineDetectionDuration"] = strconv.FormatInt(duration.Milliseconds(), 10)
+				r.state.mu.Unlock()
+			}
+		}
 	}
 
 	// Main execution loop - allows for URL updates during execution
@@ -134,24 +221,39 @@ func (r *CallRunner) answer(ctx context.Context) {
 		currentMethod := r.call.Method
 		r.state.mu.Unlock()
 
-		if currentURL != "" {
-			// Fetch TwiML
-			values := url.Values{}
-			for k, v := range r.call.InitialParams {
-				values.Set(k, v)
-			}
-			// clear initial params
-			r.call.InitialParams = nil
-			twimlResp, err := r.fetchTwiML(ctx, currentMethod, currentURL, values)
-			if err != nil {
-				log.Printf("Failed to fetch Url for call %s: %v", r.call.SID, err)
-				r.recordError(err)
-				r.updateStatus(model.CallFailed)
-				return
+		if currentURL != "" || r.participantTwiML != nil {
+			var twimlResp *twiml.Response
+			var err error
+			if r.participantTwiML != nil {
+				// A participant created through the API joins its conference without fetching TwiML
+				twimlResp = r.participantTwiML
+				r.participantTwiML = nil
+			} else {
+				// Fetch TwiML
+				values := url.Values{}
+				r.state.mu.Lock()
+				for k, v := range r.call.InitialParams {
+					values.Set(k, v)
+				}
+				// clear initial params
+				r.call.InitialParams = nil
+				r.state.mu.Unlock()
+				twimlResp, err = r.fetchTwiML(ctx, currentMethod, currentURL, values)
+				if err != nil {
+					twimlResp, err = r.fetchFallbackTwiML(ctx, currentURL, values, err)
+				}
+				if err != nil {
+					log.Printf("Failed to fetch Url for call %s: %v", r.call.SID, err)
+					r.recordError(err)
+					r.updateStatus(model.CallFailed)
+					return
+				}
 			}
-			if r.call.Direction == model.Inbound && r.call.Status != model.CallInProgress {
-				// backend answers the inbound call when twiml is fetched
-				answerNow()
+			if r.call.Direction == model.Inbound && r.call.Status != model.CallInProgress &&
+				(len(twimlResp.Children) == 0 || !defersAnswer(twimlResp.Children[0])) {
+				// backend answers the inbound call when twiml is fetched, unless
+				// the document rejects it or answers it on bridge
+				r.answerNow()
 			}
 
 			// Execute TwiML
@@ -184,11 +286,12 @@ func (r *CallRunner) answer(ctx context.Context) {
 			}
 		}
 		// TwiML execution completed, wait for hangup or URL update
+		r.engine.park(r.hangupCh, r.urlUpdateCh)
 		select {
 		case <-ctx.Done():
 			return
 		case <-r.hangupCh:
-			r.updateStatus(model.CallCompleted)
+			r.updateStatus(r.hangupStatus())
 			now := r.clock.Now()
 			r.state.mu.Lock()
 			r.call.EndedAt = &now
@@ -216,7 +319,7 @@ func (r *CallRunner) fetchTwiML(ctx context.Context, method, targetURL string, f
 	})
 
 	// Make request
-	reqCtx, cancel := context.WithTimeout(ctx, r.engine.timeout)
+	reqCtx, cancel := context.WithTimeout(r.requestContext(ctx), r.engine.timeout)
 	defer cancel()
 
 	var status int
@@ -230,7 +333,7 @@ func (r *CallRunner) fetchTwiML(ctx context.Context, method, targetURL string, f
 				"url":   targetURL,
 				"error": urlErr.Error(),
 			})
-			return nil, fmt.Errorf("failed to parse URL %s: %w", targetURL, urlErr)
+			return nil, &twimlFetchError{ErrorCodeHTTPRetrievalFailure, fmt.Errorf("failed to parse URL %s: %w", targetURL, urlErr)}
 		}
 		q := urlWithParams.Query()
 		for k, v := range callForm {
@@ -239,16 +342,17 @@ func (r *CallRunner) fetchTwiML(ctx context.Context, method, targetURL string, f
 			}
 		}
 		urlWithParams.RawQuery = q.Encode()
-		status, body, headers, err = r.engine.webhook.GET(reqCtx, urlWithParams.String())
+		signedURL := urlWithParams.String()
+		status, body, headers, err = r.engine.webhook.GET(reqCtx, signedURL, r.engine.signedHeaders(r.state, signedURL, nil))
 	} else {
-		status, body, headers, err = r.engine.webhook.POST(reqCtx, targetURL, callForm)
+		status, body, headers, err = r.engine.webhook.POST(reqCtx, targetURL, callForm, r.engine.signedHeaders(r.state, targetURL, callForm))
 	}
 	if err != nil {
 		r.addCallEvent("webhook.error", map[string]any{
 			"url":   targetURL,
 			"error": err.Error(),
 		})
-		return nil, fmt.Errorf("webhook request failed: %w", err)
+		return nil, &twimlFetchError{ErrorCodeHTTPRetrievalFailure, fmt.Errorf("webhook request failed: %w", err)}
 	}
 
 	// Log response
@@ -258,6 +362,9 @@ func (r *CallRunner) fetchTwiML(ctx context.Context, method, targetURL string, f
 		"headers": headers,
 		"body":    string(body),
 	})
+	if status < 200 || status > 299 {
+		return nil, &twimlFetchError{ErrorCodeHTTPRetrievalFailure, fmt.Errorf("webhook %s returned status %d", targetURL, status)}
+	}
 
 	// Parse TwiML
 	resp, err := twiml.Parse(body)
@@ -266,9 +373,46 @@ func (r *CallRunner) fetchTwiML(ctx context.Context, method, targetURL string, f
 			"error": err.Error(),
 			"body":  string(body),
 		})
-		return nil, fmt.Errorf("failed to parse TwiML at %s: %w", targetURL, err)
+		return nil, &twimlFetchError{ErrorCodeDocumentParseFailure, fmt.Errorf("failed to parse TwiML at %s: %w", targetURL, err)}
+	}
+
+	return resp, nil
+}
+
+// fetchFallbackTwiML retries a failed fetch of the call's TwiML against its
+// fallback URL, adding the ErrorCode and ErrorUrl parameters. It returns
+// fetchErr when the call has no fallback URL.
+func (r *CallRunner) fetchFallbackTwiML(ctx context.Context, failedURL string, form url.Values, fetchErr error) (*twiml.Response, error) {
+	r.state.mu.RLock()
+	fallbackURL := r.call.FallbackURL
+	fallbackMethod := r.call.FallbackMethod
+	r.state.mu.RUnlock()
+	if fallbackURL == "" || ctx.Err() != nil {
+		return nil, fetchErr
+	}
+
+	code := ErrorCodeHTTPRetrievalFailure
+	var fetchError *twimlFetchError
+	if errors.As(fetchErr, &fetchError) {
+		code = fetchError.code
 	}
+	r.addCallEvent("webhook.fallback", map[string]any{
+		"url":        fallbackURL,
+		"failed_url": failedURL,
+		"error_code": code,
+		"error":      fetchErr.Error(),
+	})
 
+	fallbackForm := url.Values{}
+	for k, v := range form {
+		fallbackForm[k] = v
+	}
+	fallbackForm.Set("ErrorCode", strconv.Itoa(code))
+	fallbackForm.Set("ErrorUrl", failedURL)
+	resp, err := r.fetchTwiML(ctx, fallbackMethod, fallbackURL, fallbackForm)
+	if err != nil {
+		return nil, fmt.Errorf("fallback URL failed after %w: %w", fetchErr, err)
+	}
 	return resp, nil
 }
 
@@ -293,13 +437,19 @@ func (r *CallRunner) executeTwiML(ctx context.Context, resp *twiml.Response, cur
 }
 
 func (r *CallRunner) executeNode(ctx context.Context, node twiml.Node, currentTwimlDocumentURL string, terminated *bool, executingWaitTwiml bool) error {
+	if _, hangup := node.(*twiml.Hangup); r.unansweredStatus != "" && !hangup && !defersAnswer(node) && r.awaitingAnswer() {
+		// A caller that answerOnBridge left ringing is answered by the next
+		// verb other than <Hangup>
+		r.unansweredStatus = ""
+		r.answerNow()
+	}
 	switch n := node.(type) {
 	case *twiml.Say:
 		return r.executeSay(ctx, n, false, executingWaitTwiml)
 	case *twiml.Play:
 		return r.executePlay(ctx, n, false, executingWaitTwiml)
 	case *twiml.Pause:
-		return r.executePause(ctx, n, false)
+		return r.executePause(ctx, n, false, executingWaitTwiml)
 	case *twiml.Gather:
 		return r.executeGather(ctx, n, currentTwimlDocumentURL, terminated)
 	case *twiml.Dial:
@@ -312,6 +462,16 @@ func (r *CallRunner) executeNode(ctx context.Context, node twiml.Node, currentTw
 		return r.executeRecord(ctx, n, currentTwimlDocumentURL, terminated)
 	case *twiml.Hangup:
 		return r.executeHangup(false)
+	case *twiml.Reject:
+		return r.executeReject(n)
+	case *twiml.Message:
+		return r.executeMessage(ctx, n, currentTwimlDocumentURL, terminated)
+	case *twiml.Connect:
+		return r.executeConnect(ctx, n, currentTwimlDocumentURL, terminated)
+	case *twiml.Start:
+		return r.executeStart(ctx, n, currentTwimlDocumentURL, terminated)
+	case *twiml.Stop:
+		return r.executeStop(n)
 	default:
 		msg := fmt.Sprintf("Unknown TwiML node type: %T", node)
 		err := errors.New(msg)
@@ -332,6 +492,9 @@ func (r *CallRunner) executeSay(ctx context.Context, say *twiml.Say, skipTrackin
 	if say.Loop == 0 && !executingWaitTwiml {
 		return r.busyLoop(ctx, "say")
 	}
+	if r.engine.timedMedia && !executingWaitTwiml {
+		return r.playMedia(ctx, "say", sayDuration(say)*time.Duration(say.Loop))
+	}
 	return nil
 }
 
@@ -349,10 +512,19 @@ func (r *CallRunner) executePlay(ctx context.Context, play *twiml.Play, skipTrac
 	})
 
 	// Check the media URL to ensure it's accessible (using HEAD to avoid downloading the entire file)
-	reqCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	reqCtx, cancel := context.WithTimeout(r.requestContext(ctx), r.timeout)
 	defer cancel()
 
-	status, _, err := r.engine.webhook.HEAD(reqCtx, playURL)
+	timed := r.engine.timedMedia && !executingWaitTwiml
+	var status int
+	var body []byte
+	var err error
+	if timed {
+		// Timed media is downloaded to read its length from the headers
+		status, body, _, err = r.engine.webhook.GET(reqCtx, playURL, nil)
+	} else {
+		status, _, err = r.engine.webhook.HEAD(reqCtx, playURL)
+	}
 	if err != nil {
 		r.addCallEvent("play.error", map[string]any{
 			"url":   playURL,
@@ -381,12 +553,20 @@ func (r *CallRunner) executePlay(ctx context.Context, play *twiml.Play, skipTrac
 	if play.Loop == 0 && !executingWaitTwiml {
 		return r.busyLoop(ctx, "play")
 	}
+	if timed {
+		duration, ok := mediaDuration(body)
+		if !ok {
+			r.addCallEvent("play.duration_unknown", map[string]any{"url": playURL})
+		}
+		return r.playMedia(ctx, "play", duration*time.Duration(play.Loop))
+	}
 	return nil
 }
 
 func (r *CallRunner) busyLoop(ctx context.Context, verbName string) error {
 	//return nil
 	for {
+		r.engine.park(r.hangupCh, r.urlUpdateCh, r.bargeInCh())
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
@@ -396,11 +576,13 @@ func (r *CallRunner) busyLoop(ctx context.Context, verbName string) error {
 			// URL updated, skip through gather
 			r.addCallEvent(fmt.Sprintf("%s.interrupted", verbName), map[string]any{"reason": "url_updated"})
 			return ErrURLUpdated
+		case <-r.bargeInCh():
+			return r.interruptPrompt(verbName)
 		}
 	}
 }
 
-func (r *CallRunner) executePause(ctx context.Context, pause *twiml.Pause, skipTracking bool) error {
+func (r *CallRunner) executePause(ctx context.Context, pause *twiml.Pause, skipTracking bool, executingWaitTwiml bool) error {
 	if !skipTracking {
 		r.trackCallTwiML(pause)
 	}
@@ -408,26 +590,10 @@ func (r *CallRunner) executePause(ctx context.Context, pause *twiml.Pause, skipT
 		"length": pause.Length.Seconds(),
 	})
 
-	//pauseNow := func() error {
-	//	// Use the clock-aware After which respects Advance() calls
-	//	timer := r.clock.After(pause.Length)
-	//
-	//	// Wait for either the timer to expire, or an interrupt
-	//	select {
-	//	case <-ctx.Done():
-	//		return ctx.Err()
-	//	case <-r.hangupCh:
-	//		return nil
-	//	case <-r.urlUpdateCh:
-	//		r.addCallEvent("pause.interrupted", map[string]any{"reason": "url_updated"})
-	//		return ErrURLUpdated
-	//	case <-timer:
-	//		// Pause completed normally
-	//		return nil
-	//	}
-	//}
-	////for now we don't perform real pause to keep things predictable
-	//return pauseNow()
+	// Without timed media pauses end right away to keep things predictable
+	if r.engine.timedMedia && !executingWaitTwiml {
+		return r.playMedia(ctx, "pause", pause.Length)
+	}
 	return nil
 }
 
@@ -445,41 +611,43 @@ func (r *CallRunner) executeGather(ctx context.Context, gather *twiml.Gather, cu
 		}
 	}
 
+	acceptsDTMF := gather.Input == "" || strings.Contains(gather.Input, "dtmf")
+	acceptsSpeech := strings.Contains(gather.Input, "speech")
+
+	// speechTimeout is the silence that ends speech input. When unset Twilio
+	// falls back to timeout.
+	speechTimeout := timeout
+	if gather.SpeechTimeout == "auto" {
+		speechTimeout = autoSpeechTimeout
+	} else if gather.SpeechTimeout != "" {
+		if n, err := strconv.Atoi(gather.SpeechTimeout); err == nil {
+			speechTimeout = time.Duration(n) * time.Second
+		}
+	}
+
 	r.addCallEvent("twiml.gather", map[string]any{
-		"input":         gather.Input,
-		"timeout":       timeout.Seconds(),
-		"num_digits":    gather.NumDigits,
-		"finish_on_key": gather.FinishOnKey,
-		"action":        gather.Action,
+		"input":                  gather.Input,
+		"timeout":                timeout.Seconds(),
+		"speech_timeout":         speechTimeout.Seconds(),
+		"num_digits":             gather.NumDigits,
+		"finish_on_key":          gather.FinishOnKey,
+		"action":                 gather.Action,
+		"action_on_empty_result": gather.ActionOnEmptyResult,
 	})
 
 	r.state.mu.Lock()
 	r.call.CurrentEndpoint = "gather"
+	r.gathering = true
 	r.state.mu.Unlock()
+	defer r.stopGathering()
 
-	// Execute nested children while gathering
-	for _, child := range gather.Children {
-		if *terminated {
-			break
-		}
-		switch n := child.(type) {
-		case *twiml.Say:
-			if err := r.executeSay(ctx, n, true, false); err != nil {
-				return err
-			}
-		case *twiml.Play:
-			if err := r.executePlay(ctx, n, true, false); err != nil {
-				return err
-			}
-		case *twiml.Pause:
-			if err := r.executePause(ctx, n, true); err != nil {
-				return err
-			}
-		default:
-			nodeType := fmt.Sprintf("%T", child)
-			r.addCallEvent("gather.invalid_child", map[string]any{"node": nodeType})
-			return fmt.Errorf("gather cannot contain %s", nodeType)
-		}
+	// Execute nested children while gathering. Digits interrupt the prompt,
+	// and digits typed ahead skip it.
+	r.bargeIn = acceptsDTMF
+	err := r.executeGatherPrompt(ctx, gather, terminated)
+	r.bargeIn = false
+	if err != nil {
+		return err
 	}
 
 	if *terminated {
@@ -489,13 +657,18 @@ func (r *CallRunner) executeGather(ctx context.Context, gather *twiml.Gather, cu
 	// Collect digits one by one until:
 	// - finishOnKey is pressed (if set)
 	// - numDigits is reached (if > 0)
-	// - timeout occurs
+	// - timeout passes without a new digit, timed from the end of the prompt
 	// - hangup or context cancellation
 	var collectedDigits string
+	var speechResult string
+	var confidence float64
+	var partialSequence int
 	var urlUpdated bool
-	timeoutTimer := r.clock.After(timeout)
+	timeoutTimer, stopTimeout := r.engine.after(r.clock, timeout)
+	defer func() { stopTimeout() }()
 
 	for {
+		r.engine.park(r.hangupCh, r.urlUpdateCh, r.speechCh, r.dtmfCh, timeoutTimer)
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
@@ -506,10 +679,42 @@ func (r *CallRunner) executeGather(ctx context.Context, gather *twiml.Gather, cu
 			r.addCallEvent("gather.interrupted", map[string]any{"reason": "url_updated"})
 			urlUpdated = true
 			goto gatherComplete
-		case digits := <-r.gatherCh:
-			// Got one or more digits - process each character individually
-			for _, char := range digits {
+		case speech := <-r.speechCh:
+			if !acceptsSpeech || collectedDigits != "" {
+				r.addCallEvent("gather.speech_ignored", map[string]any{
+					"transcript": speech.transcript,
+					"input":      gather.Input,
+				})
+				continue
+			}
+			if speechResult != "" {
+				speechResult += " "
+			}
+			speechResult += speech.transcript
+			confidence = speech.confidence
+			r.addCallEvent("gather.speech_received", map[string]any{
+				"transcript":    speech.transcript,
+				"confidence":    speech.confidence,
+				"speech_result": speechResult,
+			})
+			partialSequence = r.sendPartialResults(ctx, gather, speech.transcript, speechResult, partialSequence, currentTwimlDocumentURL)
+			// Speech ends once the caller has been silent for speechTimeout
+			stopTimeout()
+			timeoutTimer, stopTimeout = r.engine.after(r.clock, speechTimeout)
+		case <-r.dtmfCh:
+			digits := r.takeDigits()
+			if !acceptsDTMF || speechResult != "" {
+				r.addCallEvent("gather.digits_ignored", map[string]any{
+					"digits": digits,
+					"input":  gather.Input,
+				})
+				continue
+			}
+			// Got one or more digits - process each character individually.
+			// Digits after the ones this gather uses are left for the next verb.
+			for i, char := range digits {
 				digit := string(char)
+				rest := digits[i+len(digit):]
 
 				// Check if it's the finish key
 				if gather.FinishOnKey != "" && digit == gather.FinishOnKey {
@@ -518,6 +723,7 @@ func (r *CallRunner) executeGather(ctx context.Context, gather *twiml.Gather, cu
 						"finish_key":       digit,
 						"all_digits":       digits,
 					})
+					r.unreadDigits(rest)
 					goto gatherComplete
 				}
 
@@ -529,6 +735,9 @@ func (r *CallRunner) executeGather(ctx context.Context, gather *twiml.Gather, cu
 					"target_digits":    gather.NumDigits,
 					"all_digits":       digits,
 				})
+				// timeout is the time allowed between digits
+				stopTimeout()
+				timeoutTimer, stopTimeout = r.engine.after(r.clock, timeout)
 
 				// Check if we've reached numDigits
 				if gather.NumDigits > 0 && len(collectedDigits) >= gather.NumDigits {
@@ -537,6 +746,7 @@ func (r *CallRunner) executeGather(ctx context.Context, gather *twiml.Gather, cu
 						"num_digits":       gather.NumDigits,
 						"all_digits":       digits,
 					})
+					r.unreadDigits(rest)
 					goto gatherComplete
 				}
 			}
@@ -545,6 +755,13 @@ func (r *CallRunner) executeGather(ctx context.Context, gather *twiml.Gather, cu
 			// (will only stop on timeout or interruption)
 
 		case <-timeoutTimer:
+			if speechResult != "" {
+				r.addCallEvent("gather.speech_complete", map[string]any{
+					"speech_result": speechResult,
+					"confidence":    confidence,
+				})
+				goto gatherComplete
+			}
 			// Timeout
 			r.addCallEvent("gather.timeout", map[string]any{
 				"collected_digits": collectedDigits,
@@ -554,13 +771,40 @@ func (r *CallRunner) executeGather(ctx context.Context, gather *twiml.Gather, cu
 	}
 
 gatherComplete:
+	stopTimeout()
+	r.stopGathering()
+
 	// If URL was updated, skip action callback entirely
 	if urlUpdated {
 		return ErrURLUpdated
 	}
 
+	if speechResult != "" {
+		r.state.mu.Lock()
+		r.call.CurrentEndpoint = ""
+		r.state.mu.Unlock()
+
+		form := url.Values{}
+		form.Set("SpeechResult", speechResult)
+		form.Set("Confidence", strconv.FormatFloat(confidence, 'f', -1, 64))
+		return r.executeActionCallback(ctx, gather.Method, gather.Action, form, currentTwimlDocumentURL, false)
+	}
+
 	if collectedDigits == "" {
-		return nil
+		// Without input the call moves on to the next verb, unless the action
+		// was requested for empty results
+		r.state.mu.Lock()
+		r.call.CurrentEndpoint = ""
+		r.state.mu.Unlock()
+		r.addCallEvent("gather.no_input", map[string]any{
+			"action_on_empty_result": gather.ActionOnEmptyResult,
+		})
+		if !gather.ActionOnEmptyResult {
+			return nil
+		}
+		form := url.Values{}
+		form.Set("Digits", "")
+		return r.executeActionCallback(ctx, gather.Method, gather.Action, form, currentTwimlDocumentURL, false)
 	}
 
 	r.state.mu.Lock()
@@ -575,6 +819,41 @@ gatherComplete:
 	return r.executeActionCallback(ctx, gather.Method, gather.Action, form, currentTwimlDocumentURL, false)
 }
 
+// executeGatherPrompt plays the nested children of a gather until they finish
+// or, when r.bargeIn is set, digits arrive
+func (r *CallRunner) executeGatherPrompt(ctx context.Context, gather *twiml.Gather, terminated *bool) error {
+	for _, child := range gather.Children {
+		if *terminated {
+			break
+		}
+		if r.bargeIn && r.hasDigits() {
+			r.addCallEvent("gather.barge_in", map[string]any{"node": fmt.Sprintf("%T", child)})
+			return nil
+		}
+		var err error
+		switch n := child.(type) {
+		case *twiml.Say:
+			err = r.executeSay(ctx, n, true, false)
+		case *twiml.Play:
+			err = r.executePlay(ctx, n, true, false)
+		case *twiml.Pause:
+			err = r.executePause(ctx, n, true, false)
+		default:
+			nodeType := fmt.Sprintf("%T", child)
+			r.addCallEvent("gather.invalid_child", map[string]any{"node": nodeType})
+			return fmt.Errorf("gather cannot contain %s", nodeType)
+		}
+		if errors.Is(err, errBargeIn) {
+			r.addCallEvent("gather.barge_in", map[string]any{"node": fmt.Sprintf("%T", child)})
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *CallRunner) executeDial(ctx context.Context, dial *twiml.Dial, currentTwimlDocumentURL string) error {
 	r.trackCallTwiML(dial)
 
@@ -677,28 +956,34 @@ func (r *CallRunner) bridgeWithQueueMember(ctx context.Context, dial *twiml.Dial
 
 	// Signal the target call to dequeue with "bridged" result and partner SID
 	if targetRunner != nil {
-		select {
-		case targetRunner.dequeueCh <- dequeueResult{result: "bridged", partnerSID: r.call.SID}:
-		default:
-		}
+		notify(r.engine, targetRunner.dequeueCh, dequeueResult{result: "bridged", partnerSID: r.call.SID})
 	}
 	recordingStartTime := r.clock.Now()
-	// Bridge is established - wait until either call hangs up (no timeout during bridge)
+	// Bridge is established - wait until either call hangs up or the time limit passes
 	var dialDuration int
 	urlUpdated := false
+	timeLimit, stopTimeLimit := r.dialTimeLimit(dial)
+	defer stopTimeLimit()
 	if dial.HangupOnStar {
 		// Listen for star key to hangup during bridge
 		for {
+			r.engine.park(r.hangupCh, r.bridgeEndCh, r.urlUpdateCh, r.dtmfCh, timeLimit)
 			select {
 			case <-ctx.Done():
 				goto bridgeEnded
 			case <-r.hangupCh:
 				// This call hung up, notify the target
 				if targetRunner != nil {
-					select {
-					case targetRunner.bridgeEndCh <- struct{}{}:
-					default:
-					}
+					notify(r.engine, targetRunner.bridgeEndCh, struct{}{})
+				}
+				goto bridgeEnded
+			case <-timeLimit:
+				r.addCallEvent("dial.queue.bridge_interrupted", map[string]any{
+					"reason":     "time_limit",
+					"time_limit": dial.TimeLimit.Seconds(),
+				})
+				if targetRunner != nil {
+					notify(r.engine, targetRunner.bridgeEndCh, struct{}{})
 				}
 				goto bridgeEnded
 			case <-r.bridgeEndCh:
@@ -710,13 +995,11 @@ func (r *CallRunner) bridgeWithQueueMember(ctx context.Context, dial *twiml.Dial
 				r.addCallEvent("dial.queue.bridge_interrupted", map[string]any{"reason": "url_updated"})
 				// Notify the target before leaving
 				if targetRunner != nil {
-					select {
-					case targetRunner.bridgeEndCh <- struct{}{}:
-					default:
-					}
+					notify(r.engine, targetRunner.bridgeEndCh, struct{}{})
 				}
 				goto bridgeEnded
-			case digits := <-r.gatherCh:
+			case <-r.dtmfCh:
+				digits := r.takeDigits()
 				// Check if star is pressed
 				if strings.Contains(digits, "*") {
 					r.addCallEvent("dial.hangup_on_star", map[string]any{
@@ -724,26 +1007,29 @@ func (r *CallRunner) bridgeWithQueueMember(ctx context.Context, dial *twiml.Dial
 					})
 					// Notify the target
 					if targetRunner != nil {
-						select {
-						case targetRunner.bridgeEndCh <- struct{}{}:
-						default:
-						}
+						notify(r.engine, targetRunner.bridgeEndCh, struct{}{})
 					}
 					goto bridgeEnded
 				}
 			}
 		}
 	} else {
-		// Wait for bridge to end (no timeout)
+		// Wait for bridge to end
+		r.engine.park(r.hangupCh, r.bridgeEndCh, r.urlUpdateCh, timeLimit)
 		select {
 		case <-ctx.Done():
 		case <-r.hangupCh:
 			// This call hung up, notify the target
 			if targetRunner != nil {
-				select {
-				case targetRunner.bridgeEndCh <- struct{}{}:
-				default:
-				}
+				notify(r.engine, targetRunner.bridgeEndCh, struct{}{})
+			}
+		case <-timeLimit:
+			r.addCallEvent("dial.queue.bridge_interrupted", map[string]any{
+				"reason":     "time_limit",
+				"time_limit": dial.TimeLimit.Seconds(),
+			})
+			if targetRunner != nil {
+				notify(r.engine, targetRunner.bridgeEndCh, struct{}{})
 			}
 		case <-r.bridgeEndCh:
 			// Target call hung up, end this bridge
@@ -753,15 +1039,13 @@ func (r *CallRunner) bridgeWithQueueMember(ctx context.Context, dial *twiml.Dial
 			r.addCallEvent("dial.queue.bridge_interrupted", map[string]any{"reason": "url_updated"})
 			// Notify the target before leaving
 			if targetRunner != nil {
-				select {
-				case targetRunner.bridgeEndCh <- struct{}{}:
-				default:
-				}
+				notify(r.engine, targetRunner.bridgeEndCh, struct{}{})
 			}
 		}
 	}
 
 bridgeEnded:
+	stopTimeLimit()
 	endTime := r.clock.Now()
 	dialDuration = int(endTime.Sub(startTime).Seconds())
 	targetQueueTime := 0
@@ -778,6 +1062,7 @@ bridgeEnded:
 
 	// For queued calls, recording is always on the enqueued call
 	r.invokeRecordingCallback(ctx, dial, nil, recordingStartTime, targetCallSID, currentTwimlDocumentURL)
+	r.discardDigits()
 
 	// Call action callback with bridge results
 	form := url.Values{}
@@ -805,11 +1090,7 @@ func (r *CallRunner) waitInDialQueue(ctx context.Context, dial *twiml.Dial, queu
 	r.state.mu.Lock()
 	// Add this call to the queue
 	queue.Members = append(queue.Members, r.call.SID)
-	queue.Timeline = append(queue.Timeline, model.NewEvent(
-		startTime,
-		"member.joined",
-		map[string]any{"call_sid": r.call.SID},
-	))
+	r.engine.addQueueEventLocked(r.state, queue, "member.joined", map[string]any{"call_sid": r.call.SID})
 
 	r.call.CurrentEndpoint = "queue:" + queue.Name
 	r.state.mu.Unlock()
@@ -826,9 +1107,11 @@ func (r *CallRunner) waitInDialQueue(ctx context.Context, dial *twiml.Dial, queu
 	queueResult := ""
 	var bridgePartnerSID model.SID
 	urlUpdated := false
+	timeout, stopTimeout := r.engine.after(r.clock, dial.Timeout)
 	if dial.HangupOnStar {
 		// Listen for star key to hangup
 		for {
+			r.engine.park(r.hangupCh, r.urlUpdateCh, r.dequeueCh, timeout, r.dtmfCh)
 			select {
 			case <-ctx.Done():
 				dialStatus = "canceled"
@@ -849,12 +1132,13 @@ func (r *CallRunner) waitInDialQueue(ctx context.Context, dial *twiml.Dial, queu
 				queueResult = dqResult.result
 				bridgePartnerSID = dqResult.partnerSID
 				goto queueLeft
-			case <-r.clock.After(dial.Timeout):
+			case <-timeout:
 				dialStatus = "no-answer"
 				queueResult = "timeout"
 				r.addCallEvent("dial.queue.timeout", map[string]any{})
 				goto queueLeft
-			case digits := <-r.gatherCh:
+			case <-r.dtmfCh:
+				digits := r.takeDigits()
 				// Check if star is pressed
 				if strings.Contains(digits, "*") {
 					r.addCallEvent("dial.hangup_on_star", map[string]any{
@@ -866,6 +1150,7 @@ func (r *CallRunner) waitInDialQueue(ctx context.Context, dial *twiml.Dial, queu
 			}
 		}
 	} else {
+		r.engine.park(r.hangupCh, r.urlUpdateCh, r.dequeueCh, timeout)
 		select {
 		case <-ctx.Done():
 			dialStatus = "canceled"
@@ -882,7 +1167,7 @@ func (r *CallRunner) waitInDialQueue(ctx context.Context, dial *twiml.Dial, queu
 			dialStatus = "completed"
 			queueResult = dqResult.result
 			bridgePartnerSID = dqResult.partnerSID
-		case <-r.clock.After(dial.Timeout):
+		case <-timeout:
 			dialStatus = "no-answer"
 			queueResult = "timeout"
 			r.addCallEvent("dial.queue.timeout", map[string]any{})
@@ -890,6 +1175,7 @@ func (r *CallRunner) waitInDialQueue(ctx context.Context, dial *twiml.Dial, queu
 	}
 
 queueLeft:
+	stopTimeout()
 
 	// Calculate time in queue (time waiting before bridge)
 	endTime := r.clock.Now()
@@ -922,20 +1208,29 @@ queueLeft:
 		partnerRunner := r.state.runners[bridgePartnerSID]
 		r.state.mu.Unlock()
 
-		// Wait for bridge to complete (no timeout during bridge)
+		// Wait for bridge to complete or the time limit to pass
+		timeLimit, stopTimeLimit := r.dialTimeLimit(dial)
+		defer stopTimeLimit()
 		if dial.HangupOnStar {
 			// Listen for star key to hangup during bridge
 			for {
+				r.engine.park(r.hangupCh, r.bridgeEndCh, r.urlUpdateCh, r.dtmfCh, timeLimit)
 				select {
 				case <-ctx.Done():
 					goto bridgeEnded
 				case <-r.hangupCh:
 					// This call hung up, notify the partner
 					if partnerRunner != nil {
-						select {
-						case partnerRunner.bridgeEndCh <- struct{}{}:
-						default:
-						}
+						notify(r.engine, partnerRunner.bridgeEndCh, struct{}{})
+					}
+					goto bridgeEnded
+				case <-timeLimit:
+					r.addCallEvent("dial.queue.bridge_interrupted", map[string]any{
+						"reason":     "time_limit",
+						"time_limit": dial.TimeLimit.Seconds(),
+					})
+					if partnerRunner != nil {
+						notify(r.engine, partnerRunner.bridgeEndCh, struct{}{})
 					}
 					goto bridgeEnded
 				case <-r.bridgeEndCh:
@@ -947,13 +1242,11 @@ queueLeft:
 					r.addCallEvent("dial.queue.bridge_interrupted", map[string]any{"reason": "url_updated"})
 					// Notify partner before leaving
 					if partnerRunner != nil {
-						select {
-						case partnerRunner.bridgeEndCh <- struct{}{}:
-						default:
-						}
+						notify(r.engine, partnerRunner.bridgeEndCh, struct{}{})
 					}
 					goto bridgeEnded
-				case digits := <-r.gatherCh:
+				case <-r.dtmfCh:
+					digits := r.takeDigits()
 					// Check if star is pressed
 					if strings.Contains(digits, "*") {
 						r.addCallEvent("dial.hangup_on_star", map[string]any{
@@ -961,25 +1254,28 @@ queueLeft:
 						})
 						// Notify partner before leaving
 						if partnerRunner != nil {
-							select {
-							case partnerRunner.bridgeEndCh <- struct{}{}:
-							default:
-							}
+							notify(r.engine, partnerRunner.bridgeEndCh, struct{}{})
 						}
 						goto bridgeEnded
 					}
 				}
 			}
 		} else {
+			r.engine.park(r.hangupCh, r.bridgeEndCh, r.urlUpdateCh, timeLimit)
 			select {
 			case <-ctx.Done():
 			case <-r.hangupCh:
 				// This call hung up, notify the partner
 				if partnerRunner != nil {
-					select {
-					case partnerRunner.bridgeEndCh <- struct{}{}:
-					default:
-					}
+					notify(r.engine, partnerRunner.bridgeEndCh, struct{}{})
+				}
+			case <-timeLimit:
+				r.addCallEvent("dial.queue.bridge_interrupted", map[string]any{
+					"reason":     "time_limit",
+					"time_limit": dial.TimeLimit.Seconds(),
+				})
+				if partnerRunner != nil {
+					notify(r.engine, partnerRunner.bridgeEndCh, struct{}{})
 				}
 			case <-r.bridgeEndCh:
 				// Partner call hung up, end this bridge
@@ -989,15 +1285,13 @@ queueLeft:
 				r.addCallEvent("dial.queue.bridge_interrupted", map[string]any{"reason": "url_updated"})
 				// Notify partner before leaving
 				if partnerRunner != nil {
-					select {
-					case partnerRunner.bridgeEndCh <- struct{}{}:
-					default:
-					}
+					notify(r.engine, partnerRunner.bridgeEndCh, struct{}{})
 				}
 			}
 		}
 
 	bridgeEnded:
+		stopTimeLimit()
 		bridgeEndTime := r.clock.Now()
 		dialDuration = int(bridgeEndTime.Sub(bridgeStartTime).Seconds())
 
@@ -1008,6 +1302,7 @@ queueLeft:
 		// For queued calls, recording is always on the enqueued call
 		r.invokeRecordingCallback(ctx, dial, nil, bridgeStartTime, bridgePartnerSID, currentTwimlDocumentURL)
 	}
+	r.discardDigits()
 
 	// Call action callback with dial results
 	form := url.Values{}
@@ -1036,11 +1331,7 @@ func (r *CallRunner) executeDialConference(ctx context.Context, dial *twiml.Dial
 	r.state.mu.Lock()
 	// Add participant
 	conf.Participants = append(conf.Participants, r.call.SID)
-	conf.Timeline = append(conf.Timeline, model.NewEvent(
-		r.clock.Now(),
-		"participant.joined",
-		map[string]any{"call_sid": r.call.SID},
-	))
+	r.engine.addConferenceEventLocked(r.state, conf, "participant.joined", map[string]any{"call_sid": r.call.SID})
 
 	// Store participant attributes
 	if r.state.participantStates[conf.SID] == nil {
@@ -1053,6 +1344,9 @@ func (r *CallRunner) executeDialConference(ctx context.Context, dial *twiml.Dial
 	}
 	partState.StartConferenceOnEnter = conference.StartConferenceOnEnter
 	partState.EndConferenceOnExit = conference.EndConferenceOnExit
+	partState.Muted = conference.Muted
+	partState.Coaching = conference.Coach != ""
+	partState.CallSidToCoach = model.SID(conference.Coach)
 
 	// Check if conference should start
 	// Conference starts if it has at least 2 participants and at least one has StartConferenceOnEnter=true
@@ -1067,11 +1361,7 @@ func (r *CallRunner) executeDialConference(ctx context.Context, dial *twiml.Dial
 		}
 		if shouldStart {
 			conf.Status = model.ConferenceInProgress
-			conf.Timeline = append(conf.Timeline, model.NewEvent(
-				r.clock.Now(),
-				"conference.started",
-				map[string]any{},
-			))
+			r.engine.addConferenceEventLocked(r.state, conf, "conference.started", map[string]any{})
 		}
 	}
 
@@ -1083,35 +1373,27 @@ func (r *CallRunner) executeDialConference(ctx context.Context, dial *twiml.Dial
 		if r.engine.shouldSendConferenceStatusCallback(conf, "participant-join") {
 			// Queue the callback for serial execution
 			callSID := r.call.SID
-			conf.CallbackQueue <- func() {
+			r.engine.enqueue(conf.CallbackQueue, func() {
 				r.engine.sendConferenceStatusCallback(r.state, conf, "participant-join", &callSID, currentTwimlDocumentURL)
-			}
+			})
 		} else {
-			conf.Timeline = append(conf.Timeline, model.NewEvent(
-				r.clock.Now(),
-				"webhook.conference_status_callback_skipped",
-				map[string]any{
-					"event":    "participant-join",
-					"call_sid": r.call.SID,
-				},
-			))
+			r.engine.addConferenceEventLocked(r.state, conf, "webhook.conference_status_callback_skipped", map[string]any{
+				"event":    "participant-join",
+				"call_sid": r.call.SID,
+			})
 		}
 
 		// Send conference-start callback if conference just started
 		if shouldStart {
 			if r.engine.shouldSendConferenceStatusCallback(conf, "conference-start") {
 				// Queue the callback for serial execution
-				conf.CallbackQueue <- func() {
+				r.engine.enqueue(conf.CallbackQueue, func() {
 					r.engine.sendConferenceStatusCallback(r.state, conf, "conference-start", nil, currentTwimlDocumentURL)
-				}
+				})
 			} else {
-				conf.Timeline = append(conf.Timeline, model.NewEvent(
-					r.clock.Now(),
-					"webhook.conference_status_callback_skipped",
-					map[string]any{
-						"event": "conference-start",
-					},
-				))
+				r.engine.addConferenceEventLocked(r.state, conf, "webhook.conference_status_callback_skipped", map[string]any{
+					"event": "conference-start",
+				})
 			}
 		}
 	}
@@ -1127,37 +1409,31 @@ func (r *CallRunner) executeDialConference(ctx context.Context, dial *twiml.Dial
 	recordingStartTime := r.clock.Now()
 	// Wait until hangup or leave conference
 	urlUpdated := false
+	// Digits only matter when the caller can leave the conference with star
+	var starCh chan struct{}
 	if dial.HangupOnStar {
-		// Listen for star key to leave conference
-		for {
-			select {
-			case <-ctx.Done():
-				goto conferenceEnded
-			case <-r.hangupCh:
-				goto conferenceEnded
-			case <-r.urlUpdateCh:
-				urlUpdated = true
-				r.addCallEvent("dial.conference.interrupted", map[string]any{"reason": "url_updated"})
-				goto conferenceEnded
-			case <-r.conferenceCompleteCh:
-				r.addCallEvent("dial.conference.completed", map[string]any{"reason": "completed_via_api"})
-				goto conferenceEnded
-			case digits := <-r.gatherCh:
-				// Check if star is pressed by caller to leave conference
-				if strings.Contains(digits, "*") {
-					r.addCallEvent("dial.hangup_on_star", map[string]any{
-						"digits": digits,
-					})
-					goto conferenceEnded
-				}
-			}
-		}
-	} else {
+		starCh = r.dtmfCh
+	}
+	held := false
+	var holdURL, holdMethod string
+	var holdLoop chan struct{} // ready while hold music should be (re)played
+	playHold := make(chan struct{})
+	close(playHold)
+	timeLimit, stopTimeLimit := r.dialTimeLimit(dial)
+	defer stopTimeLimit()
+	for {
+		r.engine.park(r.hangupCh, r.urlUpdateCh, r.conferenceCompleteCh, r.kickCh, starCh, r.participantCh, holdLoop, r.announceCh, timeLimit)
 		select {
 		case <-ctx.Done():
 			goto conferenceEnded
 		case <-r.hangupCh:
 			goto conferenceEnded
+		case <-timeLimit:
+			r.addCallEvent("dial.conference.interrupted", map[string]any{
+				"reason":     "time_limit",
+				"time_limit": dial.TimeLimit.Seconds(),
+			})
+			goto conferenceEnded
 		case <-r.urlUpdateCh:
 			urlUpdated = true
 			r.addCallEvent("dial.conference.interrupted", map[string]any{"reason": "url_updated"})
@@ -1165,15 +1441,74 @@ func (r *CallRunner) executeDialConference(ctx context.Context, dial *twiml.Dial
 		case <-r.conferenceCompleteCh:
 			r.addCallEvent("dial.conference.completed", map[string]any{"reason": "completed_via_api"})
 			goto conferenceEnded
+		case <-r.kickCh:
+			r.addCallEvent("dial.conference.removed", map[string]any{"reason": "removed_via_api"})
+			goto conferenceEnded
+		case <-starCh:
+			digits := r.takeDigits()
+			// Check if star is pressed by caller to leave conference
+			if strings.Contains(digits, "*") {
+				r.addCallEvent("dial.hangup_on_star", map[string]any{
+					"digits": digits,
+				})
+				goto conferenceEnded
+			}
+		case <-r.participantCh:
+			var hold bool
+			hold, holdURL, holdMethod = r.participantHold(conf.SID)
+			if hold == held {
+				continue
+			}
+			held = hold
+			event := "participant-unhold"
+			holdLoop = nil
+			if held {
+				event = "participant-hold"
+				holdLoop = playHold
+				r.addCallEvent("participant.hold", map[string]any{
+					"conference_sid": conf.SID,
+					"hold_url":       holdURL,
+					"hold_method":    holdMethod,
+				})
+			} else {
+				r.addCallEvent("participant.unhold", map[string]any{"conference_sid": conf.SID})
+			}
+			callSID := r.call.SID
+			r.state.mu.Lock()
+			r.engine.queueConferenceStatusCallbackLocked(r.state, conf, event, &callSID, currentTwimlDocumentURL)
+			r.state.mu.Unlock()
+		case <-holdLoop:
+			started := r.clock.Now()
+			if err := r.executeWait(ctx, "participant.hold", holdURL, holdMethod, currentTwimlDocumentURL); err != nil {
+				if errors.Is(err, ErrURLUpdated) {
+					urlUpdated = true
+					goto conferenceEnded
+				}
+				// The hold URL is broken, leave the participant on hold in silence
+				holdLoop = nil
+			}
+			// Hold music that takes no time to play is only played once
+			if !r.clock.Now().After(started) {
+				holdLoop = nil
+			}
+		case a := <-r.announceCh:
+			err := r.executeWait(ctx, "participant.announce", a.url, a.method, currentTwimlDocumentURL)
+			a.done(currentTwimlDocumentURL)
+			if errors.Is(err, ErrURLUpdated) {
+				urlUpdated = true
+				goto conferenceEnded
+			}
 		}
 	}
 conferenceEnded:
+	stopTimeLimit()
 	r.state.mu.Lock()
 	r.removeFromConference(conf, currentTwimlDocumentURL)
 	r.state.mu.Unlock()
 
 	// If recording was enabled and a recording was set, invoke RecordingStatusCallback
 	r.invokeRecordingCallback(ctx, dial, conference, recordingStartTime, r.call.SID, currentTwimlDocumentURL)
+	r.discardDigits()
 
 	// Call action callback
 	r.state.mu.RLock()
@@ -1193,99 +1528,37 @@ conferenceEnded:
 	return r.executeActionCallback(ctx, dial.Method, dial.Action, form, currentTwimlDocumentURL, false)
 }
 
+// dialTarget is a number, client or SIP address dialed by <Dial>
+type dialTarget struct {
+	to                  string
+	url                 string
+	statusCallback      string
+	statusCallbackEvent string
+}
+
+// dialChild is a child call placed by <Dial>
+type dialChild struct {
+	callSID model.SID
+	runner  *CallRunner
+}
+
 func (r *CallRunner) executeDialNumber(ctx context.Context, dial *twiml.Dial, numbers []*twiml.Number, clients []*twiml.Client, sips []*twiml.Sip, currentTwimlDocumentURL string) error {
 	r.addCallEvent("dial.number", map[string]any{
-		"numbers":      numbers,
-		"clients":      clients,
-		"sips":         sips,
-		"timeout":      dial.Timeout.Seconds(),
-		"hangupOnStar": dial.HangupOnStar,
+		"numbers":        numbers,
+		"clients":        clients,
+		"sips":           sips,
+		"timeout":        dial.Timeout.Seconds(),
+		"hangupOnStar":   dial.HangupOnStar,
+		"timeLimit":      dial.TimeLimit.Seconds(),
+		"answerOnBridge": dial.AnswerOnBridge,
+		"ringTone":       dial.RingTone,
+		"sequential":     dial.Sequential,
+		"trim":           dial.Trim,
+		"referUrl":       dial.ReferURL,
+		"referMethod":    dial.ReferMethod,
 	})
 
-	// Track all child calls and their runners
-	type childCall struct {
-		callSID  model.SID
-		runner   *CallRunner
-		answerCh chan struct{}
-	}
-	var childCalls []childCall
-	var childCallsMu sync.Mutex
-
-	// Cleanup function to hangup all child calls
-	hangupAllChildren := func() {
-		childCallsMu.Lock()
-		defer childCallsMu.Unlock()
-		for _, child := range childCalls {
-			if child.runner != nil {
-				child.runner.Hangup()
-			}
-		}
-	}
-	defer hangupAllChildren()
-
-	// Create outbound calls for all numbers, clients, and sips in parallel
-	var wg sync.WaitGroup
-
-	// Helper function to create a child call
-	createChildCall := func(to, urlStr, statusCallback, statusCallbackEvent string) {
-		defer wg.Done()
-
-		params := &twilioopenapi.CreateCallParams{}
-		params.SetPathAccountSid(string(r.call.AccountSID))
-		// Use callerId if specified, otherwise use parent's From
-		fromNumber := r.call.From
-		if dial.CallerId != "" {
-			fromNumber = dial.CallerId
-		}
-		params.SetFrom(fromNumber)
-		params.SetTo(to)
-		params.SetUrl(urlStr)
-		if dial.Timeout > 0 {
-			params.SetTimeout(int(dial.Timeout.Seconds()))
-		}
-
-		if statusCallback != "" {
-			params.SetStatusCallback(statusCallback)
-		}
-		if statusCallbackEvent != "" {
-			events := strings.Split(statusCallbackEvent, " ")
-			params.SetStatusCallbackEvent(events)
-		}
-
-		apiCall, err := r.engine.createChildCall(params, &r.call.SID)
-		if err != nil {
-			r.addCallEvent("dial.create_call_failed", map[string]any{
-				"to":    to,
-				"error": err.Error(),
-			})
-			r.recordError(err)
-			return
-		}
-
-		callSID := model.SID(*apiCall.Sid)
-
-		// Get the runner for this child call
-		r.state.mu.RLock()
-		runner := r.state.runners[callSID]
-		child := r.state.calls[callSID]
-		r.state.mu.RUnlock()
-
-		if runner == nil || child == nil {
-			r.recordError(fmt.Errorf("child call %s not found", callSID))
-			return
-		}
-		r.state.mu.Lock()
-		r.state.calls[r.call.SID].ChildCallSIDs = append(r.state.calls[r.call.SID].ChildCallSIDs, callSID)
-		r.state.mu.Unlock()
-		// Track this child call
-		childCallsMu.Lock()
-		childCalls = append(childCalls, childCall{
-			callSID:  callSID,
-			runner:   runner,
-			answerCh: runner.answerCh,
-		})
-		childCallsMu.Unlock()
-	}
+	var targets []dialTarget
 
 	// Dial all numbers
 	for _, number := range numbers {
@@ -1302,8 +1575,12 @@ func (r *CallRunner) executeDialNumber(ctx context.Context, dial *twiml.Dial, nu
 				return err
 			}
 		}
-		wg.Add(1)
-		go createChildCall(number.Number, resolvedURL, number.StatusCallback, number.StatusCallbackEvent)
+		targets = append(targets, dialTarget{
+			to:                  number.Number,
+			url:                 resolvedURL,
+			statusCallback:      number.StatusCallback,
+			statusCallbackEvent: number.StatusCallbackEvent,
+		})
 	}
 
 	// Dial all clients
@@ -1321,8 +1598,7 @@ func (r *CallRunner) executeDialNumber(ctx context.Context, dial *twiml.Dial, nu
 				return err
 			}
 		}
-		wg.Add(1)
-		go createChildCall("client:"+client.Name, resolvedURL, "", "")
+		targets = append(targets, dialTarget{to: "client:" + client.Name, url: resolvedURL})
 	}
 
 	// Dial all sips
@@ -1351,106 +1627,319 @@ func (r *CallRunner) executeDialNumber(ctx context.Context, dial *twiml.Dial, nu
 				})
 			}
 		}
-		wg.Add(1)
 		// SIP addresses are used as-is in the To field
-		go createChildCall(sip.SipAddress, resolvedURL, resolvedStatusCallback, sip.StatusCallbackEvent)
-	}
-
-	// Wait for all CreateCall operations to complete
-	wg.Wait()
-
-	// If no child calls were created successfully, return no-answer
-	childCallsMu.Lock()
-	if len(childCalls) == 0 {
-		childCallsMu.Unlock()
-		r.addCallEvent("dial.no_answer", map[string]any{
-			"reason": "no_child_calls_created",
+		targets = append(targets, dialTarget{
+			to:                  sip.SipAddress,
+			url:                 resolvedURL,
+			statusCallback:      resolvedStatusCallback,
+			statusCallbackEvent: sip.StatusCallbackEvent,
 		})
-		return r.executeActionCallback(ctx, dial.Method, dial.Action, url.Values{
-			"DialCallStatus": {"no-answer"},
-		}, currentTwimlDocumentURL, false)
 	}
 
-	childCallsMu.Unlock()
-
-	// Wait for first answer, timeout, or parent hangup
-	timeoutTimer := r.clock.After(dial.Timeout)
-
-	var answeredCallSID model.SID
-	var answeredRunner *CallRunner
+	// Hang up all child calls once the dial ends
+	var children []dialChild
+	defer func() {
+		for _, child := range children {
+			child.runner.Hangup()
+		}
+	}()
+
+	// Ring every target at once, or one after another when sequential
+	groups := [][]dialTarget{targets}
+	if dial.Sequential {
+		groups = groups[:0]
+		for _, target := range targets {
+			groups = append(groups, []dialTarget{target})
+		}
+	}
+	var answered *dialChild
+	dialStatus := "no-answer"
+	for i, group := range groups {
+		if i > 0 {
+			r.addCallEvent("dial.sequential_next", map[string]any{
+				"to":              group[0].to,
+				"previous_status": dialStatus,
+			})
+		}
+		placed := r.placeDialCalls(dial, group)
+		children = append(children, placed...)
+		status, child, err := r.ringDialCalls(ctx, dial, placed)
+		if err != nil {
+			return err
+		}
+		if status == "" {
+			// The caller hung up while the dialed parties were ringing
+			return nil
+		}
+		dialStatus = status
+		if child != nil {
+			answered = child
+			break
+		}
+	}
+
+	if answered == nil {
+		r.discardDigits()
+		if r.awaitingAnswer() {
+			// answerOnBridge kept the caller ringing, so the call ends with the
+			// dial's outcome unless the action answers it
+			r.unansweredStatus = model.CallStatus(dialStatus)
+		}
+		// Like Twilio, the dialed call is reported only when there was one
+		var dialCallSID model.SID
+		if len(children) == 1 {
+			dialCallSID = children[0].callSID
+		}
+		return r.executeActionCallback(ctx, dial.Method, dial.Action, r.dialActionForm(dial, dialStatus, dialCallSID, 0), currentTwimlDocumentURL, false)
+	}
+
+	if r.awaitingAnswer() {
+		r.addCallEvent("dial.answer_on_bridge", map[string]any{
+			"answered_call_sid": answered.callSID,
+		})
+		r.answerNow()
+	}
+
+	// Bridge with the answered call
+	r.addCallEvent("dial.answered", map[string]any{
+		"answered_call_sid": answered.callSID,
+	})
+	bridgeStart := r.clock.Now()
+
+	// Start recording if requested
+	var recordingStartTime *time.Time
+	if dial.Record != "" && dial.Record != "do-not-record" {
+		now := r.clock.Now()
+		recordingStartTime = &now
+		r.addCallEvent("dial.recording_started", map[string]any{
+			"record": dial.Record,
+		})
+	}
+
+	// Wait for the bridge to end: either party hangs up, the time limit
+	// passes, or the caller presses star with hangupOnStar
+	var starCh chan struct{}
+	if dial.HangupOnStar {
+		starCh = r.dtmfCh
+	}
+	timeLimit, stopTimeLimit := r.dialTimeLimit(dial)
+	defer stopTimeLimit()
+	// silenceStart is when the caller went silent, which trim-silence cuts
+	// from the end of the recording
+	var silenceStart *time.Time
+bridge:
+	for {
+		r.engine.park(r.hangupCh, answered.runner.hangupCh, timeLimit, starCh, r.silenceCh)
+		select {
+		case <-ctx.Done():
+			answered.runner.Hangup()
+			return ctx.Err()
+		case <-r.hangupCh:
+			// Parent hung up
+			answered.runner.Hangup()
+			r.addCallEvent("dial.bridge_ended", map[string]any{
+				"reason": "parent_hangup",
+			})
+			break bridge
+		case <-answered.runner.hangupCh:
+			// Child hung up
+			r.addCallEvent("dial.bridge_ended", map[string]any{
+				"reason": "child_hangup",
+			})
+			break bridge
+		case <-timeLimit:
+			answered.runner.Hangup()
+			r.addCallEvent("dial.bridge_ended", map[string]any{
+				"reason":     "time_limit",
+				"time_limit": dial.TimeLimit.Seconds(),
+			})
+			break bridge
+		case <-starCh:
+			digits := r.takeDigits()
+			// Check if star is pressed
+			if strings.Contains(digits, "*") {
+				r.addCallEvent("dial.hangup_on_star", map[string]any{
+					"digits": digits,
+				})
+				answered.runner.Hangup()
+				return r.executeHangup(false)
+			}
+		case <-r.silenceCh:
+			if silenceStart == nil {
+				now := r.clock.Now()
+				silenceStart = &now
+				r.addCallEvent("dial.silence_started", map[string]any{})
+			}
+		}
+	}
+	stopTimeLimit()
+	duration := int(r.clock.Now().Sub(bridgeStart).Seconds())
+	if recordingStartTime != nil && dial.Trim == "trim-silence" && silenceStart != nil {
+		r.trimDialRecording(r.clock.Now().Sub(*silenceStart))
+	}
+
+	// Invoke recording callback if recording was enabled
+	if recordingStartTime != nil && dial.RecordingStatusCallback != "" {
+		r.invokeRecordingCallback(ctx, dial, nil, *recordingStartTime, r.call.SID, currentTwimlDocumentURL)
+	}
+	r.discardDigits()
+
+	// Call action callback
+	return r.executeActionCallback(ctx, dial.Method, dial.Action, r.dialActionForm(dial, "completed", answered.callSID, duration), currentTwimlDocumentURL, false)
+}
+
+// placeDialCalls creates a child call for each target in parallel. Targets
+// whose call cannot be created are left out.
+func (r *CallRunner) placeDialCalls(dial *twiml.Dial, targets []dialTarget) []dialChild {
+	placed := make([]*dialChild, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		r.engine.goTracked(func() {
+			defer wg.Done()
+			placed[i] = r.placeDialCall(dial, target)
+		})
+	}
+	wg.Wait()
+
+	var children []dialChild
+	for _, child := range placed {
+		if child != nil {
+			children = append(children, *child)
+		}
+	}
+	return children
+}
+
+// placeDialCall creates the child call for one target
+func (r *CallRunner) placeDialCall(dial *twiml.Dial, target dialTarget) *dialChild {
+	params := &twilioopenapi.CreateCallParams{}
+	params.SetPathAccountSid(string(r.call.AccountSID))
+	// Use callerId if specified, otherwise use parent's From
+	fromNumber := r.call.From
+	if dial.CallerId != "" {
+		fromNumber = dial.CallerId
+	}
+	params.SetFrom(fromNumber)
+	params.SetTo(target.to)
+	params.SetUrl(target.url)
+	if dial.Timeout > 0 {
+		params.SetTimeout(int(dial.Timeout.Seconds()))
+	}
+
+	if target.statusCallback != "" {
+		params.SetStatusCallback(target.statusCallback)
+	}
+	if target.statusCallbackEvent != "" {
+		events := strings.Split(target.statusCallbackEvent, " ")
+		params.SetStatusCallbackEvent(events)
+	}
+
+	apiCall, err := r.engine.createChildCall(params, &r.call.SID)
+	if err != nil {
+		r.addCallEvent("dial.create_call_failed", map[string]any{
+			"to":    target.to,
+			"error": err.Error(),
+		})
+		r.recordError(err)
+		return nil
+	}
+
+	callSID := model.SID(*apiCall.Sid)
+
+	// Get the runner for this child call
+	r.state.mu.RLock()
+	runner := r.state.runners[callSID]
+	child := r.state.calls[callSID]
+	r.state.mu.RUnlock()
+
+	if runner == nil || child == nil {
+		r.recordError(fmt.Errorf("child call %s not found", callSID))
+		return nil
+	}
+	r.state.mu.Lock()
+	r.state.calls[r.call.SID].ChildCallSIDs = append(r.state.calls[r.call.SID].ChildCallSIDs, callSID)
+	r.state.mu.Unlock()
+	return &dialChild{callSID: callSID, runner: runner}
+}
+
+// ringDialCalls waits for the first child call to answer. It returns the
+// DialCallStatus and the answered child, or an empty status when the caller
+// hung up first. Children that lose the race are hung up.
+func (r *CallRunner) ringDialCalls(ctx context.Context, dial *twiml.Dial, children []dialChild) (string, *dialChild, error) {
+	if len(children) == 0 {
+		r.addCallEvent("dial.no_answer", map[string]any{
+			"reason": "no_child_calls_created",
+		})
+		return "no-answer", nil, nil
+	}
 
 	// Track completion status of all children
 	type childStatus struct {
 		callSID model.SID
 		status  string // "answered", "busy", "failed", "no-answer"
 	}
-	childStatusCh := make(chan childStatus, len(childCalls))
-	completedChildren := make(map[model.SID]string) // callSID -> status
-	var completedMu sync.Mutex
+	childStatusCh := make(chan childStatus, len(children))
+	stop := make(chan struct{})
+	defer r.engine.signal(stop, func() {
+		close(stop)
+	})
 
 	// Launch goroutines to monitor each child's status channels
-	for _, child := range childCalls {
-		child := child // capture loop variable
-		go func() {
+	for _, child := range children {
+		r.engine.goTracked(func() {
+			r.engine.park(child.runner.answerCh, child.runner.busyCh, child.runner.failedCh, child.runner.noAnswerCh, stop, r.hangupCh)
 			select {
-			case <-child.answerCh:
-				childStatusCh <- childStatus{callSID: child.callSID, status: "answered"}
+			case <-child.runner.answerCh:
+				notify(r.engine, childStatusCh, childStatus{callSID: child.callSID, status: "answered"})
 			case <-child.runner.busyCh:
-				childStatusCh <- childStatus{callSID: child.callSID, status: "busy"}
+				notify(r.engine, childStatusCh, childStatus{callSID: child.callSID, status: "busy"})
 			case <-child.runner.failedCh:
-				childStatusCh <- childStatus{callSID: child.callSID, status: "failed"}
+				notify(r.engine, childStatusCh, childStatus{callSID: child.callSID, status: "failed"})
+			case <-child.runner.noAnswerCh:
+				notify(r.engine, childStatusCh, childStatus{callSID: child.callSID, status: "no-answer"})
+			case <-stop:
 			case <-ctx.Done():
 			case <-r.hangupCh:
 			}
-		}()
+		})
 	}
 
-	// Wait for first answer or all children to complete
-	totalChildren := len(childCalls)
-
-waitLoop:
+	// Wait for first answer, timeout, or parent hangup
+	timeoutTimer, stopTimeout := r.engine.after(r.clock, dial.Timeout)
+	defer stopTimeout()
+	completedChildren := make(map[model.SID]string) // callSID -> status
 	for {
+		r.engine.park(r.hangupCh, timeoutTimer, childStatusCh)
 		select {
 		case <-ctx.Done():
-			return ctx.Err()
+			return "", nil, ctx.Err()
 		case <-r.hangupCh:
 			r.addCallEvent("dial.parent_hangup", map[string]any{})
-			return nil
+			return "", nil, nil
 		case <-timeoutTimer:
 			r.addCallEvent("dial.no_answer", map[string]any{
 				"reason": "timeout",
 			})
-			return r.executeActionCallback(ctx, dial.Method, dial.Action, url.Values{
-				"DialCallStatus": {"no-answer"},
-			}, currentTwimlDocumentURL, false)
+			return "no-answer", nil, nil
 		case status := <-childStatusCh:
-			completedMu.Lock()
 			completedChildren[status.callSID] = status.status
-			numCompleted := len(completedChildren)
-			completedMu.Unlock()
 
 			if status.status == "answered" {
-				// One call answered! Break out and proceed with bridging
-				answeredCallSID = status.callSID
-				childCallsMu.Lock()
-				for _, child := range childCalls {
-					if child.callSID == answeredCallSID {
-						answeredRunner = child.runner
+				// One call answered! Hang up the others and bridge with it
+				var answered *dialChild
+				for i, child := range children {
+					if child.callSID == status.callSID {
+						answered = &children[i]
 					} else {
-						// Hangup all other child calls
-						if child.runner != nil {
-							child.runner.Hangup()
-						}
+						child.runner.Hangup()
 					}
 				}
-				childCallsMu.Unlock()
-				break waitLoop
+				return "completed", answered, nil
 			}
 
 			// Check if all children have completed without any answering
-			if numCompleted == totalChildren {
-				// All children completed, none answered
-				completedMu.Lock()
+			if len(completedChildren) == len(children) {
 				allBusy := true
 				anyFailed := false
 				for _, st := range completedChildren {
@@ -1461,118 +1950,84 @@ waitLoop:
 						anyFailed = true
 					}
 				}
-				completedMu.Unlock()
 
-				var dialStatus string
 				if allBusy {
-					dialStatus = "busy"
 					r.addCallEvent("dial.busy", map[string]any{
 						"reason": "all_children_busy",
 					})
-				} else if anyFailed {
-					dialStatus = "failed"
+					return "busy", nil, nil
+				}
+				if anyFailed {
 					r.addCallEvent("dial.failed", map[string]any{
 						"reason": "one_or_more_children_failed",
 					})
-				} else {
-					dialStatus = "no-answer"
-					r.addCallEvent("dial.no_answer", map[string]any{
-						"reason": "all_children_completed_without_answer",
-					})
+					return "failed", nil, nil
 				}
-
-				return r.executeActionCallback(ctx, dial.Method, dial.Action, url.Values{
-					"DialCallStatus": {dialStatus},
-				}, currentTwimlDocumentURL, false)
+				r.addCallEvent("dial.no_answer", map[string]any{
+					"reason": "all_children_completed_without_answer",
+				})
+				return "no-answer", nil, nil
 			}
 		}
 	}
+}
 
-	if answeredRunner == nil {
-		r.addCallEvent("dial.no_answer", map[string]any{
-			"reason": "answered_runner_not_found",
-		})
-		return r.executeActionCallback(ctx, dial.Method, dial.Action, url.Values{
-			"DialCallStatus": {"no-answer"},
-		}, currentTwimlDocumentURL, false)
+// trimDialRecording cuts the trailing silence from the recording set for the
+// call, as trim="trim-silence" does
+func (r *CallRunner) trimDialRecording(silence time.Duration) {
+	r.state.mu.Lock()
+	recording := r.state.recordings[r.state.callRecordings[r.call.SID]]
+	if recording == nil {
+		r.state.mu.Unlock()
+		return
 	}
+	trimmed := min(int(silence.Seconds()), recording.Duration)
+	recording.Duration -= trimmed
+	recordingSID := recording.SID
+	r.state.mu.Unlock()
 
-	// Bridge with the answered call
-	r.addCallEvent("dial.answered", map[string]any{
-		"answered_call_sid": answeredCallSID,
+	r.addCallEvent("dial.recording_trimmed", map[string]any{
+		"recording_sid": recordingSID,
+		"trimmed":       trimmed,
 	})
+}
 
-	// Start recording if requested
-	var recordingStartTime *time.Time
-	if dial.Record != "" && dial.Record != "do-not-record" {
-		now := r.clock.Now()
-		recordingStartTime = &now
-		r.addCallEvent("dial.recording_started", map[string]any{
-			"record": dial.Record,
-		})
+// dialTimeLimit returns a channel that fires once a <Dial> has been connected
+// for its timeLimit, or nil when it has none, and a func that stops it
+func (r *CallRunner) dialTimeLimit(dial *twiml.Dial) (<-chan time.Time, func()) {
+	if dial.TimeLimit <= 0 {
+		return nil, func() {}
 	}
+	return r.engine.after(r.clock, dial.TimeLimit)
+}
 
-	// Wait for bridge to end (either party hangs up or HangupOnStar)
-	if dial.HangupOnStar {
-		for {
-			select {
-			case <-ctx.Done():
-				answeredRunner.Hangup()
-				return ctx.Err()
-			case <-r.hangupCh:
-				// Parent hung up
-				answeredRunner.Hangup()
-				r.addCallEvent("dial.bridge_ended", map[string]any{
-					"reason": "parent_hangup",
-				})
-				goto bridgeEnded
-			case <-answeredRunner.hangupCh:
-				// Child hung up
-				r.addCallEvent("dial.bridge_ended", map[string]any{
-					"reason": "child_hangup",
-				})
-				goto bridgeEnded
-			case digits := <-r.gatherCh:
-				// Check if star is pressed
-				if strings.Contains(digits, "*") {
-					r.addCallEvent("dial.hangup_on_star", map[string]any{
-						"digits": digits,
-					})
-					answeredRunner.Hangup()
-					return r.executeHangup(false)
-				}
-			}
-		}
-	} else {
-		select {
-		case <-ctx.Done():
-			answeredRunner.Hangup()
-			return ctx.Err()
-		case <-r.hangupCh:
-			// Parent hung up
-			answeredRunner.Hangup()
-			r.addCallEvent("dial.bridge_ended", map[string]any{
-				"reason": "parent_hangup",
-			})
-		case <-answeredRunner.hangupCh:
-			// Child hung up
-			r.addCallEvent("dial.bridge_ended", map[string]any{
-				"reason": "child_hangup",
-			})
-		}
+// dialActionForm builds the parameters of a <Dial> action request. duration is
+// the length of the bridged call in seconds.
+func (r *CallRunner) dialActionForm(dial *twiml.Dial, dialStatus string, dialCallSID model.SID, duration int) url.Values {
+	bridged := dialStatus == "completed"
+	form := url.Values{}
+	form.Set("DialCallStatus", dialStatus)
+	form.Set("DialBridged", strconv.FormatBool(bridged))
+	if dialCallSID != "" {
+		form.Set("DialCallSid", dialCallSID.String())
 	}
-
-bridgeEnded:
-	// Invoke recording callback if recording was enabled
-	if recordingStartTime != nil && dial.RecordingStatusCallback != "" {
-		r.invokeRecordingCallback(ctx, dial, nil, *recordingStartTime, r.call.SID, currentTwimlDocumentURL)
+	if !bridged {
+		return form
 	}
+	form.Set("DialCallDuration", strconv.Itoa(duration))
 
-	// Call action callback
-	return r.executeActionCallback(ctx, dial.Method, dial.Action, url.Values{
-		"DialCallStatus": {"answered"},
-		"DialCallSid":    {answeredCallSID.String()},
-	}, currentTwimlDocumentURL, false)
+	if dial.Record == "" || dial.Record == "do-not-record" {
+		return form
+	}
+	r.state.mu.RLock()
+	defer r.state.mu.RUnlock()
+	recordingSID, ok := r.state.callRecordings[r.call.SID]
+	if recording := r.state.recordings[recordingSID]; ok && recording != nil {
+		form.Set("RecordingSid", string(recordingSID))
+		form.Set("RecordingUrl", r.recordingURL(recordingSID))
+		form.Set("RecordingDuration", strconv.Itoa(recordingDurationLocked(r.state, recording)))
+	}
+	return form
 }
 
 func (r *CallRunner) executeEnqueue(ctx context.Context, enqueue *twiml.Enqueue, currentTwimlDocumentURL string) error {
@@ -1620,23 +2075,18 @@ func (r *CallRunner) bridgeEnqueueWithAgent(ctx context.Context, enqueue *twiml.
 
 	// Signal the agent call to dequeue with "bridged" result and partner SID
 	if agentRunner != nil {
-		select {
-		case agentRunner.dequeueCh <- dequeueResult{result: "bridged", partnerSID: r.call.SID}:
-		default:
-		}
+		notify(r.engine, agentRunner.dequeueCh, dequeueResult{result: "bridged", partnerSID: r.call.SID})
 	}
 
 	// Bridge - wait for hangup (no timeout for enqueued callers)
 	urlUpdated := false
+	r.engine.park(r.hangupCh, r.bridgeEndCh, r.urlUpdateCh)
 	select {
 	case <-ctx.Done():
 	case <-r.hangupCh:
 		// This call hung up, notify the agent
 		if agentRunner != nil {
-			select {
-			case agentRunner.bridgeEndCh <- struct{}{}:
-			default:
-			}
+			notify(r.engine, agentRunner.bridgeEndCh, struct{}{})
 		}
 	case <-r.bridgeEndCh:
 		// Agent call hung up, end this bridge
@@ -1646,10 +2096,7 @@ func (r *CallRunner) bridgeEnqueueWithAgent(ctx context.Context, enqueue *twiml.
 		r.addCallEvent("enqueue.bridge_interrupted", map[string]any{"reason": "url_updated"})
 		// Notify the agent before leaving
 		if agentRunner != nil {
-			select {
-			case agentRunner.bridgeEndCh <- struct{}{}:
-			default:
-			}
+			notify(r.engine, agentRunner.bridgeEndCh, struct{}{})
 		}
 	}
 
@@ -1661,6 +2108,7 @@ func (r *CallRunner) bridgeEnqueueWithAgent(ctx context.Context, enqueue *twiml.
 		"agent_call_sid": agentCallSID,
 		"queue_time":     queueTime,
 	})
+	r.discardDigits()
 
 	// Call action callback with bridge results
 	form := url.Values{}
@@ -1688,11 +2136,7 @@ func (r *CallRunner) waitInEnqueue(ctx context.Context, enqueue *twiml.Enqueue,
 	r.state.mu.Lock()
 	// Add this call to the queue
 	queue.Members = append(queue.Members, r.call.SID)
-	queue.Timeline = append(queue.Timeline, model.NewEvent(
-		startTime,
-		"member.enqueued",
-		map[string]any{"call_sid": r.call.SID},
-	))
+	r.engine.addQueueEventLocked(r.state, queue, "member.enqueued", map[string]any{"call_sid": r.call.SID})
 
 	r.call.CurrentEndpoint = "queue:" + enqueue.Name
 	r.state.mu.Unlock()
@@ -1712,6 +2156,7 @@ func (r *CallRunner) waitInEnqueue(ctx context.Context, enqueue *twiml.Enqueue,
 	queueResult := ""
 	var bridgePartnerSID model.SID
 	urlUpdated := false
+	r.engine.park(r.hangupCh, r.urlUpdateCh, r.dequeueCh)
 	select {
 	case <-ctx.Done():
 		queueResult = "system-shutdown"
@@ -1756,15 +2201,13 @@ func (r *CallRunner) waitInEnqueue(ctx context.Context, enqueue *twiml.Enqueue,
 		r.state.mu.Unlock()
 
 		// Wait for bridge to complete
+		r.engine.park(r.hangupCh, r.bridgeEndCh, r.urlUpdateCh)
 		select {
 		case <-ctx.Done():
 		case <-r.hangupCh:
 			// This call hung up, notify the partner
 			if partnerRunner != nil {
-				select {
-				case partnerRunner.bridgeEndCh <- struct{}{}:
-				default:
-				}
+				notify(r.engine, partnerRunner.bridgeEndCh, struct{}{})
 			}
 		case <-r.bridgeEndCh:
 			// Partner call hung up, end this bridge
@@ -1774,10 +2217,7 @@ func (r *CallRunner) waitInEnqueue(ctx context.Context, enqueue *twiml.Enqueue,
 			r.addCallEvent("enqueue.bridge_interrupted", map[string]any{"reason": "url_updated"})
 			// Notify partner before leaving
 			if partnerRunner != nil {
-				select {
-				case partnerRunner.bridgeEndCh <- struct{}{}:
-				default:
-				}
+				notify(r.engine, partnerRunner.bridgeEndCh, struct{}{})
 			}
 		}
 
@@ -1789,6 +2229,7 @@ func (r *CallRunner) waitInEnqueue(ctx context.Context, enqueue *twiml.Enqueue,
 			"bridge_duration": bridgeDuration,
 		})
 	}
+	r.discardDigits()
 
 	// Call action callback with queue results
 	form := url.Values{}
@@ -1825,7 +2266,7 @@ func (r *CallRunner) executeWait(ctx context.Context, eventPrefix, waitURL, wait
 			return err
 		}
 
-		reqCtx, cancel := context.WithTimeout(ctx, r.timeout)
+		reqCtx, cancel := context.WithTimeout(r.requestContext(ctx), r.timeout)
 		defer cancel()
 
 		callForm := r.buildCallForm()
@@ -1851,9 +2292,10 @@ func (r *CallRunner) executeWait(ctx context.Context, eventPrefix, waitURL, wait
 				}
 			}
 			urlWithParams.RawQuery = q.Encode()
-			status, body, headers, fetchErr = r.engine.webhook.GET(reqCtx, urlWithParams.String())
+			signedURL := urlWithParams.String()
+			status, body, headers, fetchErr = r.engine.webhook.GET(reqCtx, signedURL, r.engine.signedHeaders(r.state, signedURL, nil))
 		} else {
-			status, body, headers, fetchErr = r.engine.webhook.POST(reqCtx, resolvedWaitURL, callForm)
+			status, body, headers, fetchErr = r.engine.webhook.POST(reqCtx, resolvedWaitURL, callForm, r.engine.signedHeaders(r.state, resolvedWaitURL, callForm))
 		}
 
 		if fetchErr != nil {
@@ -1978,15 +2420,23 @@ func (r *CallRunner) executeRecord(ctx context.Context, record *twiml.Record, cu
 	r.trackCallTwiML(record)
 
 	r.addCallEvent("twiml.record", map[string]any{
-		"max_length": record.MaxLength.Seconds(),
-		"play_beep":  record.PlayBeep,
-		"action":     record.Action,
-		"transcribe": record.Transcribe,
-		"timeout":    record.TimeoutInSeconds.Seconds(),
+		"max_length":          record.MaxLength.Seconds(),
+		"play_beep":           record.PlayBeep,
+		"action":              record.Action,
+		"transcribe":          record.Transcribe,
+		"transcribe_callback": record.TranscribeCallback,
+		"timeout":             record.TimeoutInSeconds.Seconds(),
+		"finish_on_key":       record.FinishOnKey,
+		"trim":                record.Trim,
 	})
 
 	r.state.mu.Lock()
 	r.call.CurrentEndpoint = "recording"
+	// A voicemail set for this call becomes the recording
+	recordingSID, hasVoicemail := r.state.callVoicemails[r.call.SID]
+	if !hasVoicemail {
+		recordingSID = r.engine.ids.RecordingSID()
+	}
 	r.state.mu.Unlock()
 
 	// Simulate beep if enabled
@@ -1994,56 +2444,137 @@ func (r *CallRunner) executeRecord(ctx context.Context, record *twiml.Record, cu
 		r.addCallEvent("record.beep", map[string]any{})
 	}
 
-	// Wait for timeout, maxLength, or hangup
-	// In a real implementation, this would wait for silence detection or user hangup
+	start := r.clock.Now()
+	r.sendRecordStatusCallback(ctx, record, "in-progress", recordingSID, start, 0, currentTwimlDocumentURL)
+
+	// Wait for a finish key, silence timeout, maxLength, or hangup
 	var recordingStatus string
+	var digits string
+	var silenceStart time.Time
+	var silenceTimeout <-chan time.Time
+	var end time.Time // when the recording stopped, if set by a timer
+	maxLength, stopMaxLength := r.engine.after(r.clock, record.MaxLength)
+	stopSilenceTimeout := func() {}
+	hungUp := false
+waitLoop:
+	for {
+		r.engine.park(r.hangupCh, maxLength, r.dtmfCh, r.silenceCh, silenceTimeout)
+		select {
+		case <-ctx.Done():
+			recordingStatus = "canceled"
+			break waitLoop
+		case <-r.hangupCh:
+			recordingStatus = "completed"
+			digits = "hangup"
+			hungUp = true
+			break waitLoop
+		case <-maxLength:
+			// Max length reached
+			recordingStatus = "completed"
+			end = start.Add(record.MaxLength)
+			r.addCallEvent("record.max_length", map[string]any{})
+			break waitLoop
+		case <-r.dtmfCh:
+			input := r.takeDigits()
+			if i := strings.IndexAny(input, record.FinishOnKey); i >= 0 {
+				recordingStatus = "completed"
+				digits = input[i : i+1]
+				r.addCallEvent("record.finish_on_key", map[string]any{"digits": digits})
+				// Digits after the finish key are left for the next verb
+				r.unreadDigits(input[i+1:])
+				break waitLoop
+			}
+			r.addCallEvent("record.digits_ignored", map[string]any{"digits": input})
+		case <-r.silenceCh:
+			// A timeout of 0 disables silence detection
+			if silenceTimeout == nil && record.TimeoutInSeconds > 0 {
+				silenceStart = r.clock.Now()
+				silenceTimeout, stopSilenceTimeout = r.engine.after(r.clock, record.TimeoutInSeconds)
+				r.addCallEvent("record.silence_started", map[string]any{})
+			}
+		case <-silenceTimeout:
+			recordingStatus = "completed"
+			end = silenceStart.Add(record.TimeoutInSeconds)
+			r.addCallEvent("record.silence_timeout", map[string]any{"timeout": record.TimeoutInSeconds.Seconds()})
+			break waitLoop
+		}
+	}
+	stopMaxLength()
+	stopSilenceTimeout()
 
-	select {
-	case <-ctx.Done():
-		recordingStatus = "canceled"
-	case <-r.hangupCh:
-		recordingStatus = "completed"
-	case <-r.clock.After(record.MaxLength):
-		// Max length reached
-		recordingStatus = "completed"
-		r.addCallEvent("record.max_length", map[string]any{})
+	if end.IsZero() {
+		end = r.clock.Now()
+	}
+	// Trailing silence is trimmed from the recording unless trim="do-not-trim"
+	if !silenceStart.IsZero() && record.Trim != "do-not-trim" {
+		end = silenceStart
+	}
+	recordingDuration := int(end.Sub(start).Seconds())
+	// A caller who stayed silent leaves an empty recording
+	if recordingStatus == "completed" && !silenceStart.IsZero() && recordingDuration == 0 {
+		recordingStatus = "absent"
 	}
 
 	r.state.mu.Lock()
 	r.call.CurrentEndpoint = ""
-
-	// Check if a voicemail recording was set for this call
-	var recordingSID model.SID
-	var recordingDuration int
-	if voicemailSID, exists := r.state.callVoicemails[r.call.SID]; exists {
-		recordingSID = voicemailSID
-		if recording, exists := r.state.recordings[recordingSID]; exists {
-			recording.Status = recordingStatus
-			recordingDuration = recording.Duration
+	recording, exists := r.state.recordings[recordingSID]
+	if !exists {
+		callSID := r.call.SID
+		recording = &model.Recording{
+			SID:        recordingSID,
+			AccountSID: r.call.AccountSID,
+			CallSID:    &callSID,
+			Channels:   1,
+			Source:     "RecordVerb",
+			CreatedAt:  start,
 		}
-	} else {
-		// Generate a fake recording SID if no voicemail was set
-		recordingSID = model.NewRecordingSID()
+		r.state.recordings[recordingSID] = recording
 	}
+	recording.Status = recordingStatus
+	recording.Duration = recordingDuration
 	r.state.mu.Unlock()
 
 	r.addCallEvent("record.completed", map[string]any{
 		"recording_sid":      recordingSID,
 		"recording_duration": recordingDuration,
 		"recording_status":   recordingStatus,
+		"digits":             digits,
 	})
+	r.sendRecordStatusCallback(ctx, record, recordingStatus, recordingSID, start, recordingDuration, currentTwimlDocumentURL)
+
+	if record.Transcribe && recordingStatus == "completed" {
+		callbackURL := ""
+		if record.TranscribeCallback != "" {
+			resolvedURL, err := resolveURL(currentTwimlDocumentURL, record.TranscribeCallback)
+			if err != nil {
+				r.addCallEvent("record.transcribe_callback_error", map[string]any{
+					"url":   record.TranscribeCallback,
+					"error": err.Error(),
+				})
+				r.recordError(err)
+			} else {
+				callbackURL = resolvedURL
+			}
+		}
+		r.engine.startTranscription(r.state, r.call, recording, callbackURL)
+	}
+
+	// Twilio does not request the action URL for an empty recording, the call
+	// continues with the next verb instead
+	if recordingStatus == "absent" {
+		*terminated = hungUp
+		return nil
+	}
 
 	// Call action callback with recording results
 	form := url.Values{}
 	form.Set("RecordingSid", string(recordingSID))
-	// Use baseURL if set, otherwise use the default Twilio URL
-	recordingURL := fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Recordings/%s", r.call.AccountSID, recordingSID)
-	if r.engine.baseURL != "" {
-		recordingURL = fmt.Sprintf("%s/Accounts/%s/Recordings/%s", r.engine.baseURL, r.call.AccountSID, recordingSID)
-	}
-	form.Set("RecordingUrl", recordingURL)
+	form.Set("RecordingUrl", r.recordingURL(recordingSID))
 	form.Set("RecordingStatus", recordingStatus)
 	form.Set("RecordingDuration", fmt.Sprintf("%d", recordingDuration))
+	if digits != "" {
+		form.Set("Digits", digits)
+	}
 
 	if err := r.executeActionCallback(ctx, record.Method, record.Action, form, currentTwimlDocumentURL, false); err != nil {
 		return err
@@ -2052,6 +2583,54 @@ func (r *CallRunner) executeRecord(ctx context.Context, record *twiml.Record, cu
 	return nil
 }
 
+// sendRecordStatusCallback posts a <Record> recordingStatusCallback if the
+// status is one of its recordingStatusCallbackEvent values
+func (r *CallRunner) sendRecordStatusCallback(ctx context.Context, record *twiml.Record, status string, recordingSID model.SID, start time.Time, duration int, currentTwimlDocumentURL string) {
+	if record.RecordingStatusCallback == "" || !slices.Contains(strings.Fields(record.RecordingStatusCallbackEvent), status) {
+		return
+	}
+	resolvedURL, err := resolveURL(currentTwimlDocumentURL, record.RecordingStatusCallback)
+	if err != nil {
+		r.addCallEvent("record.status_callback_error", map[string]any{
+			"url":   record.RecordingStatusCallback,
+			"error": err.Error(),
+		})
+		r.recordError(err)
+		return
+	}
+
+	form := url.Values{}
+	form.Set("AccountSid", string(r.call.AccountSID))
+	form.Set("CallSid", string(r.call.SID))
+	form.Set("RecordingSid", string(recordingSID))
+	form.Set("RecordingUrl", r.recordingURL(recordingSID))
+	form.Set("RecordingStatus", status)
+	form.Set("RecordingChannels", "1")
+	form.Set("RecordingSource", "RecordVerb")
+	form.Set("RecordingStartTime", start.UTC().Format(time.RFC1123Z))
+	if status != "in-progress" {
+		form.Set("RecordingDuration", strconv.Itoa(duration))
+	}
+
+	if err := r.postCallback(ctx, record.RecordingStatusCallbackMethod, resolvedURL, form); err != nil {
+		r.addCallEvent("record.status_callback_error", map[string]any{
+			"url":   resolvedURL,
+			"error": err.Error(),
+		})
+		r.recordError(err)
+		return
+	}
+	r.addCallEvent("record.status_callback", map[string]any{
+		"url":              resolvedURL,
+		"recording_status": status,
+	})
+}
+
+// recordingURL returns the URL of a recording of the call's account
+func (r *CallRunner) recordingURL(recordingSID model.SID) string {
+	return r.engine.recordingURL(r.call.AccountSID, recordingSID)
+}
+
 func (r *CallRunner) executeHangup(implicit bool) error {
 	eventType := "twiml.hangup.implicit"
 	if !implicit {
@@ -2059,7 +2638,64 @@ func (r *CallRunner) executeHangup(implicit bool) error {
 		eventType = "twiml.hangup"
 	}
 	r.addCallEvent(eventType, map[string]any{})
-	r.updateStatus(model.CallCompleted)
+	r.updateStatus(r.hangupStatus())
+	now := r.clock.Now()
+	r.state.mu.Lock()
+	r.call.EndedAt = &now
+	r.state.mu.Unlock()
+	return ErrCallHungup // Signal to stop execution
+}
+
+// hangupStatus is the status the call ends with when it hangs up. A caller
+// that answerOnBridge left unanswered ends with the outcome of its dial.
+func (r *CallRunner) hangupStatus() model.CallStatus {
+	if r.unansweredStatus != "" && r.awaitingAnswer() {
+		return r.unansweredStatus
+	}
+	return model.CallCompleted
+}
+
+// defersAnswer reports whether a verb decides when an inbound call is
+// answered. <Reject> never answers it, and <Dial answerOnBridge="true">
+// answers it once the dialed party answers.
+func defersAnswer(node twiml.Node) bool {
+	switch n := node.(type) {
+	case *twiml.Reject:
+		return true
+	case *twiml.Dial:
+		return n.AnswerOnBridge
+	}
+	return false
+}
+
+// executeReject ends an inbound call that has not been answered with busy or
+// no-answer according to the reason. An answered call cannot be rejected, so
+// it records an error and hangs up instead.
+func (r *CallRunner) executeReject(reject *twiml.Reject) error {
+	r.trackCallTwiML(reject)
+
+	r.state.mu.RLock()
+	answered := r.call.AnsweredAt != nil
+	r.state.mu.RUnlock()
+	if answered {
+		err := fmt.Errorf("error %d: <Reject> must be the first verb of an inbound call's first TwiML document", ErrorCodeSchemaValidation)
+		r.addCallEvent("twiml.reject.error", map[string]any{
+			"reason":     reject.Reason,
+			"error_code": ErrorCodeSchemaValidation,
+			"error":      err.Error(),
+		})
+		r.recordError(err)
+		return r.executeHangup(true)
+	}
+
+	status := model.CallNoAnswer
+	if reject.Reason == "busy" {
+		status = model.CallBusy
+	}
+	r.addCallEvent("twiml.reject", map[string]any{
+		"reason": reject.Reason,
+	})
+	r.updateStatus(status)
 	now := r.clock.Now()
 	r.state.mu.Lock()
 	r.call.EndedAt = &now
@@ -2069,26 +2705,107 @@ func (r *CallRunner) executeHangup(implicit bool) error {
 
 // Hangup signals the runner to hang up
 func (r *CallRunner) Hangup() {
-	r.hangupOnce.Do(func() {
-		close(r.hangupCh)
+	r.broadcast(&r.hangupOnce, r.hangupCh)
+}
+
+// broadcast closes ch once, waking every goroutine waiting on it
+func (r *CallRunner) broadcast(once *sync.Once, ch chan struct{}) {
+	r.engine.signal(ch, func() {
+		once.Do(func() {
+			close(ch)
+		})
 	})
 }
 
-// SendDigits sends digits to the gather
-func (r *CallRunner) SendDigits(digits string) {
-	select {
-	case r.gatherCh <- digits:
+// sendPartialResults posts one partialResultCallback per recognized word of
+// utterance, as Twilio does while the caller is still speaking. speechResult
+// is the full transcript so far including utterance. It returns the last
+// sequence number used.
+func (r *CallRunner) sendPartialResults(ctx context.Context, gather *twiml.Gather, utterance, speechResult string, sequence int, currentTwimlDocumentURL string) int {
+	if gather.PartialResultCallback == "" {
+		return sequence
 	}
+	resolvedURL, err := resolveURL(currentTwimlDocumentURL, gather.PartialResultCallback)
+	if err != nil {
+		r.addCallEvent("gather.partial_result_error", map[string]any{
+			"url":   gather.PartialResultCallback,
+			"error": err.Error(),
+		})
+		r.recordError(err)
+		return sequence
+	}
+	language := gather.Language
+	if language == "" {
+		language = "en-US"
+	}
+
+	words := strings.Fields(utterance)
+	stablePrefix := strings.TrimSpace(strings.TrimSuffix(speechResult, utterance))
+	for i := range words {
+		sequence++
+		stable := strings.TrimSpace(stablePrefix + " " + strings.Join(words[:i], " "))
+
+		form := url.Values{}
+		form.Set("CallSid", string(r.call.SID))
+		form.Set("AccountSid", string(r.call.AccountSID))
+		form.Set("SequenceNumber", strconv.Itoa(sequence))
+		form.Set("StableSpeechResult", stable)
+		form.Set("UnstableSpeechResult", words[i])
+		form.Set("Stability", "0.8")
+		form.Set("LanguageCode", language)
+
+		err := r.postCallback(ctx, gather.PartialResultCallbackMethod, resolvedURL, form)
+		if err != nil {
+			r.addCallEvent("gather.partial_result_error", map[string]any{
+				"url":   resolvedURL,
+				"error": err.Error(),
+			})
+			r.recordError(err)
+			continue
+		}
+		r.addCallEvent("gather.partial_result", map[string]any{
+			"url":             resolvedURL,
+			"sequence_number": sequence,
+			"stable":          stable,
+			"unstable":        words[i],
+		})
+	}
+	return sequence
 }
 
-// UpdateURL signals the runner to interrupt current execution and fetch new TwiML from the updated URL
-func (r *CallRunner) UpdateURL(newURL string) {
+// sendSpeechLocked delivers a recognized utterance to the gather that is
+// listening. It returns false if no gather is listening or a previous
+// utterance has not been consumed yet. The caller must hold state.mu.
+func (r *CallRunner) sendSpeechLocked(transcript string, confidence float64) bool {
+	if !r.gathering {
+		return false
+	}
+	return notify(r.engine, r.speechCh, speechInput{transcript: transcript, confidence: confidence})
+}
+
+// stopGathering stops listening for speech and drops an utterance the gather
+// did not read, so it cannot complete a later gather
+func (r *CallRunner) stopGathering() {
+	r.state.mu.Lock()
+	defer r.state.mu.Unlock()
+	r.gathering = false
 	select {
-	case r.urlUpdateCh <- newURL:
+	case <-r.speechCh:
 	default:
 	}
 }
 
+// SendSilence signals that the caller stopped speaking. It returns false if
+// silence is already pending.
+func (r *CallRunner) SendSilence() bool {
+	return notify(r.engine, r.silenceCh, struct{}{})
+}
+
+// UpdateURL signals the runner to interrupt current execution and fetch new TwiML from the updated URL
+func (r *CallRunner) UpdateURL(newURL string) {
+	notify(r.engine, r.urlUpdateCh, newURL)
+}
+
 func (r *CallRunner) updateStatus(status model.CallStatus) {
 	r.state.mu.Lock()
 	defer r.state.mu.Unlock()
@@ -2107,11 +2824,7 @@ func (r *CallRunner) recordError(err error) {
 func (r *CallRunner) addCallEvent(eventType string, detail map[string]any) {
 	r.state.mu.Lock()
 	defer r.state.mu.Unlock()
-	r.call.Timeline = append(r.call.Timeline, model.NewEvent(
-		r.clock.Now(),
-		eventType,
-		detail,
-	))
+	r.engine.addCallEventLocked(r.state, r.call, eventType, detail)
 }
 
 func (r *CallRunner) trackCallTwiML(verb any) {
@@ -2142,6 +2855,9 @@ func (r *CallRunner) buildCallForm() url.Values {
 	if r.call.SIPDomainSID != "" {
 		form.Set("SipDomainSid", r.call.SIPDomainSID)
 	}
+	if r.call.AnsweredBy != "" {
+		form.Set("AnsweredBy", r.call.AnsweredBy)
+	}
 	// Add custom variables
 	for k, v := range r.call.Variables {
 		form.Set(k, v)
@@ -2150,6 +2866,48 @@ func (r *CallRunner) buildCallForm() url.Values {
 	return form
 }
 
+// postCallback sends a signed notification webhook whose response body is
+// ignored. Non-2xx responses are returned as errors.
+func (r *CallRunner) postCallback(ctx context.Context, method, targetURL string, form url.Values) error {
+	return r.engine.postCallback(r.requestContext(ctx), r.state, method, targetURL, form)
+}
+
+// requestContext tags a webhook request with the call it is made for
+func (r *CallRunner) requestContext(ctx context.Context) context.Context {
+	return r.engine.callRequestContext(ctx, r.state, r.call)
+}
+
+// executeMessage sends a text from within a voice call. From and To default
+// to the Twilio number and the other party of the call.
+func (r *CallRunner) executeMessage(ctx context.Context, message *twiml.Message, currentTwimlDocumentURL string, terminated *bool) error {
+	r.trackCallTwiML(message)
+
+	r.state.mu.RLock()
+	defaultFrom, defaultTo := messageSendDefaults(r.call)
+	r.state.mu.RUnlock()
+
+	msg, err := r.engine.sendTwiMLMessage(r.state, message, defaultFrom, defaultTo, messageDirectionOutboundCall, currentTwimlDocumentURL, &r.call.SID)
+	if err != nil {
+		r.addCallEvent("message.error", map[string]any{"error": err.Error()})
+		r.recordError(err)
+		return nil
+	}
+	r.addCallEvent("message.sent", map[string]any{
+		"message_sid": msg.SID,
+		"from":        msg.From,
+		"to":          msg.To,
+	})
+
+	if message.Action == "" {
+		return nil
+	}
+	*terminated = true
+	form := url.Values{}
+	form.Set("SmsSid", string(msg.SID))
+	form.Set("SmsStatus", string(model.MessageQueued))
+	return r.executeActionCallback(ctx, message.Method, message.Action, form, currentTwimlDocumentURL, false)
+}
+
 // executeActionCallback calls an action URL with the provided form parameters
 func (r *CallRunner) executeActionCallback(ctx context.Context, actionMethod, actionURL string, form url.Values, currentTwimlDocumentURL string, skipTwimlExecution bool) error {
 	if actionURL == "" {
@@ -2167,6 +2925,9 @@ func (r *CallRunner) executeActionCallback(ctx context.Context, actionMethod, ac
 	}
 
 	resp, err := r.fetchTwiML(ctx, actionMethod, resolvedURL, form)
+	if err != nil {
+		resp, err = r.fetchFallbackTwiML(ctx, resolvedURL, form, err)
+	}
 	if err != nil {
 		return err
 	}
@@ -2202,11 +2963,7 @@ func (r *CallRunner) removeFromQueue(queue *model.Queue) {
 	for i, sid := range queue.Members {
 		if sid == r.call.SID {
 			queue.Members = append(queue.Members[:i], queue.Members[i+1:]...)
-			queue.Timeline = append(queue.Timeline, model.NewEvent(
-				r.clock.Now(),
-				"member.left",
-				map[string]any{"call_sid": r.call.SID},
-			))
+			r.engine.addQueueEventLocked(r.state, queue, "member.left", map[string]any{"call_sid": r.call.SID})
 			break
 		}
 	}
@@ -2225,32 +2982,24 @@ func (r *CallRunner) removeFromConference(conf *model.Conference, currentTwimlDo
 	for i, sid := range conf.Participants {
 		if sid == r.call.SID {
 			conf.Participants = append(conf.Participants[:i], conf.Participants[i+1:]...)
-			conf.Timeline = append(conf.Timeline, model.NewEvent(
-				r.clock.Now(),
-				"participant.left",
-				map[string]any{
-					"call_sid":               r.call.SID,
-					"end_conference_on_exit": endConferenceOnExit,
-				},
-			))
+			r.engine.addConferenceEventLocked(r.state, conf, "participant.left", map[string]any{
+				"call_sid":               r.call.SID,
+				"end_conference_on_exit": endConferenceOnExit,
+			})
 
 			// Send participant-leave callback if configured
 			if conf.StatusCallback != "" {
 				if r.engine.shouldSendConferenceStatusCallback(conf, "participant-leave") {
 					// Queue the callback for serial execution
 					callSID := r.call.SID
-					conf.CallbackQueue <- func() {
+					r.engine.enqueue(conf.CallbackQueue, func() {
 						r.engine.sendConferenceStatusCallback(r.state, conf, "participant-leave", &callSID, currentTwimlDocumentURL)
-					}
+					})
 				} else {
-					conf.Timeline = append(conf.Timeline, model.NewEvent(
-						r.clock.Now(),
-						"webhook.conference_status_callback_skipped",
-						map[string]any{
-							"event":    "participant-leave",
-							"call_sid": r.call.SID,
-						},
-					))
+					r.engine.addConferenceEventLocked(r.state, conf, "webhook.conference_status_callback_skipped", map[string]any{
+						"event":    "participant-leave",
+						"call_sid": r.call.SID,
+					})
 				}
 			}
 
@@ -2265,11 +3014,7 @@ func (r *CallRunner) removeFromConference(conf *model.Conference, currentTwimlDo
 				if endConferenceOnExit && len(conf.Participants) > 0 {
 					reason = "end_conference_on_exit"
 				}
-				conf.Timeline = append(conf.Timeline, model.NewEvent(
-					now,
-					"conference.ended",
-					map[string]any{"reason": reason},
-				))
+				r.engine.addConferenceEventLocked(r.state, conf, "conference.ended", map[string]any{"reason": reason})
 				conferenceEnded = true
 			}
 			break
@@ -2280,23 +3025,19 @@ func (r *CallRunner) removeFromConference(conf *model.Conference, currentTwimlDo
 	if conferenceEnded && conf.StatusCallback != "" {
 		if r.engine.shouldSendConferenceStatusCallback(conf, "conference-end") {
 			// Queue the callback for serial execution
-			conf.CallbackQueue <- func() {
+			r.engine.enqueue(conf.CallbackQueue, func() {
 				r.engine.sendConferenceStatusCallback(r.state, conf, "conference-end", nil, currentTwimlDocumentURL)
-			}
+			})
 		} else {
-			conf.Timeline = append(conf.Timeline, model.NewEvent(
-				r.clock.Now(),
-				"webhook.conference_status_callback_skipped",
-				map[string]any{
-					"event": "conference-end",
-				},
-			))
+			r.engine.addConferenceEventLocked(r.state, conf, "webhook.conference_status_callback_skipped", map[string]any{
+				"event": "conference-end",
+			})
 		}
 	}
 	if conferenceEnded {
 		// Close the callback queue after the conference ends
 		// This allows the worker goroutine to exit cleanly
-		close(conf.CallbackQueue)
+		r.engine.closeQueue(conf.CallbackQueue)
 	}
 
 	r.call.CurrentEndpoint = ""
@@ -2361,12 +3102,7 @@ func (r *CallRunner) invokeRecordingCallback(ctx context.Context, dial *twiml.Di
 	if cnf != nil {
 		recordingForm.Set("ConferenceSid", string(cnf.SID))
 	}
-	// Use baseURL if set, otherwise use the default Twilio URL
-	recordingURL := fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Recordings/%s", r.call.AccountSID, recordingSID)
-	if r.engine.baseURL != "" {
-		recordingURL = fmt.Sprintf("%s/Accounts/%s/Recordings/%s", r.engine.baseURL, r.call.AccountSID, recordingSID)
-	}
-	recordingForm.Set("RecordingUrl", recordingURL)
+	recordingForm.Set("RecordingUrl", r.recordingURL(recordingSID))
 	recordingForm.Set("RecordingStatus", recording.Status)
 	recordingForm.Set("RecordingDuration", fmt.Sprintf("%d", recording.Duration))
 	recordingForm.Set("RecordingStartTime", recordingStartTime.Format("Mon, 02 Jan 2006 15:04:05 -0700"))
@@ -0,0 +1,274 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const (
+	// settlePollInterval is how often Settle checks whether the engine is idle
+	settlePollInterval = 200 * time.Microsecond
+	// defaultSettleTimeout bounds how long AdvanceUntilIdle waits for each step to settle
+	defaultSettleTimeout = 5 * time.Second
+)
+
+// busyTracker counts the tracked goroutines of the engine that are working:
+// call runners, callback queue workers and other background work. A goroutine
+// stops counting while it is parked waiting on channels, and counts again as
+// soon as one of them is signalled.
+type busyTracker struct {
+	mu     sync.Mutex
+	busy   int
+	parked map[*parking]struct{}
+}
+
+// parking is a goroutine waiting for one of a set of channels
+type parking struct {
+	chans []uintptr
+}
+
+// goTracked runs fn in a new goroutine that Settle waits for
+func (e *EngineImpl) goTracked(fn func()) {
+	t := &e.busy
+	t.mu.Lock()
+	t.busy++
+	t.mu.Unlock()
+
+	go func() {
+		defer func() {
+			t.mu.Lock()
+			t.busy--
+			t.mu.Unlock()
+		}()
+		fn()
+	}()
+}
+
+// park marks the calling tracked goroutine idle until one of chans is
+// signalled. Call it right before blocking to receive from chans; a goroutine
+// with a channel that is already ready stays busy. Nil channels are ignored.
+// Every send on or close of the channels must go through signal. It returns
+// the parking, or nil if the goroutine stayed busy.
+func (e *EngineImpl) park(chans ...any) *parking {
+	p := &parking{}
+	values := make([]reflect.Value, 0, len(chans))
+	for _, ch := range chans {
+		v := reflect.ValueOf(ch)
+		if !v.IsValid() || v.IsNil() {
+			continue
+		}
+		values = append(values, v)
+		p.chans = append(p.chans, v.Pointer())
+	}
+
+	t := &e.busy
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if channelReady(values) {
+		return nil
+	}
+	t.busy--
+	t.parked[p] = struct{}{}
+	return p
+}
+
+// unpark marks a goroutine that stopped waiting for another reason, such as
+// its context being done, busy again. It does nothing if a channel of the
+// parking was already signalled.
+func (e *EngineImpl) unpark(p *parking) {
+	if p == nil {
+		return
+	}
+	t := &e.busy
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.parked[p]; ok {
+		delete(t.parked, p)
+		t.busy++
+	}
+}
+
+// channelReady reports whether a receive from any of chans would not block.
+// It must be called with the tracker locked so no value can arrive while the
+// channels are checked, which makes the non-blocking receive only ever
+// succeed on a closed channel.
+func channelReady(chans []reflect.Value) bool {
+	cases := make([]reflect.SelectCase, 0, len(chans)+1)
+	for _, v := range chans {
+		if v.Len() > 0 {
+			return true
+		}
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: v})
+	}
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+	chosen, _, _ := reflect.Select(cases)
+	return chosen < len(chans)
+}
+
+// signal marks the goroutines parked on ch busy and then runs send, which
+// sends on or closes ch without blocking
+func (e *EngineImpl) signal(ch any, send func()) {
+	ptr := reflect.ValueOf(ch).Pointer()
+	t := &e.busy
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for p := range t.parked {
+		for _, c := range p.chans {
+			if c == ptr {
+				delete(t.parked, p)
+				t.busy++
+				break
+			}
+		}
+	}
+	send()
+}
+
+// notify sends v on a buffered channel unless it is full. It reports whether
+// v was sent.
+func notify[T any](e *EngineImpl, ch chan T, v T) bool {
+	sent := false
+	e.signal(ch, func() {
+		select {
+		case ch <- v:
+			sent = true
+		default:
+		}
+	})
+	return sent
+}
+
+// deliver sends v on a buffered channel, waiting while it is full until done
+// is closed. It reports whether v was sent.
+func deliver[T any](e *EngineImpl, ch chan T, v T, done <-chan struct{}) bool {
+	if notify(e, ch, v) {
+		return true
+	}
+	// The channel is full, so its receiver is busy and not parked
+	select {
+	case ch <- v:
+		return true
+	case <-done:
+		return false
+	}
+}
+
+// enqueue adds fn to a call or conference callback queue
+func (e *EngineImpl) enqueue(queue chan func(), fn func()) {
+	deliver(e, queue, fn, nil)
+}
+
+// closeQueue closes a call or conference callback queue, letting its worker exit
+func (e *EngineImpl) closeQueue(queue chan func()) {
+	e.signal(queue, func() {
+		close(queue)
+	})
+}
+
+// runQueue runs the callbacks of a queue serially until it is closed
+func (e *EngineImpl) runQueue(queue chan func()) {
+	for {
+		e.park(queue)
+		fn, ok := <-queue
+		if !ok {
+			return
+		}
+		fn()
+	}
+}
+
+// after is the tracked counterpart of Clock.After: the channel is signalled
+// so a goroutine parked on it is busy again once the timer fires. Call stop
+// once the timer is no longer waited for, so AdvanceUntilIdle does not move
+// the clock to it.
+func (e *EngineImpl) after(clock Clock, d time.Duration) (ch <-chan time.Time, stop func()) {
+	c := make(chan time.Time, 1)
+	t := clock.AfterFunc(d, func() {
+		now := clock.Now()
+		e.signal(c, func() {
+			c <- now
+		})
+	})
+	return c, func() { t.Stop() }
+}
+
+// sleep parks the calling tracked goroutine until d passes on clock. It
+// returns false if ctx is done first.
+func (e *EngineImpl) sleep(ctx context.Context, clock Clock, d time.Duration) bool {
+	timer, stop := e.after(clock, d)
+	defer stop()
+	p := e.park(timer)
+	select {
+	case <-ctx.Done():
+		e.unpark(p)
+		return false
+	case <-timer:
+		return true
+	}
+}
+
+// Settle blocks until every call runner and background goroutine of the engine
+// is parked waiting on a clock timer or external input, such as digits or a
+// hangup. Callbacks being run, including webhooks awaiting a response, keep
+// the engine busy. It returns the context's error if the engine does not settle
+// before the context is done.
+func (e *EngineImpl) Settle(ctx context.Context) error {
+	for !e.idle() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(settlePollInterval):
+		}
+	}
+	return nil
+}
+
+// AdvanceUntilIdle steps the manual clock from timer to timer, settling the
+// engine after each step, until no timer is due within max. With other clocks it
+// only settles the engine. It returns an error if a step does not settle.
+func (e *EngineImpl) AdvanceUntilIdle(max time.Duration) error {
+	settle := func() error {
+		ctx, cancel := context.WithTimeout(e.ctx, defaultSettleTimeout)
+		defer cancel()
+		return e.Settle(ctx)
+	}
+	if err := settle(); err != nil {
+		return err
+	}
+
+	mc, ok := e.defaultClock.(*ManualClock)
+	if !ok {
+		return nil
+	}
+	deadline := mc.Now().Add(max)
+	for e.ctx.Err() == nil {
+		next, ok := mc.NextTimer()
+		if !ok || next.After(deadline) {
+			return nil
+		}
+		if next.After(mc.Now()) {
+			mc.AdvanceTo(next)
+		} else {
+			// Timers created with no delay are due now
+			mc.Advance(0)
+		}
+		if err := settle(); err != nil {
+			return err
+		}
+	}
+	return e.ctx.Err()
+}
+
+// idle reports whether every tracked goroutine is parked
+func (e *EngineImpl) idle() bool {
+	t := &e.busy
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.busy <= 0
+}
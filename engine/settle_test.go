@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/sprucehealth/twimulator/model"
+)
+
+// settleRoutes gather a PIN that times out after 5 seconds
+var settleRoutes = map[string]string{
+	"http://test/answer": `<Response><Gather timeout="5" numDigits="4" action="http://test/digits"><Say>Enter your PIN</Say></Gather><Say>Goodbye</Say></Response>`,
+}
+
+func TestSettle(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, settleRoutes)
+
+	call := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/answer"))
+	settle(t, e)
+	if err := e.AnswerCall(subAccount.SID, call.SID); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	got, _ := e.GetCallState(subAccount.SID, call.SID)
+	if !hasEvent(got, "twiml.gather") {
+		t.Fatal("expected the call to be gathering once settled")
+	}
+
+	if err := e.SendDigits(subAccount.SID, call.SID, "1234"); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	calls := mock.GetCallsTo("http://test/digits")
+	if len(calls) != 1 || calls[0].Form.Get("Digits") != "1234" {
+		t.Fatalf("expected the action with the digits once settled, got %+v", calls)
+	}
+	if got, _ := e.GetCallState(subAccount.SID, call.SID); got.Status != model.CallCompleted {
+		t.Errorf("expected the call completed, got %s", got.Status)
+	}
+}
+
+func TestAdvanceUntilIdle(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, settleRoutes)
+
+	call := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/answer"))
+	settle(t, e)
+	if err := e.AnswerCall(subAccount.SID, call.SID); err != nil {
+		t.Fatal(err)
+	}
+	start := e.Clock().Now()
+
+	// The gather times out after 5 seconds and the call says goodbye
+	if err := e.AdvanceUntilIdle(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := e.GetCallState(subAccount.SID, call.SID)
+	if got.Status != model.CallCompleted || !hasEvent(got, "gather.timeout") {
+		t.Fatalf("expected the gather to time out and the call to complete, got %s", got.Status)
+	}
+	if len(mock.GetCallsTo("http://test/digits")) != 0 {
+		t.Error("expected no action without digits")
+	}
+	if elapsed := e.Clock().Now().Sub(start); elapsed < 5*time.Second || elapsed > time.Minute {
+		t.Errorf("expected the clock to step past the gather timeout, moved %s", elapsed)
+	}
+}
+
+func TestAdvanceUntilIdleSkipsStoppedTimers(t *testing.T) {
+	e, _, subAccount := newTestEngine(t, map[string]string{
+		"http://test/answer":    `<Response><Dial action="http://test/dial-done"><Number>+15553334444</Number></Dial></Response>`,
+		"http://test/dial-done": `<Response><Gather finishOnKey="#" action="http://test/pin"/></Response>`,
+	})
+	sid := startCall(t, e, subAccount.SID).SID
+	children := childCalls(t, e, subAccount.SID, sid)
+	answerCall(t, e, subAccount.SID, children[0])
+	if err := e.Hangup(subAccount.SID, children[0]); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	sendDigits(t, e, subAccount.SID, sid, "1#")
+	if got, _ := e.GetCallState(subAccount.SID, sid); got.Status != model.CallCompleted {
+		t.Fatalf("expected the call to complete, got %s", got.Status)
+	}
+
+	// The ring timeouts, the dial time limit and the gather timeout were stopped
+	// when the call moved on, so there is nothing left to advance to
+	start := e.Clock().Now()
+	if err := e.AdvanceUntilIdle(24 * time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := e.Clock().Now().Sub(start); elapsed != 0 {
+		t.Errorf("expected the clock to stay put, moved %s", elapsed)
+	}
+}
+
+func TestSettleWaitsForCallbacks(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, settleRoutes)
+	release := make(chan struct{})
+	answer := mock.ResponseFunc
+	mock.ResponseFunc = func(targetURL string, form url.Values) (int, []byte, http.Header, error) {
+		if targetURL == "http://test/status" {
+			<-release
+		}
+		return answer(targetURL, form)
+	}
+
+	params := newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/answer")
+	params.SetStatusCallback("http://test/status")
+	params.SetStatusCallbackEvent([]string{"answered"})
+	call := mustCreateCall(t, e, params)
+	settle(t, e)
+	if err := e.AnswerCall(subAccount.SID, call.SID); err != nil {
+		t.Fatal(err)
+	}
+
+	// The status callback is in flight until the webhook responds
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := e.Settle(ctx); err == nil {
+		t.Fatal("expected the engine to stay busy while a callback is in flight")
+	}
+	close(release)
+	settle(t, e)
+	if n := len(mock.GetCallsTo("http://test/status")); n != 1 {
+		t.Errorf("expected one status callback, got %d", n)
+	}
+}
@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/twilio/twilio-go/client"
+
+	"github.com/sprucehealth/twimulator/engine"
+	"github.com/sprucehealth/twimulator/httpstub"
+)
+
+func TestWebhookSignatures(t *testing.T) {
+	mock := httpstub.NewMockWebhookClient()
+	e := engine.NewEngine(engine.WithManualClock(), engine.WithWebhookClient(mock))
+	defer e.Close()
+
+	subAccount := createTestSubAccount(t, e, "Signed")
+	mustProvisionNumbers(t, e, subAccount.SID, "+15550001111")
+
+	params := newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://example.com/voice")
+	params.SetStatusCallback("http://example.com/status")
+	params.SetStatusCallbackEvent([]string{"initiated", "ringing", "answered", "completed"})
+	call := mustCreateCall(t, e, params)
+
+	settle(t, e)
+	if err := e.AnswerCall(subAccount.SID, call.SID); err != nil {
+		t.Fatal(err)
+	}
+	e.Advance(time.Second)
+	settle(t, e)
+
+	if len(mock.Calls) == 0 {
+		t.Fatal("expected webhook calls")
+	}
+	validator := client.NewRequestValidator(subAccount.AuthToken)
+	for _, c := range mock.Calls {
+		signature := c.Headers.Get(httpstub.SignatureHeader)
+		if signature == "" {
+			t.Fatalf("missing signature on request to %s", c.URL)
+		}
+		params := make(map[string]string, len(c.Form))
+		for k := range c.Form {
+			params[k] = c.Form.Get(k)
+		}
+		if !validator.Validate(c.URL, params, signature) {
+			t.Errorf("invalid signature on request to %s", c.URL)
+		}
+	}
+}
+
+func TestWebhookCorruptedSignatures(t *testing.T) {
+	mock := httpstub.NewMockWebhookClient()
+	e := engine.NewEngine(
+		engine.WithManualClock(),
+		engine.WithWebhookClient(mock),
+		engine.WithCorruptedSignatures(),
+	)
+	defer e.Close()
+
+	subAccount := createTestSubAccount(t, e, "Corrupted")
+	mustProvisionNumbers(t, e, subAccount.SID, "+15550001111")
+
+	call := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://example.com/voice"))
+	settle(t, e)
+	if err := e.AnswerCall(subAccount.SID, call.SID); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	calls := mock.GetCallsTo("http://example.com/voice")
+	if len(calls) == 0 {
+		t.Fatal("expected voice webhook")
+	}
+	signature := calls[0].Headers.Get(httpstub.SignatureHeader)
+	if signature == "" {
+		t.Fatal("expected a signature header even when corrupted")
+	}
+	if httpstub.ValidateSignature(subAccount.AuthToken, calls[0].URL, calls[0].Form, signature) {
+		t.Error("expected corrupted signature to fail validation")
+	}
+}
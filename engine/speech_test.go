@@ -0,0 +1,153 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sprucehealth/twimulator/engine"
+)
+
+// speechRoutes answer calls with gather and hang up from its action
+func speechRoutes(gather string) map[string]string {
+	return map[string]string{
+		"http://test/answer": `<?xml version="1.0" encoding="UTF-8"?><Response>` + gather + `</Response>`,
+		"http://test/route":  `<?xml version="1.0" encoding="UTF-8"?><Response><Hangup/></Response>`,
+	}
+}
+
+func TestGatherSpeechAutoTimeout(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, speechRoutes(`<Gather input="speech" speechTimeout="auto" action="http://test/route" partialResultCallback="http://test/partial"><Say>How can I help?</Say></Gather>`))
+	call := startCall(t, e, subAccount.SID)
+
+	if err := e.SendSpeech(call.AccountSID, call.SID, "billing question", 0.92); err != nil {
+		t.Fatalf("send speech failed: %v", err)
+	}
+	settle(t, e)
+
+	partials := mock.GetCallsTo("http://test/partial")
+	if len(partials) != 2 {
+		t.Fatalf("expected 2 partial results, got %d", len(partials))
+	}
+	if got := partials[1].Form.Get("StableSpeechResult"); got != "billing" {
+		t.Errorf("expected stable result 'billing', got %q", got)
+	}
+	if got := partials[1].Form.Get("UnstableSpeechResult"); got != "question" {
+		t.Errorf("expected unstable result 'question', got %q", got)
+	}
+	if got := partials[1].Form.Get("SequenceNumber"); got != "2" {
+		t.Errorf("expected sequence number 2, got %q", got)
+	}
+	if len(mock.GetCallsTo("http://test/route")) != 0 {
+		t.Fatal("action should wait for the end of speech")
+	}
+
+	e.Advance(time.Second)
+	settle(t, e)
+
+	actions := mock.GetCallsTo("http://test/route")
+	if len(actions) != 1 {
+		t.Fatalf("expected 1 action callback, got %d", len(actions))
+	}
+	if got := actions[0].Form.Get("SpeechResult"); got != "billing question" {
+		t.Errorf("expected SpeechResult 'billing question', got %q", got)
+	}
+	if got := actions[0].Form.Get("Confidence"); got != "0.92" {
+		t.Errorf("expected Confidence 0.92, got %q", got)
+	}
+	if actions[0].Form.Get("Digits") != "" {
+		t.Error("speech gather should not post Digits")
+	}
+}
+
+func TestGatherSpeechNumericTimeout(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, speechRoutes(`<Gather input="dtmf speech" speechTimeout="3" timeout="10" action="http://test/route"></Gather>`))
+	call := startCall(t, e, subAccount.SID)
+
+	if err := e.SendSpeech(call.AccountSID, call.SID, "agent", 0.5); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	e.Advance(2 * time.Second)
+	settle(t, e)
+
+	// More speech before the silence window closes extends the result
+	if err := e.SendSpeech(call.AccountSID, call.SID, "please", 0.8); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	e.Advance(2 * time.Second)
+	settle(t, e)
+	if len(mock.GetCallsTo("http://test/route")) != 0 {
+		t.Fatal("action should not fire before speechTimeout of silence")
+	}
+
+	e.Advance(time.Second)
+	settle(t, e)
+	actions := mock.GetCallsTo("http://test/route")
+	if len(actions) != 1 {
+		t.Fatalf("expected 1 action callback, got %d", len(actions))
+	}
+	if got := actions[0].Form.Get("SpeechResult"); got != "agent please" {
+		t.Errorf("expected SpeechResult 'agent please', got %q", got)
+	}
+}
+
+func TestGatherDTMFIgnoresSpeech(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, speechRoutes(`<Gather input="dtmf" numDigits="1" action="http://test/route"></Gather>`))
+	call := startCall(t, e, subAccount.SID)
+
+	if err := e.SendSpeech(call.AccountSID, call.SID, "operator", 0.9); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	if err := e.SendDigits(call.AccountSID, call.SID, "2"); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	actions := mock.GetCallsTo("http://test/route")
+	if len(actions) != 1 {
+		t.Fatalf("expected 1 action callback, got %d", len(actions))
+	}
+	if actions[0].Form.Get("Digits") != "2" || actions[0].Form.Get("SpeechResult") != "" {
+		t.Errorf("expected only Digits=2, got %v", actions[0].Form)
+	}
+
+	got, _ := e.GetCallState(call.AccountSID, call.SID)
+	ignored := false
+	for _, event := range got.Timeline {
+		if event.Type == "gather.speech_ignored" {
+			ignored = true
+		}
+	}
+	if !ignored {
+		t.Error("expected gather.speech_ignored event")
+	}
+}
+
+func TestSpeechOutsideGather(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, speechRoutes(`<Pause length="5"/><Gather input="speech" timeout="3" action="http://test/route"></Gather>`), engine.WithTimedMedia())
+	call := startCall(t, e, subAccount.SID)
+
+	// Nobody is listening during the pause, so the speech is rejected
+	// instead of completing the gather that follows
+	if err := e.SendSpeech(call.AccountSID, call.SID, "too early", 0.9); err == nil {
+		t.Fatal("expected speech outside a gather to fail")
+	}
+	e.Advance(5 * time.Second)
+	settle(t, e)
+	e.Advance(3 * time.Second)
+	settle(t, e)
+
+	if n := len(mock.GetCallsTo("http://test/route")); n != 0 {
+		t.Errorf("expected the gather to time out without speech, got %d action callbacks", n)
+	}
+	got, _ := e.GetCallState(call.AccountSID, call.SID)
+	if !hasEvent(got, "gather.timeout") || hasEvent(got, "gather.speech_received") {
+		t.Error("expected the gather to time out without the early speech")
+	}
+}
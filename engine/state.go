@@ -0,0 +1,438 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/sprucehealth/twimulator/model"
+)
+
+// savedStateVersion is the version of the format written by SaveState
+const savedStateVersion = 1
+
+// savedState is the document written by SaveState
+type savedState struct {
+	Version int       `json:"version"`
+	SavedAt time.Time `json:"saved_at"`
+	// Accounts are encoded one at a time while each is locked
+	Accounts []json.RawMessage `json:"accounts"`
+}
+
+// savedAccount holds every resource of a subaccount
+type savedAccount struct {
+	Account              *model.SubAccount                                  `json:"account"`
+	IncomingNumbers      []*incomingNumber                                  `json:"incoming_numbers"`
+	Applications         []*applicationRecord                               `json:"applications"`
+	Addresses            []*model.Address                                   `json:"addresses"`
+	SigningKeys          []*model.SigningKey                                `json:"signing_keys"`
+	SipDomains           []*model.SipDomain                                 `json:"sip_domains"`
+	SipCredentialLists   []*model.SipCredentialList                         `json:"sip_credential_lists"`
+	SipCredentials       []*model.SipCredential                             `json:"sip_credentials"`
+	SipAuthCallsMappings []*model.SipAuthCallsCredentialListMapping         `json:"sip_auth_calls_mappings"`
+	SipAuthRegMappings   []*model.SipAuthRegistrationsCredentialListMapping `json:"sip_auth_registrations_mappings"`
+	Calls                []*model.Call                                      `json:"calls"`
+	Queues               []*model.Queue                                     `json:"queues"`
+	Conferences          []*model.Conference                                `json:"conferences"`
+	Recordings           []*model.Recording                                 `json:"recordings"`
+	Transcriptions       []*model.Transcription                             `json:"transcriptions"`
+	Messages             []*model.Message                                   `json:"messages"`
+	// Participant states by conference SID and call SID
+	ParticipantStates map[model.SID]map[model.SID]*model.ParticipantState `json:"participant_states,omitempty"`
+	// Simulated outcomes set by the test
+	MessageOutcomes      map[string]savedMessageOutcome         `json:"message_outcomes,omitempty"`
+	TranscriptionResults map[model.SID]savedTranscriptionResult `json:"transcription_results,omitempty"`
+	CallRecordings       map[model.SID]model.SID                `json:"call_recordings,omitempty"`
+	CallVoicemails       map[model.SID]model.SID                `json:"call_voicemails,omitempty"`
+	Errors               []string                               `json:"errors,omitempty"`
+}
+
+type savedMessageOutcome struct {
+	Status    model.MessageStatus `json:"status"`
+	ErrorCode int                 `json:"error_code,omitempty"`
+}
+
+type savedTranscriptionResult struct {
+	Text   string `json:"text"`
+	Status string `json:"status"`
+}
+
+// WithStateFrom restores the subaccounts saved by SaveState when the engine is
+// created. Calls, conferences and messages that were in flight are restored as
+// ended. A failure to restore leaves the engine empty and is reported by
+// StateFromError.
+func WithStateFrom(r io.Reader) EngineOption {
+	return func(e *EngineImpl) {
+		e.stateFrom = r
+	}
+}
+
+// SaveState writes every subaccount and its resources as JSON so that they can
+// be restored with WithStateFrom or LoadState
+func (e *EngineImpl) SaveState(w io.Writer) error {
+	e.subAccountsMu.RLock()
+	states := make([]*subAccountState, 0, len(e.subAccounts))
+	for _, state := range e.subAccounts {
+		states = append(states, state)
+	}
+	e.subAccountsMu.RUnlock()
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].account.CreatedAt.Before(states[j].account.CreatedAt) ||
+			(states[i].account.CreatedAt.Equal(states[j].account.CreatedAt) && states[i].account.SID < states[j].account.SID)
+	})
+
+	doc := &savedState{
+		Version:  savedStateVersion,
+		SavedAt:  e.defaultClock.Now(),
+		Accounts: make([]json.RawMessage, 0, len(states)),
+	}
+	for _, state := range states {
+		b, err := e.encodeAccount(state)
+		if err != nil {
+			return err
+		}
+		doc.Accounts = append(doc.Accounts, b)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	return nil
+}
+
+func (e *EngineImpl) encodeAccount(state *subAccountState) (json.RawMessage, error) {
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	now := state.clock.Now()
+	saved := &savedAccount{
+		Account:              state.account,
+		IncomingNumbers:      sortedValues(state.incomingNumbers, func(n *incomingNumber) string { return string(n.SID) }),
+		Applications:         sortedValues(state.applications, func(a *applicationRecord) string { return string(a.SID) }),
+		Addresses:            sortedValues(state.addresses, func(a *model.Address) string { return string(a.SID) }),
+		SigningKeys:          sortedValues(state.signingKeys, func(k *model.SigningKey) string { return k.SID }),
+		SipDomains:           sortedValues(state.sipDomains, func(d *model.SipDomain) string { return string(d.SID) }),
+		SipCredentialLists:   sortedValues(state.sipCredentialLists, func(l *model.SipCredentialList) string { return string(l.SID) }),
+		SipCredentials:       sortedValues(state.sipCredentials, func(c *model.SipCredential) string { return string(c.SID) }),
+		SipAuthCallsMappings: sortedValues(state.sipAuthCallsMappings, func(m *model.SipAuthCallsCredentialListMapping) string { return string(m.SID) }),
+		SipAuthRegMappings:   sortedValues(state.sipAuthRegMappings, func(m *model.SipAuthRegistrationsCredentialListMapping) string { return string(m.SID) }),
+		Queues:               sortedValues(state.queues, func(q *model.Queue) string { return string(q.SID) }),
+		Conferences:          sortedValues(state.conferences, func(c *model.Conference) string { return string(c.SID) }),
+		Transcriptions:       sortedValues(state.transcriptions, func(t *model.Transcription) string { return string(t.SID) }),
+		Messages:             sortedValues(state.messages, func(m *model.Message) string { return string(m.SID) }),
+		ParticipantStates:    state.participantStates,
+		MessageOutcomes:      make(map[string]savedMessageOutcome, len(state.messageOutcomes)),
+		TranscriptionResults: make(map[model.SID]savedTranscriptionResult, len(state.transcriptionResults)),
+		CallRecordings:       state.callRecordings,
+		CallVoicemails:       state.callVoicemails,
+	}
+
+	// Executed TwiML holds parsed verbs for tests and is not saved
+	for _, call := range sortedValues(state.calls, func(c *model.Call) string { return string(c.SID) }) {
+		callCopy := *call
+		callCopy.ExecutedTwiML = nil
+		saved.Calls = append(saved.Calls, &callCopy)
+	}
+	// Active call recordings are saved with their duration so far
+	for _, recording := range sortedValues(state.recordings, func(r *model.Recording) string { return string(r.SID) }) {
+		recordingCopy := *recording
+		if active, ok := state.activeRecordings[recording.SID]; ok {
+			recordingCopy.Duration = active.duration(now)
+		}
+		saved.Recordings = append(saved.Recordings, &recordingCopy)
+	}
+	for to, outcome := range state.messageOutcomes {
+		saved.MessageOutcomes[to] = savedMessageOutcome{Status: outcome.status, ErrorCode: outcome.errorCode}
+	}
+	for sid, result := range state.transcriptionResults {
+		saved.TranscriptionResults[sid] = savedTranscriptionResult{Text: result.text, Status: result.status}
+	}
+	for _, err := range state.errors {
+		saved.Errors = append(saved.Errors, err.Error())
+	}
+
+	b, err := json.Marshal(saved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode account %s: %w", state.account.SID, err)
+	}
+	return b, nil
+}
+
+// sortedValues returns the values of a map ordered by key so that saved state is stable
+func sortedValues[K comparable, V any](m map[K]V, key func(V) string) []V {
+	values := make([]V, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool { return key(values[i]) < key(values[j]) })
+	return values
+}
+
+// LoadState restores the subaccounts written by SaveState. Calls, conferences,
+// messages, recordings and transcriptions that had not finished are ended, since
+// nothing is left running them. Loading fails, without changing the engine, if a
+// saved subaccount already exists or a resource has an unknown status.
+func (e *EngineImpl) LoadState(r io.Reader) error {
+	var doc savedState
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return fmt.Errorf("failed to read state: %w", err)
+	}
+	if doc.Version != savedStateVersion {
+		return fmt.Errorf("unsupported state version %d", doc.Version)
+	}
+
+	states := make([]*subAccountState, 0, len(doc.Accounts))
+	var sids []string
+	for _, b := range doc.Accounts {
+		var saved savedAccount
+		if err := json.Unmarshal(b, &saved); err != nil {
+			return fmt.Errorf("failed to read account: %w", err)
+		}
+		if saved.Account == nil {
+			return fmt.Errorf("account is required")
+		}
+		state, accountSIDs, err := e.restoreAccount(&saved, doc.SavedAt)
+		if err != nil {
+			return fmt.Errorf("failed to restore account %s: %w", saved.Account.SID, err)
+		}
+		states = append(states, state)
+		sids = append(sids, accountSIDs...)
+	}
+
+	e.subAccountsMu.Lock()
+	for _, state := range states {
+		if _, exists := e.subAccounts[state.account.SID]; exists {
+			e.subAccountsMu.Unlock()
+			return fmt.Errorf("account %s already exists", state.account.SID)
+		}
+	}
+	for _, sid := range sids {
+		e.ids.Reserve(sid)
+	}
+	for _, state := range states {
+		e.subAccounts[state.account.SID] = state
+	}
+	e.subAccountsMu.Unlock()
+
+	// Restored resources are dated up to when they were saved, so a manual clock
+	// moves forward to keep the timeline in order
+	if mc, ok := e.defaultClock.(*ManualClock); ok && doc.SavedAt.After(mc.Now()) {
+		mc.AdvanceTo(doc.SavedAt)
+	}
+	return nil
+}
+
+// restoreAccount rebuilds the state of a saved subaccount, ending everything
+// that was in flight at savedAt
+func (e *EngineImpl) restoreAccount(saved *savedAccount, savedAt time.Time) (*subAccountState, []string, error) {
+	state := newSubAccountState(saved.Account, e.defaultClock)
+	endedAt := savedAt
+	// SIDs generated after the restore must not repeat restored SIDs. They are
+	// reserved once the load is accepted.
+	var sids []string
+	reserve := func(sid string) {
+		sids = append(sids, sid)
+	}
+	reserve(string(saved.Account.SID))
+
+	for _, n := range saved.IncomingNumbers {
+		reserve(string(n.SID))
+		state.incomingNumbers[n.PhoneNumber] = n
+	}
+	for _, a := range saved.Applications {
+		reserve(string(a.SID))
+		state.applications[a.SID] = a
+	}
+	for _, a := range saved.Addresses {
+		reserve(string(a.SID))
+		state.addresses[a.SID] = a
+	}
+	for _, k := range saved.SigningKeys {
+		reserve(k.SID)
+		state.signingKeys[k.SID] = k
+	}
+	for _, d := range saved.SipDomains {
+		reserve(string(d.SID))
+		state.sipDomains[d.SID] = d
+	}
+	for _, l := range saved.SipCredentialLists {
+		reserve(string(l.SID))
+		state.sipCredentialLists[l.SID] = l
+	}
+	for _, c := range saved.SipCredentials {
+		reserve(string(c.SID))
+		state.sipCredentials[c.SID] = c
+	}
+	for _, m := range saved.SipAuthCallsMappings {
+		reserve(string(m.SID))
+		state.sipAuthCallsMappings[m.SID] = m
+	}
+	for _, m := range saved.SipAuthRegMappings {
+		reserve(string(m.SID))
+		state.sipAuthRegMappings[m.SID] = m
+	}
+
+	for _, call := range saved.Calls {
+		if !knownCallStatus(call.Status) {
+			return nil, nil, fmt.Errorf("call %s has unknown status %q", call.SID, call.Status)
+		}
+		if call.Variables == nil {
+			call.Variables = make(map[string]string)
+		}
+		if !call.Status.IsTerminal() {
+			from := call.Status
+			call.Status = model.CallCanceled
+			if from == model.CallInProgress || from == model.CallAnswered {
+				call.Status = model.CallCompleted
+			}
+			call.EndedAt = &endedAt
+			call.CurrentEndpoint = ""
+			call.Timeline = append(call.Timeline, model.NewEvent(endedAt, "call.restored", map[string]any{
+				"from": from,
+				"to":   call.Status,
+			}))
+		}
+		// Terminal calls have no callback worker, so their queue is closed
+		call.CallbackQueue = make(chan func())
+		close(call.CallbackQueue)
+		reserve(string(call.SID))
+		state.calls[call.SID] = call
+	}
+
+	for _, queue := range saved.Queues {
+		// The calls that were waiting have ended
+		queue.Members = []model.SID{}
+		reserve(string(queue.SID))
+		state.queues[queue.Name] = queue
+	}
+	for _, conf := range saved.Conferences {
+		if !knownConferenceStatus(conf.Status) {
+			return nil, nil, fmt.Errorf("conference %s has unknown status %q", conf.SID, conf.Status)
+		}
+		if conf.Status != model.ConferenceCompleted {
+			conf.Status = model.ConferenceCompleted
+			conf.EndedAt = &endedAt
+			conf.Timeline = append(conf.Timeline, model.NewEvent(endedAt, "conference.restored", map[string]any{
+				"participants": conf.Participants,
+			}))
+		}
+		conf.Participants = []model.SID{}
+		conf.CallbackQueue = make(chan func())
+		close(conf.CallbackQueue)
+		reserve(string(conf.SID))
+		state.conferences[conf.Name] = conf
+	}
+	for confSID, participants := range saved.ParticipantStates {
+		state.participantStates[confSID] = participants
+	}
+
+	for _, recording := range saved.Recordings {
+		if recording.Status == RecordingInProgress || recording.Status == RecordingPaused {
+			recording.Status = RecordingCompleted
+		}
+		reserve(string(recording.SID))
+		state.recordings[recording.SID] = recording
+	}
+	for _, transcription := range saved.Transcriptions {
+		if transcription.Status == "in-progress" {
+			transcription.Status = TranscriptionFailed
+			transcription.UpdatedAt = endedAt
+		}
+		reserve(string(transcription.SID))
+		state.transcriptions[transcription.SID] = transcription
+	}
+	for _, msg := range saved.Messages {
+		if !knownMessageStatus(msg.Status) {
+			return nil, nil, fmt.Errorf("message %s has unknown status %q", msg.SID, msg.Status)
+		}
+		if !msg.Status.IsTerminal() {
+			from := msg.Status
+			if msg.Status == model.MessageScheduled {
+				msg.Status = model.MessageCanceled
+			} else {
+				msg.Status = model.MessageFailed
+				msg.ErrorCode = 30008
+				msg.ErrorMessage = messageErrorMessages[30008]
+			}
+			msg.UpdatedAt = endedAt
+			msg.Timeline = append(msg.Timeline, model.NewEvent(endedAt, "message.restored", map[string]any{
+				"from": from,
+				"to":   msg.Status,
+			}))
+		}
+		reserve(string(msg.SID))
+		state.messages[msg.SID] = msg
+	}
+
+	for to, outcome := range saved.MessageOutcomes {
+		state.messageOutcomes[to] = messageOutcome{status: outcome.Status, errorCode: outcome.ErrorCode}
+	}
+	for sid, result := range saved.TranscriptionResults {
+		state.transcriptionResults[sid] = transcriptionResult{text: result.Text, status: result.Status}
+	}
+	for callSID, recordingSID := range saved.CallRecordings {
+		state.callRecordings[callSID] = recordingSID
+	}
+	for callSID, recordingSID := range saved.CallVoicemails {
+		state.callVoicemails[callSID] = recordingSID
+	}
+	for _, msg := range saved.Errors {
+		state.errors = append(state.errors, errors.New(msg))
+	}
+	return state, sids, nil
+}
+
+func knownCallStatus(status model.CallStatus) bool {
+	switch status {
+	case model.CallInitiated, model.CallQueued, model.CallRinging, model.CallInProgress, model.CallAnswered,
+		model.CallCompleted, model.CallBusy, model.CallFailed, model.CallNoAnswer, model.CallCanceled:
+		return true
+	}
+	return false
+}
+
+func knownMessageStatus(status model.MessageStatus) bool {
+	switch status {
+	case model.MessageScheduled, model.MessageQueued, model.MessageSending, model.MessageSent, model.MessageDelivered,
+		model.MessageUndelivered, model.MessageFailed, model.MessageCanceled, model.MessageReceiving, model.MessageReceived:
+		return true
+	}
+	return false
+}
+
+func knownConferenceStatus(status model.ConferenceStatus) bool {
+	switch status {
+	case model.ConferenceCreated, model.ConferenceInProgress, model.ConferenceCompleted:
+		return true
+	}
+	return false
+}
+
+// loadInitialState restores the state given to WithStateFrom
+func (e *EngineImpl) loadInitialState() {
+	if e.stateFrom == nil {
+		return
+	}
+	if err := e.LoadState(e.stateFrom); err != nil {
+		log.Printf("Failed to restore state: %v", err)
+		e.stateFromErr = err
+	}
+	e.stateFrom = nil
+}
+
+// StateFromError returns why the state given to WithStateFrom could not be
+// restored, or nil if it was
+func (e *EngineImpl) StateFromError() error {
+	return e.stateFromErr
+}
@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+
+	"github.com/sprucehealth/twimulator/engine"
+	"github.com/sprucehealth/twimulator/httpstub"
+	"github.com/sprucehealth/twimulator/model"
+)
+
+func TestSaveStateRoundTrip(t *testing.T) {
+	mock := httpstub.NewMockWebhookClient()
+	mock.ResponseFunc = func(targetURL string, form url.Values) (int, []byte, http.Header, error) {
+		if targetURL == "http://test/hold" {
+			return 200, []byte(`<Response><Gather timeout="600"></Gather></Response>`), make(http.Header), nil
+		}
+		return 200, []byte(`<Response><Say>Goodbye</Say></Response>`), make(http.Header), nil
+	}
+	e := engine.NewEngine(engine.WithManualClock(), engine.WithWebhookClient(mock))
+	defer e.Close()
+
+	subAccount := createTestSubAccount(t, e, "Saved")
+	accountSID := string(subAccount.SID)
+	mustProvisionNumbers(t, e, subAccount.SID, "+15550001111")
+	app, err := e.CreateApplication((&twilioopenapi.CreateApplicationParams{}).
+		SetPathAccountSid(accountSID).
+		SetFriendlyName("Clinic").
+		SetVoiceUrl("http://test/voice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.CreateSipDomain((&twilioopenapi.CreateSipDomainParams{}).
+		SetPathAccountSid(accountSID).
+		SetDomainName("clinic.sip.twilio.com")); err != nil {
+		t.Fatal(err)
+	}
+
+	completed := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/answer"))
+	answerCall(t, e, subAccount.SID, completed.SID)
+	inFlight := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15554445555", "http://test/hold"))
+	answerCall(t, e, subAccount.SID, inFlight.SID)
+	e.Advance(30 * time.Second)
+
+	var buf bytes.Buffer
+	if err := e.SaveState(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := engine.NewEngine(engine.WithManualClock(), engine.WithWebhookClient(mock), engine.WithSeed(1), engine.WithStateFrom(&buf))
+	defer restored.Close()
+	if err := restored.StateFromError(); err != nil {
+		t.Fatalf("expected the state to be restored: %v", err)
+	}
+
+	numbers, err := restored.ListIncomingPhoneNumber(&twilioopenapi.ListIncomingPhoneNumberParams{PathAccountSid: &accountSID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(numbers) != 1 || *numbers[0].PhoneNumber != "+15550001111" {
+		t.Fatalf("expected the provisioned number to be restored, got %+v", numbers)
+	}
+
+	snap, err := restored.Snapshot(subAccount.SID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := snap.SubAccounts[subAccount.SID]
+	if len(account.SipDomains) != 1 || account.SipDomains[0].DomainName != "clinic.sip.twilio.com" {
+		t.Errorf("expected the SIP domain to be restored, got %+v", account.SipDomains)
+	}
+	if len(account.Applications) != 1 || account.Applications[0].SID != *app.Sid {
+		t.Errorf("expected the application to be restored, got %+v", account.Applications)
+	}
+	if snap.Timestamp.Before(inFlight.StartAt.Add(30 * time.Second)) {
+		t.Errorf("expected the clock to move to when the state was saved, got %s", snap.Timestamp)
+	}
+
+	if call := snap.Calls[completed.SID]; call == nil || call.Status != model.CallCompleted || !hasEvent(call, "twiml.say") {
+		t.Errorf("expected the completed call and its timeline to be restored, got %+v", call)
+	}
+	call := snap.Calls[inFlight.SID]
+	if call == nil || call.Status != model.CallCompleted || call.EndedAt == nil || !hasEvent(call, "call.restored") {
+		t.Fatalf("expected the in-flight call to be restored as completed, got %+v", call)
+	}
+	if err := restored.Hangup(subAccount.SID, inFlight.SID); err != nil {
+		t.Fatal(err)
+	}
+
+	// New calls do not reuse the SIDs of restored calls
+	next := mustCreateCall(t, restored, newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/answer"))
+	if next.SID[:20] <= inFlight.SID[:20] {
+		t.Errorf("expected a call SID after the restored calls, got %s", next.SID)
+	}
+
+	// Restoring accounts that already exist fails
+	var again bytes.Buffer
+	if err := restored.SaveState(&again); err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.LoadState(&again); err == nil {
+		t.Error("expected an error restoring an existing account")
+	}
+}
+
+func TestLoadStateRejectsUnknownStatus(t *testing.T) {
+	mock := httpstub.NewMockWebhookClient()
+	e := engine.NewEngine(engine.WithManualClock(), engine.WithWebhookClient(mock))
+	defer e.Close()
+
+	subAccount := createTestSubAccount(t, e, "Saved")
+	mustProvisionNumbers(t, e, subAccount.SID, "+15550001111")
+	first := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/answer"))
+	for i := 0; i < 3; i++ {
+		mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/answer"))
+	}
+	settle(t, e)
+	if err := e.Hangup(subAccount.SID, first.SID); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+
+	var buf bytes.Buffer
+	if err := e.SaveState(&buf); err != nil {
+		t.Fatal(err)
+	}
+	corrupted := strings.Replace(buf.String(), `"status": "canceled"`, `"status": "unheard-of"`, 1)
+	if corrupted == buf.String() {
+		t.Fatal("expected a canceled call in the saved state")
+	}
+
+	restored := engine.NewEngine(engine.WithManualClock(), engine.WithWebhookClient(mock))
+	defer restored.Close()
+	if err := restored.LoadState(strings.NewReader(corrupted)); err == nil {
+		t.Fatal("expected an error loading a call with an unknown status")
+	}
+	started := engine.NewEngine(engine.WithManualClock(), engine.WithWebhookClient(mock), engine.WithStateFrom(strings.NewReader(corrupted)))
+	defer started.Close()
+	if err := started.StateFromError(); err == nil {
+		t.Error("expected WithStateFrom to report the rejected state")
+	}
+
+	// The rejected load neither adds the account nor reserves its SIDs
+	if _, err := restored.Snapshot(subAccount.SID); err == nil {
+		t.Error("expected the account not to be restored")
+	}
+	other := createTestSubAccount(t, restored, "Other")
+	mustProvisionNumbers(t, restored, other.SID, "+15550001111")
+	next := mustCreateCall(t, restored, newCreateCallParams(other.SID, "+15550001111", "+15552223333", "http://test/answer"))
+	if next.SID[:20] != first.SID[:20] {
+		t.Errorf("expected SIDs to start over after a rejected load, got %s", next.SID)
+	}
+}
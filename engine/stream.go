@@ -0,0 +1,641 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/sprucehealth/twimulator/model"
+	"github.com/sprucehealth/twimulator/twiml"
+)
+
+const (
+	// streamFrameInterval is the audio duration carried by each media frame
+	streamFrameInterval = 20 * time.Millisecond
+	// streamFrameBytes is 20ms of 8kHz mu-law audio
+	streamFrameBytes = 160
+	// mulawSilence is the mu-law encoding of a zero sample
+	mulawSilence = 0xFF
+	// mulawBytesPerSecond is the playback rate of audio sent back on a bidirectional stream
+	mulawBytesPerSecond = 8000
+)
+
+// streamMessage is a message sent by the WebSocket server on a media stream
+type streamMessage struct {
+	Event     string `json:"event"`
+	StreamSid string `json:"streamSid"`
+	Media     *struct {
+		Payload string `json:"payload"`
+	} `json:"media,omitempty"`
+	Mark *struct {
+		Name string `json:"name"`
+	} `json:"mark,omitempty"`
+}
+
+type pendingMark struct {
+	name string
+	due  time.Time
+}
+
+// mediaStream is a Media Streams WebSocket attached to a call
+type mediaStream struct {
+	sid                  model.SID
+	name                 string
+	url                  string
+	tracks               []string // "inbound" and/or "outbound"
+	params               map[string]string
+	statusCallback       string
+	statusCallbackMethod string
+	bidirectional        bool
+
+	runner *CallRunner
+	conn   *websocket.Conn
+
+	audioCh  chan []byte // caller audio injected with SendStreamAudio
+	dtmfCh   chan string // digits pressed during a bidirectional stream
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+
+	// Owned by the run goroutine
+	seq            int
+	start          time.Time
+	framesSent     int
+	pendingAudio   []byte
+	playbackEnd    time.Time
+	marks          []pendingMark
+	bytesReceived  int
+	serverClosed   bool
+	stopReason     string
+	incoming       chan streamMessage
+	readerFinished chan error
+}
+
+func newMediaStream(r *CallRunner, stream *twiml.Stream, bidirectional bool, currentTwimlDocumentURL string) (*mediaStream, error) {
+	params := make(map[string]string)
+	for _, child := range stream.Children {
+		if p, ok := child.(*twiml.Parameter); ok {
+			params[p.Name] = p.Value
+		}
+	}
+	var tracks []string
+	switch stream.Track {
+	case "outbound_track":
+		tracks = []string{"outbound"}
+	case "both_tracks":
+		tracks = []string{"inbound", "outbound"}
+	default:
+		tracks = []string{"inbound"}
+	}
+	s := &mediaStream{
+		sid:                  r.engine.ids.StreamSID(),
+		name:                 stream.Name,
+		url:                  stream.URL,
+		tracks:               tracks,
+		params:               params,
+		statusCallbackMethod: stream.StatusCallbackMethod,
+		bidirectional:        bidirectional,
+		runner:               r,
+		audioCh:              make(chan []byte, 16),
+		dtmfCh:               make(chan string, 16),
+		stopCh:               make(chan struct{}),
+		done:                 make(chan struct{}),
+		incoming:             make(chan streamMessage, 64),
+		readerFinished:       make(chan error, 1),
+	}
+	if s.name == "" {
+		s.name = string(s.sid)
+	}
+	if stream.StatusCallback != "" {
+		resolved, err := resolveURL(currentTwimlDocumentURL, stream.StatusCallback)
+		if err != nil {
+			return nil, err
+		}
+		s.statusCallback = resolved
+	}
+	return s, nil
+}
+
+// stop asks the stream to close; it is safe to call more than once
+func (s *mediaStream) stop(reason string) {
+	s.runner.engine.signal(s.stopCh, func() {
+		s.stopOnce.Do(func() {
+			s.stopReason = reason
+			close(s.stopCh)
+		})
+	})
+}
+
+// run connects to the WebSocket and exchanges frames until the stream is
+// stopped, the server disconnects, or the call ends
+func (s *mediaStream) run(ctx context.Context) {
+	r := s.runner
+	defer r.engine.signal(s.done, func() {
+		close(s.done)
+	})
+
+	dialCtx, cancel := context.WithTimeout(ctx, r.engine.timeout)
+	conn, _, err := websocket.DefaultDialer.DialContext(dialCtx, s.url, r.engine.signedHeaders(r.state, s.url, nil))
+	cancel()
+	if err != nil {
+		r.addCallEvent("stream.error", map[string]any{
+			"stream_sid": s.sid,
+			"url":        s.url,
+			"error":      err.Error(),
+		})
+		r.recordError(fmt.Errorf("failed to connect media stream to %s: %w", s.url, err))
+		s.sendStatusCallback(ctx, "stream-error", err.Error())
+		return
+	}
+	s.conn = conn
+	defer conn.Close()
+
+	s.start = r.clock.Now()
+	s.playbackEnd = s.start
+	if err := s.sendConnectedAndStart(); err != nil {
+		s.fail(ctx, err)
+		return
+	}
+	r.addCallEvent("stream.started", map[string]any{
+		"stream_sid":    s.sid,
+		"name":          s.name,
+		"url":           s.url,
+		"tracks":        s.tracks,
+		"bidirectional": s.bidirectional,
+		"parameters":    s.params,
+	})
+	s.sendStatusCallback(ctx, "stream-started", "")
+
+	go s.readLoop()
+
+	stopFrame := func() {}
+	defer func() { stopFrame() }()
+	for {
+		var frame <-chan time.Time
+		stopFrame()
+		frame, stopFrame = r.engine.after(r.clock, streamFrameInterval)
+		r.engine.park(r.hangupCh, r.done, s.stopCh, s.readerFinished, s.incoming, s.audioCh, s.dtmfCh, frame)
+		select {
+		case <-ctx.Done():
+			s.finish(ctx, "engine_closed")
+			return
+		case <-r.hangupCh:
+			s.finish(ctx, "call_ended")
+			return
+		case <-r.done:
+			s.finish(ctx, "call_ended")
+			return
+		case <-s.stopCh:
+			s.finish(ctx, s.stopReason)
+			return
+		case err := <-s.readerFinished:
+			s.serverClosed = true
+			s.drainIncoming()
+			reason := "server_closed"
+			if err != nil && !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				r.addCallEvent("stream.error", map[string]any{
+					"stream_sid": s.sid,
+					"error":      err.Error(),
+				})
+			}
+			s.finish(ctx, reason)
+			return
+		case msg := <-s.incoming:
+			if err := s.handleMessage(msg); err != nil {
+				s.fail(ctx, err)
+				return
+			}
+		case audio := <-s.audioCh:
+			s.pendingAudio = append(s.pendingAudio, audio...)
+		case digits := <-s.dtmfCh:
+			if err := s.sendDTMF(digits); err != nil {
+				s.fail(ctx, err)
+				return
+			}
+		case <-frame:
+		}
+		if err := s.catchUp(); err != nil {
+			s.fail(ctx, err)
+			return
+		}
+	}
+}
+
+func (s *mediaStream) readLoop() {
+	for {
+		var msg streamMessage
+		if err := s.conn.ReadJSON(&msg); err != nil {
+			notify(s.runner.engine, s.readerFinished, err)
+			return
+		}
+		if !deliver(s.runner.engine, s.incoming, msg, s.done) {
+			return
+		}
+	}
+}
+
+// drainIncoming handles messages the server sent before closing the socket
+func (s *mediaStream) drainIncoming() {
+	for {
+		select {
+		case msg := <-s.incoming:
+			_ = s.handleMessage(msg)
+		default:
+			return
+		}
+	}
+}
+
+func (s *mediaStream) nextSeq() string {
+	s.seq++
+	return strconv.Itoa(s.seq)
+}
+
+func (s *mediaStream) send(frame map[string]any) error {
+	return s.conn.WriteJSON(frame)
+}
+
+func (s *mediaStream) sendConnectedAndStart() error {
+	if err := s.send(map[string]any{
+		"event":    "connected",
+		"protocol": "Call",
+		"version":  "1.0.0",
+	}); err != nil {
+		return err
+	}
+	r := s.runner
+	return s.send(map[string]any{
+		"event":          "start",
+		"sequenceNumber": s.nextSeq(),
+		"streamSid":      string(s.sid),
+		"start": map[string]any{
+			"streamSid":        string(s.sid),
+			"accountSid":       string(r.call.AccountSID),
+			"callSid":          string(r.call.SID),
+			"tracks":           s.tracks,
+			"customParameters": s.params,
+			"mediaFormat": map[string]any{
+				"encoding":   "audio/x-mulaw",
+				"sampleRate": 8000,
+				"channels":   1,
+			},
+		},
+	})
+}
+
+// catchUp sends every media frame and played mark that is due on the engine clock
+func (s *mediaStream) catchUp() error {
+	now := s.runner.clock.Now()
+	due := int(now.Sub(s.start) / streamFrameInterval)
+	for s.framesSent < due {
+		timestamp := strconv.FormatInt(int64(s.framesSent)*streamFrameInterval.Milliseconds(), 10)
+		chunk := strconv.Itoa(s.framesSent + 1)
+		for _, track := range s.tracks {
+			payload := silence(streamFrameBytes)
+			if track == "inbound" && len(s.pendingAudio) > 0 {
+				n := copy(payload, s.pendingAudio)
+				s.pendingAudio = s.pendingAudio[n:]
+			}
+			if err := s.send(map[string]any{
+				"event":          "media",
+				"sequenceNumber": s.nextSeq(),
+				"streamSid":      string(s.sid),
+				"media": map[string]any{
+					"track":     track,
+					"chunk":     chunk,
+					"timestamp": timestamp,
+					"payload":   base64.StdEncoding.EncodeToString(payload),
+				},
+			}); err != nil {
+				return err
+			}
+		}
+		s.framesSent++
+	}
+	return s.flushMarks(now, false)
+}
+
+// flushMarks echoes marks whose preceding audio has finished playing, or all
+// of them when the audio buffer was cleared
+func (s *mediaStream) flushMarks(now time.Time, all bool) error {
+	remaining := s.marks[:0]
+	for _, m := range s.marks {
+		if !all && m.due.After(now) {
+			remaining = append(remaining, m)
+			continue
+		}
+		if err := s.send(map[string]any{
+			"event":          "mark",
+			"sequenceNumber": s.nextSeq(),
+			"streamSid":      string(s.sid),
+			"mark":           map[string]any{"name": m.name},
+		}); err != nil {
+			return err
+		}
+		s.runner.addCallEvent("stream.mark_sent", map[string]any{
+			"stream_sid": s.sid,
+			"name":       m.name,
+		})
+	}
+	s.marks = remaining
+	return nil
+}
+
+func (s *mediaStream) handleMessage(msg streamMessage) error {
+	r := s.runner
+	now := r.clock.Now()
+	if !s.bidirectional {
+		r.addCallEvent("stream.message_ignored", map[string]any{
+			"stream_sid": s.sid,
+			"event":      msg.Event,
+		})
+		return nil
+	}
+
+	switch msg.Event {
+	case "media":
+		if msg.Media == nil {
+			return fmt.Errorf("media message without media payload")
+		}
+		audio, err := base64.StdEncoding.DecodeString(msg.Media.Payload)
+		if err != nil {
+			return fmt.Errorf("invalid media payload: %w", err)
+		}
+		if s.playbackEnd.Before(now) {
+			s.playbackEnd = now
+		}
+		s.playbackEnd = s.playbackEnd.Add(time.Duration(len(audio)) * time.Second / mulawBytesPerSecond)
+		s.bytesReceived += len(audio)
+		r.addCallEvent("stream.media_received", map[string]any{
+			"stream_sid": s.sid,
+			"bytes":      len(audio),
+		})
+	case "mark":
+		if msg.Mark == nil {
+			return fmt.Errorf("mark message without a name")
+		}
+		due := s.playbackEnd
+		if due.Before(now) {
+			due = now
+		}
+		s.marks = append(s.marks, pendingMark{name: msg.Mark.Name, due: due})
+		r.addCallEvent("stream.mark_received", map[string]any{
+			"stream_sid": s.sid,
+			"name":       msg.Mark.Name,
+		})
+	case "clear":
+		s.playbackEnd = now
+		r.addCallEvent("stream.cleared", map[string]any{
+			"stream_sid":    s.sid,
+			"pending_marks": len(s.marks),
+		})
+		return s.flushMarks(now, true)
+	default:
+		r.addCallEvent("stream.unknown_message", map[string]any{
+			"stream_sid": s.sid,
+			"event":      msg.Event,
+		})
+	}
+	return nil
+}
+
+func (s *mediaStream) sendDTMF(digits string) error {
+	for _, d := range digits {
+		if err := s.send(map[string]any{
+			"event":          "dtmf",
+			"sequenceNumber": s.nextSeq(),
+			"streamSid":      string(s.sid),
+			"dtmf": map[string]any{
+				"track": "inbound_track",
+				"digit": string(d),
+			},
+		}); err != nil {
+			return err
+		}
+	}
+	s.runner.addCallEvent("stream.dtmf", map[string]any{
+		"stream_sid": s.sid,
+		"digits":     digits,
+	})
+	return nil
+}
+
+// finish sends the stop message, closes the socket and reports the stream as stopped
+func (s *mediaStream) finish(ctx context.Context, reason string) {
+	r := s.runner
+	if !s.serverClosed {
+		// Flush audio up to now so the server sees the full call
+		_ = s.catchUp()
+		_ = s.send(map[string]any{
+			"event":          "stop",
+			"sequenceNumber": s.nextSeq(),
+			"streamSid":      string(s.sid),
+			"stop": map[string]any{
+				"accountSid": string(r.call.AccountSID),
+				"callSid":    string(r.call.SID),
+			},
+		})
+		_ = s.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	}
+	r.addCallEvent("stream.stopped", map[string]any{
+		"stream_sid":           s.sid,
+		"name":                 s.name,
+		"reason":               reason,
+		"media_frames_sent":    s.framesSent,
+		"media_bytes_received": s.bytesReceived,
+	})
+	s.sendStatusCallback(ctx, "stream-stopped", "")
+}
+
+func (s *mediaStream) fail(ctx context.Context, err error) {
+	r := s.runner
+	r.addCallEvent("stream.error", map[string]any{
+		"stream_sid": s.sid,
+		"error":      err.Error(),
+	})
+	r.recordError(fmt.Errorf("media stream %s: %w", s.sid, err))
+	s.sendStatusCallback(ctx, "stream-error", err.Error())
+}
+
+func (s *mediaStream) sendStatusCallback(ctx context.Context, event, streamError string) {
+	if s.statusCallback == "" {
+		return
+	}
+	r := s.runner
+	form := url.Values{}
+	form.Set("AccountSid", string(r.call.AccountSID))
+	form.Set("CallSid", string(r.call.SID))
+	form.Set("StreamSid", string(s.sid))
+	form.Set("StreamName", s.name)
+	form.Set("StreamEvent", event)
+	if streamError != "" {
+		form.Set("StreamError", streamError)
+	}
+	form.Set("Timestamp", r.clock.Now().UTC().Format(time.RFC3339))
+	if err := r.postCallback(ctx, s.statusCallbackMethod, s.statusCallback, form); err != nil {
+		r.addCallEvent("stream.status_callback_error", map[string]any{
+			"url":   s.statusCallback,
+			"error": err.Error(),
+		})
+		r.recordError(err)
+	}
+}
+
+func silence(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = mulawSilence
+	}
+	return b
+}
+
+// executeConnect bridges the call to a bidirectional media stream. TwiML after
+// <Connect> runs once the server closes the stream, unless an action is set.
+func (r *CallRunner) executeConnect(ctx context.Context, connect *twiml.Connect, currentTwimlDocumentURL string, terminated *bool) error {
+	r.trackCallTwiML(connect)
+	stream := connect.Children[0].(*twiml.Stream)
+	s, err := newMediaStream(r, stream, true, currentTwimlDocumentURL)
+	if err != nil {
+		return err
+	}
+	r.addStream(s)
+	defer r.removeStream(s)
+
+	r.engine.goTracked(func() { s.run(ctx) })
+	for waiting := true; waiting; {
+		r.engine.park(r.hangupCh, r.urlUpdateCh, r.dtmfCh, s.done)
+		select {
+		case <-ctx.Done():
+			s.stop("engine_closed")
+			<-s.done
+			return ctx.Err()
+		case <-r.hangupCh:
+			<-s.done
+			return nil
+		case <-r.urlUpdateCh:
+			r.addCallEvent("connect.interrupted", map[string]any{"reason": "url_updated"})
+			s.stop("url_updated")
+			<-s.done
+			return ErrURLUpdated
+		case <-r.dtmfCh:
+			digits := r.takeDigits()
+			deliver(r.engine, s.dtmfCh, digits, s.done)
+		case <-s.done:
+			waiting = false
+		}
+	}
+
+	if connect.Action == "" {
+		return nil
+	}
+	*terminated = true
+	return r.executeActionCallback(ctx, connect.Method, connect.Action, url.Values{}, currentTwimlDocumentURL, false)
+}
+
+// executeStart forks the call audio to media streams and continues with the
+// next verb right away
+func (r *CallRunner) executeStart(ctx context.Context, start *twiml.Start, currentTwimlDocumentURL string, terminated *bool) error {
+	r.trackCallTwiML(start)
+	for _, child := range start.Children {
+		stream := child.(*twiml.Stream)
+		s, err := newMediaStream(r, stream, false, currentTwimlDocumentURL)
+		if err != nil {
+			return err
+		}
+		r.addStream(s)
+		r.engine.wg.Add(1)
+		r.engine.goTracked(func() {
+			defer r.engine.wg.Done()
+			defer r.removeStream(s)
+			s.run(ctx)
+		})
+	}
+
+	if start.Action == "" {
+		return nil
+	}
+	*terminated = true
+	return r.executeActionCallback(ctx, start.Method, start.Action, url.Values{}, currentTwimlDocumentURL, false)
+}
+
+// executeStop ends the named media streams begun with <Start>
+func (r *CallRunner) executeStop(stop *twiml.Stop) error {
+	r.trackCallTwiML(stop)
+	for _, child := range stop.Children {
+		stream := child.(*twiml.Stream)
+		stopped := false
+		for _, s := range r.activeStreams() {
+			if s.name == stream.Name && !s.bidirectional {
+				s.stop("stopped")
+				<-s.done
+				stopped = true
+			}
+		}
+		if !stopped {
+			r.addCallEvent("stream.stop_unknown", map[string]any{"name": stream.Name})
+		}
+	}
+	return nil
+}
+
+func (r *CallRunner) addStream(s *mediaStream) {
+	r.state.mu.Lock()
+	defer r.state.mu.Unlock()
+	r.streams[s.sid] = s
+}
+
+func (r *CallRunner) removeStream(s *mediaStream) {
+	r.state.mu.Lock()
+	defer r.state.mu.Unlock()
+	delete(r.streams, s.sid)
+}
+
+func (r *CallRunner) activeStreams() []*mediaStream {
+	r.state.mu.RLock()
+	defer r.state.mu.RUnlock()
+	streams := make([]*mediaStream, 0, len(r.streams))
+	for _, s := range r.streams {
+		streams = append(streams, s)
+	}
+	return streams
+}
+
+// SendStreamAudio feeds caller audio (8kHz mu-law) into the inbound track of
+// every media stream active on a call
+func (e *EngineImpl) SendStreamAudio(subaccountSID model.SID, callSID model.SID, audio []byte) error {
+	// Get subaccount state
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[subaccountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return notFoundError(subaccountSID)
+	}
+
+	state.mu.RLock()
+	runner := state.runners[callSID]
+	state.mu.RUnlock()
+	if runner == nil {
+		return notFoundError(callSID)
+	}
+
+	streams := runner.activeStreams()
+	if len(streams) == 0 {
+		return fmt.Errorf("call %s has no active media stream", callSID)
+	}
+	for _, s := range streams {
+		deliver(e, s.audioCh, append([]byte(nil), audio...), s.done)
+	}
+	return nil
+}
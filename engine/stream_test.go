@@ -0,0 +1,316 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/sprucehealth/twimulator/engine"
+	"github.com/sprucehealth/twimulator/httpstub"
+	"github.com/sprucehealth/twimulator/model"
+)
+
+type streamFrame struct {
+	Event          string `json:"event"`
+	SequenceNumber string `json:"sequenceNumber"`
+	StreamSid      string `json:"streamSid"`
+	Start          *struct {
+		CallSid          string            `json:"callSid"`
+		Tracks           []string          `json:"tracks"`
+		CustomParameters map[string]string `json:"customParameters"`
+	} `json:"start"`
+	Media *struct {
+		Track     string `json:"track"`
+		Chunk     string `json:"chunk"`
+		Timestamp string `json:"timestamp"`
+		Payload   string `json:"payload"`
+	} `json:"media"`
+	Mark *struct {
+		Name string `json:"name"`
+	} `json:"mark"`
+	DTMF *struct {
+		Digit string `json:"digit"`
+	} `json:"dtmf"`
+}
+
+// streamServer is a local Media Streams WebSocket server for tests
+type streamServer struct {
+	*httptest.Server
+	frames chan streamFrame
+	conns  chan *websocket.Conn
+	header chan http.Header
+}
+
+func newStreamServer(t *testing.T) *streamServer {
+	t.Helper()
+	s := &streamServer{
+		frames: make(chan streamFrame, 1024),
+		conns:  make(chan *websocket.Conn, 1),
+		header: make(chan http.Header, 1),
+	}
+	upgrader := websocket.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		s.header <- r.Header
+		s.conns <- conn
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				close(s.frames)
+				return
+			}
+			var f streamFrame
+			if err := json.Unmarshal(data, &f); err == nil {
+				s.frames <- f
+			}
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *streamServer) wsURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+// next returns the next frame that is not a media frame
+func (s *streamServer) next(t *testing.T) streamFrame {
+	t.Helper()
+	for {
+		select {
+		case f, ok := <-s.frames:
+			if !ok {
+				t.Fatal("stream closed")
+			}
+			if f.Event != "media" {
+				return f
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for stream frame")
+		}
+	}
+}
+
+// media collects the media frames received so far
+func (s *streamServer) media() []streamFrame {
+	var frames []streamFrame
+	for {
+		select {
+		case f := <-s.frames:
+			if f.Event == "media" {
+				frames = append(frames, f)
+			}
+		case <-time.After(20 * time.Millisecond):
+			return frames
+		}
+	}
+}
+
+// subscribeStream subscribes to the stream events of a call
+func subscribeStream(t *testing.T, e *engine.EngineImpl, call *model.Call) <-chan engine.EngineEvent {
+	t.Helper()
+	events, cancel := e.Subscribe(engine.EventFilter{CallSID: call.SID, TypePrefixes: []string{"stream."}})
+	t.Cleanup(cancel)
+	return events
+}
+
+// awaitStreamEvent waits for a stream event of eventType and then settles the
+// engine. Messages from the stream server reach the engine over the socket, so
+// settling alone can return before they arrive.
+func awaitStreamEvent(t *testing.T, e *engine.EngineImpl, events <-chan engine.EngineEvent, eventType string) {
+	t.Helper()
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type == eventType {
+				settle(t, e)
+				return
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %s", eventType)
+		}
+	}
+}
+
+func TestConnectStreamBidirectional(t *testing.T) {
+	srv := newStreamServer(t)
+	e, mock, subAccount := newTestEngine(t, map[string]string{
+		"http://test/answer": `<Response><Connect><Stream url="` + srv.wsURL() + `" statusCallback="http://test/stream-status"><Parameter name="patient" value="42"/></Stream></Connect><Say>Back from the bot</Say></Response>`,
+	})
+	call := startCall(t, e, subAccount.SID)
+	events := subscribeStream(t, e, call)
+
+	if f := srv.next(t); f.Event != "connected" {
+		t.Fatalf("expected connected, got %s", f.Event)
+	}
+	start := srv.next(t)
+	if start.Event != "start" || start.Start == nil {
+		t.Fatalf("expected start, got %+v", start)
+	}
+	if start.Start.CallSid != string(call.SID) || start.Start.CustomParameters["patient"] != "42" {
+		t.Errorf("unexpected start payload %+v", start.Start)
+	}
+	if sig := (<-srv.header).Get(httpstub.SignatureHeader); sig == "" {
+		t.Error("expected signed WebSocket handshake")
+	}
+	conn := <-srv.conns
+
+	e.Advance(100 * time.Millisecond)
+	settle(t, e)
+	media := srv.media()
+	if len(media) != 5 {
+		t.Fatalf("expected 5 media frames for 100ms, got %d", len(media))
+	}
+	if media[4].Media.Track != "inbound" || media[4].Media.Timestamp != "80" || media[4].Media.Chunk != "5" {
+		t.Errorf("unexpected media frame %+v", media[4].Media)
+	}
+
+	// 1600 bytes of audio take 200ms to play before the mark is echoed
+	payload := base64.StdEncoding.EncodeToString(make([]byte, 1600))
+	if err := conn.WriteJSON(map[string]any{"event": "media", "streamSid": start.StreamSid, "media": map[string]any{"payload": payload}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.WriteJSON(map[string]any{"event": "mark", "streamSid": start.StreamSid, "mark": map[string]any{"name": "greeting"}}); err != nil {
+		t.Fatal(err)
+	}
+	awaitStreamEvent(t, e, events, "stream.mark_received")
+	e.Advance(100 * time.Millisecond)
+	settle(t, e)
+	select {
+	case f := <-srv.frames:
+		if f.Event == "mark" {
+			t.Fatal("mark echoed before its audio finished playing")
+		}
+	default:
+	}
+	e.Advance(100 * time.Millisecond)
+	settle(t, e)
+	if f := srv.next(t); f.Event != "mark" || f.Mark.Name != "greeting" {
+		t.Fatalf("expected mark greeting, got %+v", f)
+	}
+
+	if err := e.SendDigits(call.AccountSID, call.SID, "7"); err != nil {
+		t.Fatal(err)
+	}
+	if f := srv.next(t); f.Event != "dtmf" || f.DTMF.Digit != "7" {
+		t.Fatalf("expected dtmf 7, got %+v", f)
+	}
+
+	// Closing the socket resumes TwiML after <Connect>
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	conn.Close()
+	awaitStreamEvent(t, e, events, "stream.stopped")
+
+	got, _ := e.GetCallState(call.AccountSID, call.SID)
+	for _, eventType := range []string{"stream.started", "stream.media_received", "stream.mark_received", "stream.mark_sent", "stream.stopped", "twiml.say"} {
+		if !hasEvent(got, eventType) {
+			t.Errorf("expected %s event on timeline", eventType)
+		}
+	}
+	if got.Status != model.CallCompleted {
+		t.Errorf("expected call completed after TwiML, got %s", got.Status)
+	}
+
+	var callbacks []string
+	for _, c := range mock.GetCallsTo("http://test/stream-status") {
+		callbacks = append(callbacks, c.Form.Get("StreamEvent"))
+	}
+	if strings.Join(callbacks, ",") != "stream-started,stream-stopped" {
+		t.Errorf("expected started and stopped callbacks, got %v", callbacks)
+	}
+}
+
+func TestConnectStreamClear(t *testing.T) {
+	srv := newStreamServer(t)
+	e, _, subAccount := newTestEngine(t, map[string]string{
+		"http://test/answer": `<Response><Connect><Stream url="` + srv.wsURL() + `"/></Connect></Response>`,
+	})
+	call := startCall(t, e, subAccount.SID)
+	events := subscribeStream(t, e, call)
+	srv.next(t)
+	start := srv.next(t)
+	conn := <-srv.conns
+
+	payload := base64.StdEncoding.EncodeToString(make([]byte, 8000))
+	conn.WriteJSON(map[string]any{"event": "media", "streamSid": start.StreamSid, "media": map[string]any{"payload": payload}})
+	conn.WriteJSON(map[string]any{"event": "mark", "streamSid": start.StreamSid, "mark": map[string]any{"name": "long"}})
+	conn.WriteJSON(map[string]any{"event": "clear", "streamSid": start.StreamSid})
+	awaitStreamEvent(t, e, events, "stream.cleared")
+	e.Advance(20 * time.Millisecond)
+
+	// Clearing the buffer returns pending marks without waiting for playback
+	if f := srv.next(t); f.Event != "mark" || f.Mark.Name != "long" {
+		t.Fatalf("expected mark long after clear, got %+v", f)
+	}
+}
+
+func TestStartStreamForksAudio(t *testing.T) {
+	srv := newStreamServer(t)
+	e, _, subAccount := newTestEngine(t, map[string]string{
+		"http://test/answer": `<Response><Start><Stream name="transcriber" url="` + srv.wsURL() + `" track="both_tracks"/></Start><Gather timeout="30"><Say>Tell us how you feel</Say></Gather></Response>`,
+	})
+	call := startCall(t, e, subAccount.SID)
+
+	srv.next(t)
+	start := srv.next(t)
+	if len(start.Start.Tracks) != 2 {
+		t.Fatalf("expected both tracks, got %v", start.Start.Tracks)
+	}
+	<-srv.conns
+
+	audio := []byte(strings.Repeat("a", 160))
+	if err := e.SendStreamAudio(call.AccountSID, call.SID, audio); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	e.Advance(20 * time.Millisecond)
+	settle(t, e)
+
+	media := srv.media()
+	if len(media) != 2 {
+		t.Fatalf("expected one frame per track, got %d", len(media))
+	}
+	for _, f := range media {
+		decoded, _ := base64.StdEncoding.DecodeString(f.Media.Payload)
+		if f.Media.Track == "inbound" && string(decoded) != string(audio) {
+			t.Error("expected injected audio on the inbound track")
+		}
+		if f.Media.Track == "outbound" && decoded[0] != 0xFF {
+			t.Error("expected silence on the outbound track")
+		}
+	}
+
+	if err := e.Hangup(call.AccountSID, call.SID); err != nil {
+		t.Fatal(err)
+	}
+	if f := srv.next(t); f.Event != "stop" {
+		t.Fatalf("expected stop frame after hangup, got %s", f.Event)
+	}
+}
+
+func TestStopStream(t *testing.T) {
+	srv := newStreamServer(t)
+	e, _, subAccount := newTestEngine(t, map[string]string{
+		"http://test/answer": `<Response><Start><Stream name="tap" url="` + srv.wsURL() + `"/></Start><Stop><Stream name="tap"/></Stop><Gather timeout="30"></Gather></Response>`,
+	})
+	startCall(t, e, subAccount.SID)
+
+	srv.next(t)
+	srv.next(t)
+	if f := srv.next(t); f.Event != "stop" {
+		t.Fatalf("expected stop frame, got %s", f.Event)
+	}
+}
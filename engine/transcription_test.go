@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine_test
+
+import (
+	"testing"
+	"time"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+
+	"github.com/sprucehealth/twimulator/engine"
+	"github.com/sprucehealth/twimulator/httpstub"
+	"github.com/sprucehealth/twimulator/model"
+)
+
+// transcribeRoutes record a transcribed voicemail and hang up after it
+var transcribeRoutes = map[string]string{
+	"http://test/answer":   `<Response><Record finishOnKey="#" action="/recorded" transcribeCallback="/transcribed"/></Response>`,
+	"http://test/recorded": `<Response><Hangup/></Response>`,
+}
+
+// recordVoicemail answers a call, leaves a 5 second message and ends it with #
+func recordVoicemail(t *testing.T, e *engine.EngineImpl, call *model.Call) {
+	t.Helper()
+	answerCall(t, e, call.AccountSID, call.SID)
+	e.Advance(5 * time.Second)
+	sendDigits(t, e, call.AccountSID, call.SID, "#")
+}
+
+func TestRecordTranscription(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, transcribeRoutes)
+	call := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/answer"))
+	recordingSID, err := e.SetCallVoicemail(subAccount.SID, call.SID, "voicemail.wav", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.SetRecordingTranscription(subAccount.SID, recordingSID, "Please call me back about my refill", engine.TranscriptionCompleted); err != nil {
+		t.Fatal(err)
+	}
+	recordVoicemail(t, e, call)
+
+	snap, err := e.Snapshot(subAccount.SID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Transcriptions) != 1 {
+		t.Fatalf("expected 1 transcription, got %d", len(snap.Transcriptions))
+	}
+	var transcriptionSID model.SID
+	for sid, transcription := range snap.Transcriptions {
+		transcriptionSID = sid
+		if transcription.Status != "in-progress" || transcription.RecordingSID != recordingSID {
+			t.Errorf("unexpected transcription %+v", transcription)
+		}
+	}
+	if len(mock.GetCallsTo("http://test/transcribed")) != 0 {
+		t.Fatal("expected no transcribeCallback before the transcription delay")
+	}
+
+	e.Advance(10 * time.Second)
+	settle(t, e)
+
+	callbacks := mock.GetCallsTo("http://test/transcribed")
+	if len(callbacks) != 1 {
+		t.Fatalf("expected 1 transcribeCallback, got %d", len(callbacks))
+	}
+	form := callbacks[0].Form
+	if form.Get("TranscriptionText") != "Please call me back about my refill" || form.Get("TranscriptionStatus") != "completed" {
+		t.Errorf("unexpected transcribeCallback form %v", form)
+	}
+	if form.Get("TranscriptionSid") != string(transcriptionSID) || form.Get("RecordingSid") != string(recordingSID) || form.Get("CallSid") != string(call.SID) {
+		t.Errorf("unexpected transcribeCallback SIDs %v", form)
+	}
+	if callbacks[0].Headers.Get(httpstub.SignatureHeader) == "" {
+		t.Error("expected a signed transcribeCallback")
+	}
+
+	accountSID := string(subAccount.SID)
+	transcription, err := e.FetchTranscription(string(transcriptionSID), &twilioopenapi.FetchTranscriptionParams{PathAccountSid: &accountSID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *transcription.Status != "completed" || *transcription.TranscriptionText != "Please call me back about my refill" || *transcription.Duration != "5" {
+		t.Errorf("unexpected fetched transcription %+v", transcription)
+	}
+
+	if _, err := e.FetchTranscription("TRFAKE00000000000000000000000000", &twilioopenapi.FetchTranscriptionParams{PathAccountSid: &accountSID}); err == nil {
+		t.Error("expected an error for an unknown transcription")
+	}
+}
+
+func TestRecordTranscriber(t *testing.T) {
+	var transcribed *model.Recording
+	e, mock, subAccount := newTestEngine(t, transcribeRoutes,
+		engine.WithTranscriptionDelay(time.Minute),
+		engine.WithTranscriber(func(recording *model.Recording) (string, string) {
+			transcribed = recording
+			return "Transcribed by the test", engine.TranscriptionCompleted
+		}),
+	)
+	call := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/answer"))
+	recordVoicemail(t, e, call)
+
+	e.Advance(30 * time.Second)
+	settle(t, e)
+	if len(mock.GetCallsTo("http://test/transcribed")) != 0 {
+		t.Fatal("expected no transcribeCallback before the configured delay")
+	}
+	e.Advance(30 * time.Second)
+	settle(t, e)
+
+	callbacks := mock.GetCallsTo("http://test/transcribed")
+	if len(callbacks) != 1 || callbacks[0].Form.Get("TranscriptionText") != "Transcribed by the test" {
+		t.Fatalf("expected the transcriber's text, got %+v", callbacks)
+	}
+	if transcribed == nil || transcribed.Duration != 5 || string(transcribed.SID) != callbacks[0].Form.Get("RecordingSid") {
+		t.Errorf("unexpected recording passed to the transcriber %+v", transcribed)
+	}
+}
+
+func TestRecordTranscriptionFailsWithoutText(t *testing.T) {
+	e, mock, subAccount := newTestEngine(t, transcribeRoutes)
+	call := mustCreateCall(t, e, newCreateCallParams(subAccount.SID, "+15550001111", "+15552223333", "http://test/answer"))
+	recordVoicemail(t, e, call)
+
+	e.Advance(10 * time.Second)
+	settle(t, e)
+
+	callbacks := mock.GetCallsTo("http://test/transcribed")
+	if len(callbacks) != 1 || callbacks[0].Form.Get("TranscriptionStatus") != "failed" {
+		t.Fatalf("expected a failed transcription, got %+v", callbacks)
+	}
+
+	if err := e.SetRecordingTranscription(subAccount.SID, "RE123", "text", "done"); err == nil {
+		t.Error("expected an error for an invalid status")
+	}
+}
@@ -0,0 +1,235 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+
+	"github.com/sprucehealth/twimulator/model"
+)
+
+const (
+	// TranscriptionCompleted is the status of a successful transcription
+	TranscriptionCompleted = "completed"
+	// TranscriptionFailed is the status of a recording that could not be transcribed
+	TranscriptionFailed = "failed"
+
+	defaultTranscriptionDelay = 10 * time.Second
+)
+
+// Transcriber produces the text and status of a recording's transcription.
+// The status must be "completed" or "failed".
+type Transcriber func(recording *model.Recording) (text string, status string)
+
+// transcriptionResult is a transcription outcome set with SetRecordingTranscription
+type transcriptionResult struct {
+	text   string
+	status string
+}
+
+// WithTranscriber sets the function that transcribes recordings which have no
+// transcription set with SetRecordingTranscription. Without one such
+// recordings fail to transcribe.
+func WithTranscriber(transcriber Transcriber) EngineOption {
+	return func(e *EngineImpl) {
+		e.transcriber = transcriber
+	}
+}
+
+// WithTranscriptionDelay sets how long transcribing a recording takes on the
+// engine clock (10 seconds by default)
+func WithTranscriptionDelay(d time.Duration) EngineOption {
+	return func(e *EngineImpl) {
+		e.transcriptionDelay = d
+	}
+}
+
+// SetRecordingTranscription sets the text a recording transcribes to. It can be
+// called with the SID returned by SetCallVoicemail before the recording is made,
+// or for a recording whose transcription is still in progress.
+func (e *EngineImpl) SetRecordingTranscription(accountSID model.SID, recordingSID model.SID, text string, status string) error {
+	if status != TranscriptionCompleted && status != TranscriptionFailed {
+		return fmt.Errorf("invalid transcription status %q: must be completed or failed", status)
+	}
+
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[accountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return notFoundError(accountSID)
+	}
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	state.transcriptionResults[recordingSID] = transcriptionResult{text: text, status: status}
+	return nil
+}
+
+// FetchTranscription returns a transcription by SID
+func (e *EngineImpl) FetchTranscription(sid string, params *twilioopenapi.FetchTranscriptionParams) (*twilioopenapi.ApiV2010Transcription, error) {
+	if params == nil || params.PathAccountSid == nil || *params.PathAccountSid == "" {
+		return nil, fmt.Errorf("PathAccountSid is required")
+	}
+	accountSID := model.SID(*params.PathAccountSid)
+
+	e.subAccountsMu.RLock()
+	state, exists := e.subAccounts[accountSID]
+	e.subAccountsMu.RUnlock()
+
+	if !exists {
+		return nil, notFoundError(accountSID)
+	}
+
+	state.mu.RLock()
+	defer state.mu.RUnlock()
+
+	transcription, exists := state.transcriptions[model.SID(sid)]
+	if !exists {
+		return nil, notFoundError(model.SID(sid))
+	}
+
+	sidStr := string(transcription.SID)
+	accountSIDStr := string(transcription.AccountSID)
+	recordingSIDStr := string(transcription.RecordingSID)
+	apiVersion := e.apiVersion
+	status := transcription.Status
+	text := transcription.Text
+	duration := strconv.Itoa(transcription.Duration)
+	transcriptionType := "fast"
+	dateCreated := transcription.CreatedAt.UTC().Format(time.RFC1123Z)
+	dateUpdated := transcription.UpdatedAt.UTC().Format(time.RFC1123Z)
+	uri := fmt.Sprintf("/2010-04-01/Accounts/%s/Transcriptions/%s.json", transcription.AccountSID, transcription.SID)
+
+	return &twilioopenapi.ApiV2010Transcription{
+		Sid:               &sidStr,
+		AccountSid:        &accountSIDStr,
+		RecordingSid:      &recordingSIDStr,
+		ApiVersion:        &apiVersion,
+		Status:            &status,
+		TranscriptionText: &text,
+		Duration:          &duration,
+		Type:              &transcriptionType,
+		DateCreated:       &dateCreated,
+		DateUpdated:       &dateUpdated,
+		Uri:               &uri,
+	}, nil
+}
+
+// startTranscription creates an in-progress transcription of a completed
+// recording and finishes it after the transcription delay, posting the result
+// to callbackURL if set
+func (e *EngineImpl) startTranscription(state *subAccountState, call *model.Call, recording *model.Recording, callbackURL string) {
+	state.mu.Lock()
+	now := state.clock.Now()
+	transcription := &model.Transcription{
+		SID:          e.ids.TranscriptionSID(),
+		AccountSID:   recording.AccountSID,
+		RecordingSID: recording.SID,
+		Status:       "in-progress",
+		Duration:     recording.Duration,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	state.transcriptions[transcription.SID] = transcription
+	recordingCopy := *recording
+	clock := state.clock
+	e.addCallEventLocked(state, call, "record.transcription_started", map[string]any{
+		"transcription_sid": transcription.SID,
+		"recording_sid":     recording.SID,
+	})
+	state.mu.Unlock()
+
+	e.wg.Add(1)
+	e.goTracked(func() {
+		defer e.wg.Done()
+		if !e.sleep(e.ctx, clock, e.transcriptionDelay) {
+			return
+		}
+		e.finishTranscription(state, call, transcription, &recordingCopy, callbackURL)
+	})
+}
+
+// finishTranscription resolves the text of a transcription and posts it to the
+// transcribeCallback
+func (e *EngineImpl) finishTranscription(state *subAccountState, call *model.Call, transcription *model.Transcription, recording *model.Recording, callbackURL string) {
+	state.mu.RLock()
+	result, ok := state.transcriptionResults[recording.SID]
+	state.mu.RUnlock()
+	if !ok {
+		result = transcriptionResult{status: TranscriptionFailed}
+		if e.transcriber != nil {
+			result.text, result.status = e.transcriber(recording)
+		}
+	}
+
+	state.mu.Lock()
+	transcription.Text = result.text
+	transcription.Status = result.status
+	transcription.UpdatedAt = state.clock.Now()
+	e.addCallEventLocked(state, call, "record.transcription_completed", map[string]any{
+		"transcription_sid":    transcription.SID,
+		"transcription_status": result.status,
+	})
+	form := url.Values{}
+	form.Set("AccountSid", string(transcription.AccountSID))
+	form.Set("CallSid", string(call.SID))
+	form.Set("TranscriptionSid", string(transcription.SID))
+	form.Set("TranscriptionText", result.text)
+	form.Set("TranscriptionStatus", result.status)
+	form.Set("TranscriptionUrl", e.transcriptionURL(transcription.AccountSID, transcription.SID))
+	form.Set("RecordingSid", string(recording.SID))
+	form.Set("RecordingUrl", e.recordingURL(recording.AccountSID, recording.SID))
+	form.Set("ApiVersion", e.apiVersion)
+	state.mu.Unlock()
+
+	if callbackURL == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(e.ctx, e.timeout)
+	defer cancel()
+
+	status, _, _, err := e.webhook.POST(ctx, callbackURL, form, e.signedHeaders(state, callbackURL, form))
+	if err == nil && (status < 200 || status >= 300) {
+		err = fmt.Errorf("returned status %d", status)
+	}
+	if err != nil {
+		e.addCallEvent(state, call, "webhook.transcribe_callback.error", map[string]any{
+			"url":   callbackURL,
+			"error": err.Error(),
+		})
+		e.recordError(state, fmt.Errorf("failed to fetch URL %s: %w", callbackURL, err))
+		return
+	}
+	e.addCallEvent(state, call, "webhook.transcribe_callback", map[string]any{
+		"url":                  callbackURL,
+		"transcription_status": result.status,
+	})
+}
+
+// recordingURL returns the URL of a recording, under baseURL if set or the
+// default Twilio URL otherwise
+func (e *EngineImpl) recordingURL(accountSID, recordingSID model.SID) string {
+	if e.baseURL != "" {
+		return fmt.Sprintf("%s/Accounts/%s/Recordings/%s", e.baseURL, accountSID, recordingSID)
+	}
+	return fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Recordings/%s", accountSID, recordingSID)
+}
+
+// transcriptionURL returns the URL of a transcription, under baseURL if set or
+// the default Twilio URL otherwise
+func (e *EngineImpl) transcriptionURL(accountSID, transcriptionSID model.SID) string {
+	if e.baseURL != "" {
+		return fmt.Sprintf("%s/Accounts/%s/Transcriptions/%s", e.baseURL, accountSID, transcriptionSID)
+	}
+	return fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Transcriptions/%s", accountSID, transcriptionSID)
+}
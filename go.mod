@@ -2,7 +2,10 @@ module github.com/sprucehealth/twimulator
 
 go 1.25
 
-require github.com/twilio/twilio-go v1.26.3
+require (
+	github.com/gorilla/websocket v1.5.3
+	github.com/twilio/twilio-go v1.26.3
+)
 
 require (
 	github.com/golang-jwt/jwt/v5 v5.2.2 // indirect
@@ -0,0 +1,44 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package httpstub
+
+import (
+	"context"
+	"time"
+)
+
+// Clock is the clock a webhook client waits on to simulate slow responses. The
+// engine passes the clock of the account a request is made for.
+type Clock interface {
+	After(d time.Duration) <-chan time.Time
+}
+
+// Waiter is a Clock that waits itself. The engine's clock implements it so
+// that a webhook client waiting on it does not keep the engine from settling.
+type Waiter interface {
+	Wait(ctx context.Context, d time.Duration) error
+}
+
+// RequestInfo describes the call a webhook request is made for
+type RequestInfo struct {
+	CallSID string
+	// Clock is the engine clock of the call's account
+	Clock Clock
+	// Record adds an event to the call's timeline
+	Record func(eventType string, detail map[string]any)
+}
+
+type requestInfoKey struct{}
+
+// WithRequestInfo returns a context carrying info about the call a request is made for
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFromContext returns the request info carried by ctx, if any
+func RequestInfoFromContext(ctx context.Context) (RequestInfo, bool) {
+	info, ok := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info, ok
+}
@@ -0,0 +1,257 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package httpstub
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Fault is a kind of misbehavior injected into webhook requests
+type Fault string
+
+const (
+	// FaultLatency delays the request by FaultRule.Latency and then sends it
+	FaultLatency Fault = "latency"
+	// FaultTimeout holds the request until its deadline passes on the engine
+	// clock and fails it as timed out
+	FaultTimeout Fault = "timeout"
+	// FaultStatus answers with FaultRule.Status, 500 by default
+	FaultStatus Fault = "status"
+	// FaultConnectionReset fails the request with a connection reset
+	FaultConnectionReset Fault = "connection_reset"
+	// FaultTruncatedBody sends the request and cuts the response body in half
+	FaultTruncatedBody Fault = "truncated_body"
+	// FaultInvalidTwiML answers 200 with a document that is not valid TwiML
+	FaultInvalidTwiML Fault = "invalid_twiml"
+)
+
+// invalidTwiML is the body of FaultInvalidTwiML responses
+const invalidTwiML = `<?xml version="1.0" encoding="UTF-8"?><Response><Say>`
+
+// FaultRule selects the requests to inject a fault into. Empty selectors match
+// every request.
+type FaultRule struct {
+	// URLPattern matches the request URL without its query. A "*" matches
+	// any characters, so "http://app.test/ivr/*" matches every IVR route.
+	URLPattern string
+	// CallSID matches the requests made for one call
+	CallSID string
+
+	Fault   Fault
+	Latency time.Duration // For FaultLatency
+	Status  int           // For FaultStatus
+
+	// Probability is the chance that a matching request is faulted. Zero
+	// faults every matching request.
+	Probability float64
+	// Nth faults only the Nth matching request, counting from 1. Zero faults
+	// every matching request.
+	Nth int
+}
+
+// FaultInjectingClient wraps a WebhookClient and injects faults into the
+// requests that match its rules. The first rule that matches and fires
+// applies. Latency and timeouts are measured on the engine clock of the call a
+// request is made for, and every fault is recorded on that call's timeline as
+// a "webhook.fault" event.
+type FaultInjectingClient struct {
+	next WebhookClient
+
+	mu     sync.Mutex
+	rules  []*faultRule
+	random *rand.Rand
+}
+
+type faultRule struct {
+	FaultRule
+	pattern *regexp.Regexp
+	count   int
+}
+
+// NewFaultInjectingClient wraps next with no rules
+func NewFaultInjectingClient(next WebhookClient) *FaultInjectingClient {
+	return &FaultInjectingClient{
+		next:   next,
+		random: rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+	}
+}
+
+// Seed makes the probabilistic rules fire the same way on every run
+func (c *FaultInjectingClient) Seed(seed uint64) *FaultInjectingClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.random = rand.New(rand.NewPCG(seed, seed))
+	return c
+}
+
+// AddRule adds a rule after the existing ones
+func (c *FaultInjectingClient) AddRule(rule FaultRule) *FaultInjectingClient {
+	r := &faultRule{FaultRule: rule}
+	if rule.URLPattern != "" {
+		parts := strings.Split(rule.URLPattern, "*")
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+		r.pattern = regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rules = append(c.rules, r)
+	return c
+}
+
+// match returns the rule that fires for a request, or nil
+func (c *FaultInjectingClient) match(ctx context.Context, targetURL string) *FaultRule {
+	target, _, _ := strings.Cut(targetURL, "?")
+	info, _ := RequestInfoFromContext(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, rule := range c.rules {
+		if rule.pattern != nil && !rule.pattern.MatchString(target) {
+			continue
+		}
+		if rule.CallSID != "" && rule.CallSID != info.CallSID {
+			continue
+		}
+		rule.count++
+		if rule.Nth > 0 && rule.count != rule.Nth {
+			continue
+		}
+		if rule.Probability > 0 && c.random.Float64() >= rule.Probability {
+			continue
+		}
+		matched := rule.FaultRule
+		return &matched
+	}
+	return nil
+}
+
+// inject applies the fault of rule before the request is sent. It returns a
+// response when the request must not reach the wrapped client.
+func (c *FaultInjectingClient) inject(ctx context.Context, method, targetURL string, rule *FaultRule) (handled bool, status int, body []byte, headers http.Header, err error) {
+	info, _ := RequestInfoFromContext(ctx)
+	detail := map[string]any{
+		"url":    targetURL,
+		"method": method,
+		"fault":  string(rule.Fault),
+	}
+	switch rule.Fault {
+	case FaultLatency:
+		detail["latency"] = rule.Latency.String()
+	case FaultStatus:
+		detail["status"] = faultStatus(rule)
+	}
+	if info.Record != nil {
+		info.Record("webhook.fault", detail)
+	}
+
+	switch rule.Fault {
+	case FaultLatency:
+		if err := wait(ctx, info.Clock, rule.Latency); err != nil {
+			return true, 0, nil, nil, fmt.Errorf("injected latency: %w", err)
+		}
+		return false, 0, nil, nil, nil
+	case FaultTimeout:
+		var d time.Duration
+		if deadline, ok := ctx.Deadline(); ok {
+			d = time.Until(deadline)
+		}
+		if err := wait(ctx, info.Clock, d); err != nil {
+			return true, 0, nil, nil, fmt.Errorf("injected timeout: %w", err)
+		}
+		return true, 0, nil, nil, fmt.Errorf("injected timeout: %w", context.DeadlineExceeded)
+	case FaultStatus:
+		status := faultStatus(rule)
+		return true, status, []byte(http.StatusText(status)), make(http.Header), nil
+	case FaultConnectionReset:
+		return true, 0, nil, nil, fmt.Errorf("injected fault: %w", syscall.ECONNRESET)
+	case FaultInvalidTwiML:
+		return true, http.StatusOK, []byte(invalidTwiML), make(http.Header), nil
+	}
+	return false, 0, nil, nil, nil
+}
+
+func faultStatus(rule *FaultRule) int {
+	if rule.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return rule.Status
+}
+
+// wait blocks for d on clock, or on the wall clock when clock is nil. A
+// non-positive d waits until ctx is done.
+func wait(ctx context.Context, clock Clock, d time.Duration) error {
+	if waiter, ok := clock.(Waiter); ok && d > 0 {
+		return waiter.Wait(ctx, d)
+	}
+	var after <-chan time.Time
+	switch {
+	case d <= 0:
+	case clock != nil:
+		after = clock.After(d)
+	default:
+		after = time.After(d)
+	}
+	select {
+	case <-after:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// truncate cuts a response body in half for FaultTruncatedBody
+func truncate(rule *FaultRule, body []byte) []byte {
+	if rule != nil && rule.Fault == FaultTruncatedBody {
+		return body[:len(body)/2]
+	}
+	return body
+}
+
+// POST sends the request through the wrapped client unless a fault answers it
+func (c *FaultInjectingClient) POST(ctx context.Context, targetURL string, form url.Values, reqHeaders http.Header) (status int, body []byte, headers http.Header, err error) {
+	rule := c.match(ctx, targetURL)
+	if rule != nil {
+		if handled, status, body, headers, err := c.inject(ctx, http.MethodPost, targetURL, rule); handled {
+			return status, body, headers, err
+		}
+	}
+	status, body, headers, err = c.next.POST(ctx, targetURL, form, reqHeaders)
+	return status, truncate(rule, body), headers, err
+}
+
+// GET sends the request through the wrapped client unless a fault answers it
+func (c *FaultInjectingClient) GET(ctx context.Context, targetURL string, reqHeaders http.Header) (status int, body []byte, headers http.Header, err error) {
+	rule := c.match(ctx, targetURL)
+	if rule != nil {
+		if handled, status, body, headers, err := c.inject(ctx, http.MethodGet, targetURL, rule); handled {
+			return status, body, headers, err
+		}
+	}
+	status, body, headers, err = c.next.GET(ctx, targetURL, reqHeaders)
+	return status, truncate(rule, body), headers, err
+}
+
+// HEAD sends the request through the wrapped client unless a fault answers it
+func (c *FaultInjectingClient) HEAD(ctx context.Context, targetURL string) (status int, headers http.Header, err error) {
+	rule := c.match(ctx, targetURL)
+	if rule != nil {
+		if handled, status, _, headers, err := c.inject(ctx, http.MethodHead, targetURL, rule); handled {
+			return status, headers, err
+		}
+	}
+	return c.next.HEAD(ctx, targetURL)
+}
@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package httpstub
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// HandlerWebhookClient dispatches webhook requests straight into http.Handlers
+// in process, so the engine can drive an application's real routes without
+// opening sockets
+type HandlerWebhookClient struct {
+	mu     sync.Mutex
+	routes []handlerRoute
+	calls  []HandlerCall
+}
+
+// HandlerCall records a request served by a HandlerWebhookClient
+type HandlerCall struct {
+	Request  *http.Request
+	Form     url.Values
+	Recorder *httptest.ResponseRecorder
+}
+
+type handlerRoute struct {
+	host    string
+	prefix  string
+	handler http.Handler
+}
+
+// NewHandlerWebhookClient creates a client with no routes
+func NewHandlerWebhookClient() *HandlerWebhookClient {
+	return &HandlerWebhookClient{}
+}
+
+// Handle routes requests matching pattern to handler. A pattern starting with
+// "/" matches a path prefix on any host, such as "/twilio/". Other patterns
+// match a host, optionally followed by a path prefix, such as "app.test" or
+// "app.test/twilio/". When several routes match, the longest pattern wins.
+func (c *HandlerWebhookClient) Handle(pattern string, handler http.Handler) *HandlerWebhookClient {
+	route := handlerRoute{prefix: "/", handler: handler}
+	if strings.HasPrefix(pattern, "/") {
+		route.prefix = pattern
+	} else if host, prefix, ok := strings.Cut(pattern, "/"); ok {
+		route.host, route.prefix = host, "/"+prefix
+	} else {
+		route.host = pattern
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.routes = append(c.routes, route)
+	return c
+}
+
+// Calls returns the requests served so far
+func (c *HandlerWebhookClient) Calls() []HandlerCall {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	calls := make([]HandlerCall, len(c.calls))
+	copy(calls, c.calls)
+	return calls
+}
+
+// Reset clears all recorded calls
+func (c *HandlerWebhookClient) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = nil
+}
+
+// handler returns the handler of the longest route matching u
+func (c *HandlerWebhookClient) handler(u *url.URL) http.Handler {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var best *handlerRoute
+	for i := range c.routes {
+		route := &c.routes[i]
+		if route.host != "" && route.host != u.Host && route.host != u.Hostname() {
+			continue
+		}
+		if !strings.HasPrefix(u.Path, route.prefix) {
+			continue
+		}
+		if best == nil || len(route.host)+len(route.prefix) > len(best.host)+len(best.prefix) {
+			best = route
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return best.handler
+}
+
+// serve builds a server-side request and runs it through the matching handler
+func (c *HandlerWebhookClient) serve(ctx context.Context, method, targetURL string, form url.Values, reqHeaders http.Header) (*httptest.ResponseRecorder, error) {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, targetURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	handler := c.handler(req.URL)
+	if handler == nil {
+		return nil, fmt.Errorf("no handler for %s", targetURL)
+	}
+
+	copyHeaders(req.Header, reqHeaders)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	req.Header.Set("User-Agent", "Twimulator/1.0")
+	// Make the request look like one received by a server
+	req.RequestURI = req.URL.RequestURI()
+	req.RemoteAddr = "192.0.2.1:1234"
+
+	rec := serveHTTP(handler, req)
+
+	c.mu.Lock()
+	c.calls = append(c.calls, HandlerCall{
+		Request:  req,
+		Form:     form,
+		Recorder: rec,
+	})
+	c.mu.Unlock()
+	return rec, nil
+}
+
+// serveHTTP runs req through handler. A handler that panics is answered with
+// a 500, replacing anything it wrote before panicking.
+func serveHTTP(handler http.Handler, req *http.Request) (rec *httptest.ResponseRecorder) {
+	rec = httptest.NewRecorder()
+	defer func() {
+		if p := recover(); p != nil {
+			log.Printf("httpstub: panic serving %s %s: %v", req.Method, req.URL, p)
+			rec = httptest.NewRecorder()
+			http.Error(rec, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+	}()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// POST sends form to the handler as a urlencoded body
+func (c *HandlerWebhookClient) POST(ctx context.Context, targetURL string, form url.Values, reqHeaders http.Header) (status int, body []byte, headers http.Header, err error) {
+	if form == nil {
+		form = url.Values{}
+	}
+	rec, err := c.serve(ctx, "POST", targetURL, form, reqHeaders)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return rec.Code, rec.Body.Bytes(), rec.Result().Header, nil
+}
+
+// GET sends a GET request to the handler
+func (c *HandlerWebhookClient) GET(ctx context.Context, targetURL string, reqHeaders http.Header) (status int, body []byte, headers http.Header, err error) {
+	rec, err := c.serve(ctx, "GET", targetURL, nil, reqHeaders)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return rec.Code, rec.Body.Bytes(), rec.Result().Header, nil
+}
+
+// HEAD sends a HEAD request to the handler and discards any body it writes
+func (c *HandlerWebhookClient) HEAD(ctx context.Context, targetURL string) (status int, headers http.Header, err error) {
+	rec, err := c.serve(ctx, "HEAD", targetURL, nil, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	return rec.Code, rec.Result().Header, nil
+}
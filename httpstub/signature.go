@@ -0,0 +1,49 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package httpstub
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// SignatureHeader is the header Twilio uses to sign webhook requests
+const SignatureHeader = "X-Twilio-Signature"
+
+// ComputeSignature returns the X-Twilio-Signature value for a request: the
+// base64 HMAC-SHA1, keyed by the auth token, of the URL followed by each
+// form parameter name and value in sorted name order. GET requests pass nil
+// params since their parameters are already part of the URL.
+func ComputeSignature(authToken, targetURL string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(targetURL)
+	for _, k := range keys {
+		for _, v := range params[k] {
+			b.WriteString(k)
+			b.WriteString(v)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(b.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateSignature reports whether signature matches the request
+func ValidateSignature(authToken, targetURL string, params url.Values, signature string) bool {
+	expected := ComputeSignature(authToken, targetURL, params)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(signature)) == 1
+}
@@ -11,13 +11,16 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
 // WebhookClient defines the interface for making webhook HTTP calls
 type WebhookClient interface {
-	POST(ctx context.Context, url string, form url.Values) (status int, body []byte, headers http.Header, err error)
-	GET(ctx context.Context, url string) (status int, body []byte, headers http.Header, err error)
+	// POST sends form as the request body. reqHeaders are added to the request.
+	POST(ctx context.Context, url string, form url.Values, reqHeaders http.Header) (status int, body []byte, headers http.Header, err error)
+	// GET fetches url. reqHeaders are added to the request.
+	GET(ctx context.Context, url string, reqHeaders http.Header) (status int, body []byte, headers http.Header, err error)
 	HEAD(ctx context.Context, url string) (status int, headers http.Header, err error)
 }
 
@@ -41,12 +44,14 @@ func NewDefaultWebhookClient(timeout time.Duration) *DefaultWebhookClient {
 }
 
 // POST makes an HTTP POST request with form data
-func (c *DefaultWebhookClient) POST(ctx context.Context, targetURL string, form url.Values) (status int, body []byte, headers http.Header, err error) {
+func (c *DefaultWebhookClient) POST(ctx context.Context, targetURL string, form url.Values, reqHeaders http.Header) (status int, body []byte, headers http.Header, err error) {
 	req, err := http.NewRequestWithContext(ctx, "POST", targetURL, strings.NewReader(form.Encode()))
 	if err != nil {
 		return 0, nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	copyHeaders(req.Header, reqHeaders)
+
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.Header.Set("User-Agent", "Twimulator/1.0")
 
@@ -65,12 +70,14 @@ func (c *DefaultWebhookClient) POST(ctx context.Context, targetURL string, form
 }
 
 // GET makes an HTTP GET request
-func (c *DefaultWebhookClient) GET(ctx context.Context, targetURL string) (status int, body []byte, headers http.Header, err error) {
+func (c *DefaultWebhookClient) GET(ctx context.Context, targetURL string, reqHeaders http.Header) (status int, body []byte, headers http.Header, err error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", targetURL, nil)
 	if err != nil {
 		return 0, nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	copyHeaders(req.Header, reqHeaders)
+
 	req.Header.Set("User-Agent", "Twimulator/1.0")
 
 	resp, err := c.client.Do(req)
@@ -106,8 +113,18 @@ func (c *DefaultWebhookClient) HEAD(ctx context.Context, targetURL string) (stat
 	return resp.StatusCode, resp.Header, nil
 }
 
-// MockWebhookClient is a test double for capturing webhook calls
+func copyHeaders(dst, src http.Header) {
+	for k, v := range src {
+		for _, val := range v {
+			dst.Add(k, val)
+		}
+	}
+}
+
+// MockWebhookClient is a test double for capturing webhook calls. It is safe
+// for concurrent use; read Calls directly only once the engine has settled.
 type MockWebhookClient struct {
+	mu    sync.Mutex
 	Calls []MockCall
 	// ResponseFunc allows tests to control responses
 	ResponseFunc func(url string, form url.Values) (status int, body []byte, headers http.Header, err error)
@@ -117,6 +134,7 @@ type MockWebhookClient struct {
 type MockCall struct {
 	URL     string
 	Form    url.Values
+	Headers http.Header
 	Time    time.Time
 	Context context.Context
 }
@@ -133,10 +151,11 @@ func NewMockWebhookClient() *MockWebhookClient {
 }
 
 // POST records the call and returns the configured response
-func (m *MockWebhookClient) POST(ctx context.Context, targetURL string, form url.Values) (status int, body []byte, headers http.Header, err error) {
-	m.Calls = append(m.Calls, MockCall{
+func (m *MockWebhookClient) POST(ctx context.Context, targetURL string, form url.Values, reqHeaders http.Header) (status int, body []byte, headers http.Header, err error) {
+	m.record(MockCall{
 		URL:     targetURL,
 		Form:    form,
+		Headers: reqHeaders,
 		Time:    time.Now(),
 		Context: ctx,
 	})
@@ -149,10 +168,11 @@ func (m *MockWebhookClient) POST(ctx context.Context, targetURL string, form url
 }
 
 // GET records the call and returns the configured response
-func (m *MockWebhookClient) GET(ctx context.Context, targetURL string) (status int, body []byte, headers http.Header, err error) {
-	m.Calls = append(m.Calls, MockCall{
+func (m *MockWebhookClient) GET(ctx context.Context, targetURL string, reqHeaders http.Header) (status int, body []byte, headers http.Header, err error) {
+	m.record(MockCall{
 		URL:     targetURL,
 		Form:    nil, // No form data for GET requests
+		Headers: reqHeaders,
 		Time:    time.Now(),
 		Context: ctx,
 	})
@@ -167,7 +187,7 @@ func (m *MockWebhookClient) GET(ctx context.Context, targetURL string) (status i
 
 // HEAD records the call and returns the configured response (no body)
 func (m *MockWebhookClient) HEAD(ctx context.Context, targetURL string) (status int, headers http.Header, err error) {
-	m.Calls = append(m.Calls, MockCall{
+	m.record(MockCall{
 		URL:     targetURL,
 		Form:    nil, // No form data for HEAD requests
 		Time:    time.Now(),
@@ -183,13 +203,24 @@ func (m *MockWebhookClient) HEAD(ctx context.Context, targetURL string) (status
 	return 200, make(http.Header), nil
 }
 
+// record appends a webhook call to Calls
+func (m *MockWebhookClient) record(call MockCall) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Calls = append(m.Calls, call)
+}
+
 // Reset clears all recorded calls
 func (m *MockWebhookClient) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.Calls = make([]MockCall, 0)
 }
 
 // GetCallsTo returns all calls to a specific URL
 func (m *MockWebhookClient) GetCallsTo(url string) []MockCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	var result []MockCall
 	for _, call := range m.Calls {
 		if call.URL == url {
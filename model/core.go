@@ -5,10 +5,14 @@
 package model
 
 import (
-	"crypto/rand"
+	cryptorand "crypto/rand"
+	"encoding/binary"
 	"encoding/hex"
 	"fmt"
-	"sync/atomic"
+	"io"
+	"math/rand/v2"
+	"strconv"
+	"sync"
 	"time"
 )
 
@@ -46,6 +50,31 @@ func (s CallStatus) IsTerminal() bool {
 	}
 }
 
+// MessageStatus represents the delivery status of a message
+type MessageStatus string
+
+const (
+	MessageScheduled   MessageStatus = "scheduled"
+	MessageQueued      MessageStatus = "queued"
+	MessageSending     MessageStatus = "sending"
+	MessageSent        MessageStatus = "sent"
+	MessageDelivered   MessageStatus = "delivered"
+	MessageUndelivered MessageStatus = "undelivered"
+	MessageFailed      MessageStatus = "failed"
+	MessageCanceled    MessageStatus = "canceled"
+	MessageReceiving   MessageStatus = "receiving"
+	MessageReceived    MessageStatus = "received"
+)
+
+func (s MessageStatus) IsTerminal() bool {
+	switch s {
+	case MessageDelivered, MessageUndelivered, MessageFailed, MessageCanceled, MessageReceived:
+		return true
+	default:
+		return false
+	}
+}
+
 // Direction represents whether a call is inbound or outbound
 type Direction string
 
@@ -82,16 +111,41 @@ type Call struct {
 	Variables            map[string]string `json:"variables"`
 	Url                  string            `json:"url"`
 	Method               string            `json:"method"`
+	FallbackURL          string            `json:"fallback_url,omitempty"` // Fetched when Url fails
+	FallbackMethod       string            `json:"fallback_method,omitempty"`
 	StatusCallback       string            `json:"status_callback,omitempty"`
 	StatusCallbackEvents []CallStatus      `json:"status_callback_events,omitempty"` // Events to trigger callbacks for
 	InitialParams        map[string]string `json:"initial_params,omitempty"`
 	SIPDomainSID         string            `json:"sip_domain_sid,omitempty"`
+	MachineDetection     string            `json:"machine_detection,omitempty"` // "Enable" or "DetectMessageEnd"
+	AnsweredBy           string            `json:"answered_by,omitempty"`       // Answering machine detection outcome
 
 	// CallbackQueue serializes status callbacks for this call
 	// This is not serialized to JSON as it's internal state
 	CallbackQueue chan func() `json:"-"`
 }
 
+// Message represents an SMS or MMS
+type Message struct {
+	SID            SID           `json:"sid"`
+	AccountSID     SID           `json:"account_sid"`
+	From           string        `json:"from"`
+	To             string        `json:"to"`
+	Body           string        `json:"body"`
+	MediaURLs      []string      `json:"media_urls,omitempty"`
+	Direction      string        `json:"direction"` // "inbound", "outbound-api", "outbound-call", "outbound-reply"
+	Status         MessageStatus `json:"status"`
+	ErrorCode      int           `json:"error_code,omitempty"`
+	ErrorMessage   string        `json:"error_message,omitempty"`
+	StatusCallback string        `json:"status_callback,omitempty"`
+	CallSID        *SID          `json:"call_sid,omitempty"` // Set when sent by <Message> during a call
+	CreatedAt      time.Time     `json:"date_created"`
+	UpdatedAt      time.Time     `json:"date_updated"`
+	SentAt         *time.Time    `json:"date_sent,omitempty"`
+	SendAt         *time.Time    `json:"send_at,omitempty"` // Scheduled send time
+	Timeline       []Event       `json:"timeline"`
+}
+
 // Queue represents a call queue
 type Queue struct {
 	Name       string  `json:"name"`
@@ -129,6 +183,8 @@ type ParticipantState struct {
 	AnnounceMethod         string `json:"announce_method,omitempty"`
 	StartConferenceOnEnter bool   `json:"start_conference_on_enter"`
 	EndConferenceOnExit    bool   `json:"end_conference_on_exit"`
+	Coaching               bool   `json:"coaching"`
+	CallSidToCoach         SID    `json:"call_sid_to_coach,omitempty"`
 }
 
 // Event represents a timeline event for a call, queue, or conference
@@ -157,6 +213,13 @@ type IncomingNumber struct {
 	SID                 string    `json:"sid"`
 	PhoneNumber         string    `json:"phone_number"`
 	VoiceApplicationSID *string   `json:"voice_application_sid,omitempty"`
+	VoiceURL            string    `json:"voice_url,omitempty"`
+	VoiceMethod         string    `json:"voice_method,omitempty"`
+	VoiceFallbackURL    string    `json:"voice_fallback_url,omitempty"`
+	VoiceFallbackMethod string    `json:"voice_fallback_method,omitempty"`
+	SmsApplicationSID   *string   `json:"sms_application_sid,omitempty"`
+	SmsURL              string    `json:"sms_url,omitempty"`
+	SmsMethod           string    `json:"sms_method,omitempty"`
 	CreatedAt           time.Time `json:"created_at"`
 }
 
@@ -166,8 +229,13 @@ type Application struct {
 	FriendlyName         string    `json:"friendly_name,omitempty"`
 	VoiceMethod          string    `json:"voice_method,omitempty"`
 	VoiceURL             string    `json:"voice_url,omitempty"`
+	VoiceFallbackMethod  string    `json:"voice_fallback_method,omitempty"`
+	VoiceFallbackURL     string    `json:"voice_fallback_url,omitempty"`
 	StatusCallbackMethod string    `json:"status_callback_method,omitempty"`
 	StatusCallback       string    `json:"status_callback,omitempty"`
+	SmsURL               string    `json:"sms_url,omitempty"`
+	SmsMethod            string    `json:"sms_method,omitempty"`
+	SmsStatusCallback    string    `json:"sms_status_callback,omitempty"`
 	CreatedAt            time.Time `json:"created_at"`
 }
 
@@ -206,10 +274,24 @@ type Recording struct {
 	CallSID    *SID      `json:"call_sid,omitempty"` // nil for voicemail recordings
 	FilePath   string    `json:"file_path"`          // Path to the recording file
 	Duration   int       `json:"duration"`           // Duration in seconds
-	Status     string    `json:"status"`             // "completed", "absent", etc.
+	Status     string    `json:"status"`             // "in-progress", "paused", "completed", "absent", etc.
+	Channels   int       `json:"channels,omitempty"` // 1 for mono, 2 for dual
+	Source     string    `json:"source,omitempty"`   // "RecordVerb", "OutboundAPI", "StartCallRecordingAPI", etc.
 	CreatedAt  time.Time `json:"date_created"`
 }
 
+// Transcription represents the text of a recording made with <Record transcribe="true">
+type Transcription struct {
+	SID          SID       `json:"sid"`
+	AccountSID   SID       `json:"account_sid"`
+	RecordingSID SID       `json:"recording_sid"`
+	Text         string    `json:"transcription_text"`
+	Status       string    `json:"status"`   // "in-progress", "completed" or "failed"
+	Duration     int       `json:"duration"` // Duration of the transcribed recording in seconds
+	CreatedAt    time.Time `json:"date_created"`
+	UpdatedAt    time.Time `json:"date_updated"`
+}
+
 // SipDomain represents a Twilio SIP Domain
 type SipDomain struct {
 	SID                       SID                                         `json:"sid"`
@@ -218,6 +300,8 @@ type SipDomain struct {
 	FriendlyName              string                                      `json:"friendly_name"`
 	VoiceUrl                  string                                      `json:"voice_url,omitempty"`
 	VoiceMethod               string                                      `json:"voice_method,omitempty"`
+	VoiceFallbackUrl          string                                      `json:"voice_fallback_url,omitempty"`
+	VoiceFallbackMethod       string                                      `json:"voice_fallback_method,omitempty"`
 	VoiceStatusCallbackUrl    string                                      `json:"voice_status_callback_url,omitempty"`
 	VoiceStatusCallbackMethod string                                      `json:"voice_status_callback_method,omitempty"`
 	SipRegistration           bool                                        `json:"sip_registration"`
@@ -268,150 +352,287 @@ type SipAuthRegistrationsCredentialListMapping struct {
 	UpdatedAt         time.Time `json:"date_updated"`
 }
 
-// SID generators with atomic counters for determinism
-var (
-	callCounter                        uint64
-	conferenceCounter                  uint64
-	queueCounter                       uint64
-	subAccountCounter                  uint64
-	phoneNumberCounter                 uint64
-	applicationCounter                 uint64
-	recordingCounter                   uint64
-	addressCounter                     uint64
-	signingKeyCounter                  uint64
-	sipDomainCounter                   uint64
-	sipCredentialListCounter           uint64
-	sipCredentialCounter               uint64
-	sipAuthCallsMappingCounter         uint64
-	sipAuthRegistrationsMappingCounter uint64
-)
+// IDGenerator generates SIDs, auth tokens and secrets. Each engine owns one so
+// that the SIDs of one engine do not depend on other engines in the process. SIDs
+// are a per-type counter followed by random hex, making them 34 chars total.
+type IDGenerator struct {
+	mu       sync.Mutex
+	rand     io.Reader
+	counters map[string]uint64
+}
+
+// NewIDGenerator creates a generator that uses crypto/rand
+func NewIDGenerator() *IDGenerator {
+	return &IDGenerator{
+		rand:     cryptorand.Reader,
+		counters: make(map[string]uint64),
+	}
+}
+
+// NewSeededIDGenerator creates a generator whose output is the same on every run
+// for the same seed
+func NewSeededIDGenerator(seed int64) *IDGenerator {
+	var key [32]byte
+	binary.LittleEndian.PutUint64(key[:], uint64(seed))
+	return &IDGenerator{
+		rand:     rand.NewChaCha8(key),
+		counters: make(map[string]uint64),
+	}
+}
+
+// randomBytes reads n random bytes. Caller must hold g.mu.
+func (g *IDGenerator) randomBytes(n int) []byte {
+	b := make([]byte, n)
+	io.ReadFull(g.rand, b)
+	return b
+}
+
+// counterKey returns the counter used for SIDs with the given prefix. SMS and MMS
+// messages share a sequence.
+func counterKey(prefix string) string {
+	if prefix == "SMFAKE" || prefix == "MMFAKE" {
+		return "message"
+	}
+	return prefix
+}
+
+// sid generates a SID with the given prefix
+func (g *IDGenerator) sid(prefix string) SID {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	key := counterKey(prefix)
+	g.counters[key]++
+	// Generate 14 random hex characters to make total length 34
+	// prefix (6) + counter hex (14) + 14 hex chars = 34
+	return SID(fmt.Sprintf("%s%014x%s", prefix, g.counters[key], hex.EncodeToString(g.randomBytes(7))[:14]))
+}
+
+// Reserve moves the counter of a SID's type past the SID so that SIDs restored
+// from saved state are not generated again. SIDs not made by a generator are ignored.
+func (g *IDGenerator) Reserve(sid string) {
+	if len(sid) != 34 || sid[2:6] != "FAKE" {
+		return
+	}
+	counter, err := strconv.ParseUint(sid[6:20], 16, 64)
+	if err != nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	key := counterKey(sid[:6])
+	if counter > g.counters[key] {
+		g.counters[key] = counter
+	}
+}
+
+// hexString returns n random bytes as hex
+func (g *IDGenerator) hexString(n int) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return hex.EncodeToString(g.randomBytes(n))
+}
+
+// Intn returns a random int in the range [0, n)
+func (g *IDGenerator) Intn(n int) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return int(binary.LittleEndian.Uint64(g.randomBytes(8)) % uint64(n))
+}
+
+// CallSID generates a new Call SID (CAFAKE prefix)
+func (g *IDGenerator) CallSID() SID {
+	return g.sid("CAFAKE")
+}
+
+// ConferenceSID generates a new Conference SID (CFFAKE prefix)
+func (g *IDGenerator) ConferenceSID() SID {
+	return g.sid("CFFAKE")
+}
+
+// QueueSID generates a new Queue SID (QUFAKE prefix)
+func (g *IDGenerator) QueueSID() SID {
+	return g.sid("QUFAKE")
+}
+
+// ApplicationSID generates a new Application SID (APFAKE prefix)
+func (g *IDGenerator) ApplicationSID() SID {
+	return g.sid("APFAKE")
+}
+
+// SubAccountSID generates a new SubAccount SID (ACFAKE prefix)
+func (g *IDGenerator) SubAccountSID() SID {
+	return g.sid("ACFAKE")
+}
+
+// PhoneNumberSID generates a new Incoming Phone Number SID (PNFAKE prefix)
+func (g *IDGenerator) PhoneNumberSID() SID {
+	return g.sid("PNFAKE")
+}
+
+// RecordingSID generates a new Recording SID (REFAKE prefix)
+func (g *IDGenerator) RecordingSID() SID {
+	return g.sid("REFAKE")
+}
+
+// StreamSID generates a new media Stream SID (MZFAKE prefix)
+func (g *IDGenerator) StreamSID() SID {
+	return g.sid("MZFAKE")
+}
+
+// TranscriptionSID generates a new Transcription SID (TRFAKE prefix)
+func (g *IDGenerator) TranscriptionSID() SID {
+	return g.sid("TRFAKE")
+}
+
+// AddressSID generates a new Address SID (ADFAKE prefix)
+func (g *IDGenerator) AddressSID() SID {
+	return g.sid("ADFAKE")
+}
+
+// SipDomainSID generates a new SIP Domain SID (SDFAKE prefix)
+func (g *IDGenerator) SipDomainSID() SID {
+	return g.sid("SDFAKE")
+}
+
+// SipCredentialListSID generates a new SIP Credential List SID (CLFAKE prefix)
+func (g *IDGenerator) SipCredentialListSID() SID {
+	return g.sid("CLFAKE")
+}
+
+// SipCredentialSID generates a new SIP Credential SID (CRFAKE prefix)
+func (g *IDGenerator) SipCredentialSID() SID {
+	return g.sid("CRFAKE")
+}
+
+// SipAuthCallsMappingSID generates a new SIP Auth Calls Mapping SID (CMFAKE prefix)
+func (g *IDGenerator) SipAuthCallsMappingSID() SID {
+	return g.sid("CMFAKE")
+}
+
+// SipAuthRegistrationsMappingSID generates a new SIP Auth Registrations Mapping SID (RMFAKE prefix)
+func (g *IDGenerator) SipAuthRegistrationsMappingSID() SID {
+	return g.sid("RMFAKE")
+}
+
+// MessageSID generates a new Message SID (SMFAKE prefix for SMS, MMFAKE for MMS)
+func (g *IDGenerator) MessageSID(mms bool) SID {
+	if mms {
+		return g.sid("MMFAKE")
+	}
+	return g.sid("SMFAKE")
+}
+
+// SigningKeySID generates a new Signing Key SID (SKFAKE prefix)
+func (g *IDGenerator) SigningKeySID() string {
+	return string(g.sid("SKFAKE"))
+}
+
+// SigningKeySecret generates a secret for signing keys
+func (g *IDGenerator) SigningKeySecret() string {
+	return g.hexString(32)
+}
+
+// AuthToken generates an auth token for subaccounts
+func (g *IDGenerator) AuthToken() string {
+	return g.hexString(16)
+}
+
+// defaultIDGenerator backs the package-level generators
+var defaultIDGenerator = NewIDGenerator()
 
 // NewCallSID generates a new Call SID (CAFAKE prefix, 34 chars total)
 func NewCallSID() SID {
-	counter := atomic.AddUint64(&callCounter, 1)
-	// Generate 14 random hex characters to make total length 34
-	// CAFAKE (6) + 14 hex chars + counter hex (14) = 34
-	b := make([]byte, 7)
-	rand.Read(b)
-	return SID(fmt.Sprintf("CAFAKE%014x%s", counter, hex.EncodeToString(b)[:14]))
+	return defaultIDGenerator.CallSID()
 }
 
 // NewConferenceSID generates a new Conference SID (CFFAKE prefix, 34 chars total)
 func NewConferenceSID() SID {
-	counter := atomic.AddUint64(&conferenceCounter, 1)
-	b := make([]byte, 7)
-	rand.Read(b)
-	return SID(fmt.Sprintf("CFFAKE%014x%s", counter, hex.EncodeToString(b)[:14]))
+	return defaultIDGenerator.ConferenceSID()
 }
 
 // NewQueueSID generates a new Queue SID (QUFAKE prefix, 34 chars total)
 func NewQueueSID() SID {
-	counter := atomic.AddUint64(&queueCounter, 1)
-	b := make([]byte, 7)
-	rand.Read(b)
-	return SID(fmt.Sprintf("QUFAKE%014x%s", counter, hex.EncodeToString(b)[:14]))
+	return defaultIDGenerator.QueueSID()
 }
 
 // NewApplicationSID generates a new Application SID (APFAKE prefix, 34 chars total)
 func NewApplicationSID() SID {
-	counter := atomic.AddUint64(&applicationCounter, 1)
-	b := make([]byte, 7)
-	rand.Read(b)
-	return SID(fmt.Sprintf("APFAKE%014x%s", counter, hex.EncodeToString(b)[:14]))
+	return defaultIDGenerator.ApplicationSID()
 }
 
 // NewSubAccountSID generates a new SubAccount SID (ACFAKE prefix, 34 chars total)
 func NewSubAccountSID() SID {
-	counter := atomic.AddUint64(&subAccountCounter, 1)
-	b := make([]byte, 7)
-	rand.Read(b)
-	return SID(fmt.Sprintf("ACFAKE%014x%s", counter, hex.EncodeToString(b)[:14]))
+	return defaultIDGenerator.SubAccountSID()
 }
 
 // NewPhoneNumberSID generates a new Incoming Phone Number SID (PNFAKE prefix, 34 chars total)
 func NewPhoneNumberSID() SID {
-	counter := atomic.AddUint64(&phoneNumberCounter, 1)
-	b := make([]byte, 7)
-	rand.Read(b)
-	return SID(fmt.Sprintf("PNFAKE%014x%s", counter, hex.EncodeToString(b)[:14]))
+	return defaultIDGenerator.PhoneNumberSID()
 }
 
 // NewRecordingSID generates a new Recording SID (REFAKE prefix, 34 chars total)
 func NewRecordingSID() SID {
-	counter := atomic.AddUint64(&recordingCounter, 1)
-	b := make([]byte, 7)
-	rand.Read(b)
-	return SID(fmt.Sprintf("REFAKE%014x%s", counter, hex.EncodeToString(b)[:14]))
+	return defaultIDGenerator.RecordingSID()
 }
 
-// NewAddressSID generates a new Address SID (ADFAKE prefix, 34 chars total)
-func NewAddressSID() SID {
-	counter := atomic.AddUint64(&addressCounter, 1)
-	b := make([]byte, 7)
-	rand.Read(b)
-	return SID(fmt.Sprintf("ADFAKE%014x%s", counter, hex.EncodeToString(b)[:14]))
-}
-
-// NewSigningKeySID generates a new Signing Key SID (SKFAKE prefix, 34 chars total)
-func NewSigningKeySID() string {
-	counter := atomic.AddUint64(&signingKeyCounter, 1)
-	b := make([]byte, 7)
-	rand.Read(b)
-	return fmt.Sprintf("SKFAKE%014x%s", counter, hex.EncodeToString(b)[:14])
+// NewStreamSID generates a new media Stream SID (MZFAKE prefix, 34 chars total)
+func NewStreamSID() SID {
+	return defaultIDGenerator.StreamSID()
 }
 
-// NewSigningKeySecret generates a pseudo-random secret for signing keys
-func NewSigningKeySecret() string {
-	b := make([]byte, 32)
-	rand.Read(b)
-	return hex.EncodeToString(b)
+// NewTranscriptionSID generates a new Transcription SID (TRFAKE prefix, 34 chars total)
+func NewTranscriptionSID() SID {
+	return defaultIDGenerator.TranscriptionSID()
 }
 
-// NewAuthToken generates a pseudo-random auth token for subaccounts
-func NewAuthToken() string {
-	b := make([]byte, 16)
-	rand.Read(b)
-	return hex.EncodeToString(b)
+// NewAddressSID generates a new Address SID (ADFAKE prefix, 34 chars total)
+func NewAddressSID() SID {
+	return defaultIDGenerator.AddressSID()
 }
 
 // NewSipDomainSID generates a new SIP Domain SID (SDFAKE prefix, 34 chars total)
 func NewSipDomainSID() SID {
-	counter := atomic.AddUint64(&sipDomainCounter, 1)
-	b := make([]byte, 7)
-	rand.Read(b)
-	return SID(fmt.Sprintf("SDFAKE%014x%s", counter, hex.EncodeToString(b)[:14]))
+	return defaultIDGenerator.SipDomainSID()
 }
 
 // NewSipCredentialListSID generates a new SIP Credential List SID (CLFAKE prefix, 34 chars total)
 func NewSipCredentialListSID() SID {
-	counter := atomic.AddUint64(&sipCredentialListCounter, 1)
-	b := make([]byte, 7)
-	rand.Read(b)
-	return SID(fmt.Sprintf("CLFAKE%014x%s", counter, hex.EncodeToString(b)[:14]))
+	return defaultIDGenerator.SipCredentialListSID()
 }
 
 // NewSipCredentialSID generates a new SIP Credential SID (CRFAKE prefix, 34 chars total)
 func NewSipCredentialSID() SID {
-	counter := atomic.AddUint64(&sipCredentialCounter, 1)
-	b := make([]byte, 7)
-	rand.Read(b)
-	return SID(fmt.Sprintf("CRFAKE%014x%s", counter, hex.EncodeToString(b)[:14]))
+	return defaultIDGenerator.SipCredentialSID()
 }
 
 // NewSipAuthCallsMappingSID generates a new SIP Auth Calls Mapping SID (CMFAKE prefix, 34 chars total)
 func NewSipAuthCallsMappingSID() SID {
-	counter := atomic.AddUint64(&sipAuthCallsMappingCounter, 1)
-	b := make([]byte, 7)
-	rand.Read(b)
-	return SID(fmt.Sprintf("CMFAKE%014x%s", counter, hex.EncodeToString(b)[:14]))
+	return defaultIDGenerator.SipAuthCallsMappingSID()
 }
 
 // NewSipAuthRegistrationsMappingSID generates a new SIP Auth Registrations Mapping SID (RMFAKE prefix, 34 chars total)
 func NewSipAuthRegistrationsMappingSID() SID {
-	counter := atomic.AddUint64(&sipAuthRegistrationsMappingCounter, 1)
-	b := make([]byte, 7)
-	rand.Read(b)
-	return SID(fmt.Sprintf("RMFAKE%014x%s", counter, hex.EncodeToString(b)[:14]))
+	return defaultIDGenerator.SipAuthRegistrationsMappingSID()
+}
+
+// NewMessageSID generates a new Message SID (SMFAKE prefix for SMS, MMFAKE for MMS, 34 chars total)
+func NewMessageSID(mms bool) SID {
+	return defaultIDGenerator.MessageSID(mms)
+}
+
+// NewSigningKeySID generates a new Signing Key SID (SKFAKE prefix, 34 chars total)
+func NewSigningKeySID() string {
+	return defaultIDGenerator.SigningKeySID()
+}
+
+// NewSigningKeySecret generates a pseudo-random secret for signing keys
+func NewSigningKeySecret() string {
+	return defaultIDGenerator.SigningKeySecret()
+}
+
+// NewAuthToken generates a pseudo-random auth token for subaccounts
+func NewAuthToken() string {
+	return defaultIDGenerator.AuthToken()
 }
 
 // NewEvent creates a new timeline event
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package restserver
+
+import (
+	"net/http"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+
+	"github.com/sprucehealth/twimulator/model"
+)
+
+func (s *Server) routeAccounts() {
+	s.handle("POST /Accounts", s.createAccount)
+	s.handle("GET /Accounts", s.listAccounts)
+	s.handle("GET /Accounts/{AccountSid}", s.fetchAccount)
+	s.mux.HandleFunc("/", s.unknownRoute)
+}
+
+func (s *Server) createAccount(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.CreateAccountParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	acct, err := s.engine.CreateAccount(params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, acct)
+}
+
+func (s *Server) listAccounts(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.ListAccountParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	accounts, err := s.engine.ListAccount(params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	accounts, p, ok := paginate(w, r, accounts)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, &twilioopenapi.ListAccountResponse{
+		Accounts:        accounts,
+		End:             p.end,
+		FirstPageUri:    p.firstPageURI,
+		NextPageUri:     p.nextPageURI,
+		Page:            p.page,
+		PageSize:        p.pageSize,
+		PreviousPageUri: p.previousPageURI,
+		Start:           p.start,
+		Uri:             p.uri,
+	})
+}
+
+func (s *Server) fetchAccount(w http.ResponseWriter, r *http.Request) {
+	sid := r.PathValue("AccountSid")
+	accounts, err := s.engine.ListAccount(&twilioopenapi.ListAccountParams{})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	for _, acct := range accounts {
+		if acct.Sid != nil && *acct.Sid == sid {
+			writeJSON(w, http.StatusOK, &acct)
+			return
+		}
+	}
+	writeError(w, notFound(model.SID(sid)))
+}
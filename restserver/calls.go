@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package restserver
+
+import (
+	"net/http"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+)
+
+func (s *Server) routeCalls() {
+	s.handle("POST /Accounts/{AccountSid}/Calls", s.createCall)
+	s.handle("GET /Accounts/{AccountSid}/Calls", s.listCalls)
+	s.handle("GET /Accounts/{AccountSid}/Calls/{Sid}", s.fetchCall)
+	s.handle("POST /Accounts/{AccountSid}/Calls/{Sid}", s.updateCall)
+	s.handle("GET /Accounts/{AccountSid}/Calls/{CallSid}/Recordings", s.listCallRecordings)
+	s.handle("POST /Accounts/{AccountSid}/Calls/{CallSid}/Recordings", s.createCallRecording)
+	s.handle("POST /Accounts/{AccountSid}/Calls/{CallSid}/Recordings/{Sid}", s.updateCallRecording)
+}
+
+func (s *Server) createCall(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.CreateCallParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	call, err := s.engine.CreateCall(params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, call)
+}
+
+func (s *Server) listCalls(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.ListCallParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	calls, err := s.engine.ListCall(params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	calls, p, ok := paginate(w, r, calls)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, &twilioopenapi.ListCallResponse{
+		Calls:           calls,
+		End:             p.end,
+		FirstPageUri:    p.firstPageURI,
+		NextPageUri:     p.nextPageURI,
+		Page:            p.page,
+		PageSize:        p.pageSize,
+		PreviousPageUri: p.previousPageURI,
+		Start:           p.start,
+		Uri:             p.uri,
+	})
+}
+
+func (s *Server) fetchCall(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.FetchCallParams{}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	call, err := s.engine.FetchCall(r.PathValue("Sid"), params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, call)
+}
+
+func (s *Server) updateCall(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.UpdateCallParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	call, err := s.engine.UpdateCall(r.PathValue("Sid"), params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, call)
+}
+
+func (s *Server) listCallRecordings(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.ListCallRecordingParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	recordings, err := s.engine.ListCallRecording(r.PathValue("CallSid"), params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	recordings, p, ok := paginate(w, r, recordings)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, &twilioopenapi.ListCallRecordingResponse{
+		Recordings:      recordings,
+		End:             p.end,
+		FirstPageUri:    p.firstPageURI,
+		NextPageUri:     p.nextPageURI,
+		Page:            p.page,
+		PageSize:        p.pageSize,
+		PreviousPageUri: p.previousPageURI,
+		Start:           p.start,
+		Uri:             p.uri,
+	})
+}
+
+func (s *Server) createCallRecording(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.CreateCallRecordingParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	recording, err := s.engine.CreateCallRecording(r.PathValue("CallSid"), params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, recording)
+}
+
+func (s *Server) updateCallRecording(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.UpdateCallRecordingParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	recording, err := s.engine.UpdateCallRecording(r.PathValue("CallSid"), r.PathValue("Sid"), params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, recording)
+}
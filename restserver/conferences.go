@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package restserver
+
+import (
+	"net/http"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+)
+
+func (s *Server) routeConferences() {
+	s.handle("GET /Accounts/{AccountSid}/Conferences", s.listConferences)
+	s.handle("GET /Accounts/{AccountSid}/Conferences/{Sid}", s.fetchConference)
+	s.handle("POST /Accounts/{AccountSid}/Conferences/{Sid}", s.updateConference)
+	s.handle("GET /Accounts/{AccountSid}/Conferences/{ConferenceSid}/Participants", s.listParticipants)
+	s.handle("POST /Accounts/{AccountSid}/Conferences/{ConferenceSid}/Participants", s.createParticipant)
+	s.handle("GET /Accounts/{AccountSid}/Conferences/{ConferenceSid}/Participants/{CallSid}", s.fetchParticipant)
+	s.handle("POST /Accounts/{AccountSid}/Conferences/{ConferenceSid}/Participants/{CallSid}", s.updateParticipant)
+	s.handle("DELETE /Accounts/{AccountSid}/Conferences/{ConferenceSid}/Participants/{CallSid}", s.deleteParticipant)
+}
+
+func (s *Server) listConferences(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.ListConferenceParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	conferences, err := s.engine.ListConference(params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	conferences, p, ok := paginate(w, r, conferences)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, &twilioopenapi.ListConferenceResponse{
+		Conferences:     conferences,
+		End:             p.end,
+		FirstPageUri:    p.firstPageURI,
+		NextPageUri:     p.nextPageURI,
+		Page:            p.page,
+		PageSize:        p.pageSize,
+		PreviousPageUri: p.previousPageURI,
+		Start:           p.start,
+		Uri:             p.uri,
+	})
+}
+
+func (s *Server) fetchConference(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.FetchConferenceParams{}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	conf, err := s.engine.FetchConference(r.PathValue("Sid"), params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, conf)
+}
+
+func (s *Server) updateConference(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.UpdateConferenceParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	conf, err := s.engine.UpdateConference(r.PathValue("Sid"), params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, conf)
+}
+
+func (s *Server) fetchParticipant(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.FetchParticipantParams{}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	participant, err := s.engine.FetchParticipant(r.PathValue("ConferenceSid"), r.PathValue("CallSid"), params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, participant)
+}
+
+func (s *Server) updateParticipant(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.UpdateParticipantParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	participant, err := s.engine.UpdateParticipant(r.PathValue("ConferenceSid"), r.PathValue("CallSid"), params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, participant)
+}
+
+func (s *Server) listParticipants(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.ListParticipantParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	participants, err := s.engine.ListParticipant(r.PathValue("ConferenceSid"), params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	participants, p, ok := paginate(w, r, participants)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, &twilioopenapi.ListParticipantResponse{
+		Participants:    participants,
+		End:             p.end,
+		FirstPageUri:    p.firstPageURI,
+		NextPageUri:     p.nextPageURI,
+		Page:            p.page,
+		PageSize:        p.pageSize,
+		PreviousPageUri: p.previousPageURI,
+		Start:           p.start,
+		Uri:             p.uri,
+	})
+}
+
+func (s *Server) createParticipant(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.CreateParticipantParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	participant, err := s.engine.CreateParticipant(r.PathValue("ConferenceSid"), params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, participant)
+}
+
+func (s *Server) deleteParticipant(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.DeleteParticipantParams{}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	if err := s.engine.DeleteParticipant(r.PathValue("ConferenceSid"), r.PathValue("CallSid"), params); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
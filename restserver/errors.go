@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package restserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/twilio/twilio-go/client"
+
+	"github.com/sprucehealth/twimulator/model"
+)
+
+const (
+	// ErrorCodeInvalidParameter is returned for malformed or missing parameters
+	ErrorCodeInvalidParameter = 20001
+	// ErrorCodeAuthenticationFailed is returned when credentials are missing or wrong
+	ErrorCodeAuthenticationFailed = 20003
+	// ErrorCodeMethodNotAllowed is returned for methods a resource does not support
+	ErrorCodeMethodNotAllowed = 20004
+	// ErrorCodeResourceNotFound is returned for unknown resources and routes
+	ErrorCodeResourceNotFound = 20404
+)
+
+func moreInfo(code int) string {
+	return fmt.Sprintf("https://www.twilio.com/docs/errors/%d", code)
+}
+
+func writeJSONError(w http.ResponseWriter, status, code int, message string) {
+	writeJSON(w, status, &client.TwilioRestError{
+		Code:     code,
+		Message:  message,
+		MoreInfo: moreInfo(code),
+		Status:   status,
+	})
+}
+
+func notFound(sid model.SID) error {
+	return &client.TwilioRestError{
+		Code:    ErrorCodeResourceNotFound,
+		Message: fmt.Sprintf("The requested resource %s was not found", sid),
+		Status:  http.StatusNotFound,
+	}
+}
+
+// writeError converts an engine error into a Twilio error body. Engine
+// TwilioRestErrors keep their code; anything else is a bad request.
+func writeError(w http.ResponseWriter, err error) {
+	var restErr *client.TwilioRestError
+	if errors.As(err, &restErr) {
+		status := restErr.Status
+		if status < 400 || status > 599 {
+			status = statusForCode(restErr.Code)
+		}
+		out := *restErr
+		out.Status = status
+		if out.MoreInfo == "" {
+			out.MoreInfo = moreInfo(out.Code)
+		}
+		writeJSON(w, status, &out)
+		return
+	}
+	writeJSONError(w, http.StatusBadRequest, ErrorCodeInvalidParameter, err.Error())
+}
+
+// statusForCode derives an HTTP status from Twilio codes of the form 20XXX
+// where XXX is the HTTP status.
+func statusForCode(code int) int {
+	if status := code % 1000; code/1000 == 20 && status >= 400 && status <= 599 {
+		return status
+	}
+	return http.StatusBadRequest
+}
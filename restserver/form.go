@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package restserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// decodeForm fills the pointer fields of a twilio-go params struct from form
+// values, keyed by each field's json tag. Path parameters are never read from
+// the form.
+func decodeForm(form url.Values, dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("decodeForm requires a pointer to a struct")
+	}
+	v = v.Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := formName(field)
+		if name == "" || strings.HasPrefix(name, "Path") {
+			continue
+		}
+		values, ok := form[name]
+		if !ok || len(values) == 0 {
+			continue
+		}
+		if field.Type.Kind() != reflect.Pointer {
+			continue
+		}
+		target := reflect.New(field.Type.Elem())
+		if err := setValue(target.Elem(), values); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+		v.Field(i).Set(target)
+	}
+	return nil
+}
+
+func formName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "-" {
+		return ""
+	}
+	// The generated params escape comparison operators in their tags
+	name = strings.ReplaceAll(name, "&lt;", "<")
+	name = strings.ReplaceAll(name, "&gt;", ">")
+	return name
+}
+
+func setValue(v reflect.Value, values []string) error {
+	raw := values[0]
+	switch {
+	case v.Type() == timeType:
+		ts, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			ts, err = time.Parse("2006-01-02", raw)
+			if err != nil {
+				return err
+			}
+		}
+		v.Set(reflect.ValueOf(ts))
+		return nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		v.Set(reflect.ValueOf(append([]string(nil), values...)))
+		return nil
+	case v.Kind() == reflect.Map:
+		m := reflect.New(v.Type())
+		if err := json.Unmarshal([]byte(raw), m.Interface()); err != nil {
+			return err
+		}
+		v.Set(m.Elem())
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	default:
+		// Nested structs (e.g. capabilities) are not accepted as form input
+		return nil
+	}
+	return nil
+}
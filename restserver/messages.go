@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package restserver
+
+import (
+	"net/http"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+)
+
+func (s *Server) routeMessages() {
+	s.handle("POST /Accounts/{AccountSid}/Messages", s.createMessage)
+	s.handle("GET /Accounts/{AccountSid}/Messages", s.listMessages)
+	s.handle("GET /Accounts/{AccountSid}/Messages/{Sid}", s.fetchMessage)
+	s.handle("POST /Accounts/{AccountSid}/Messages/{Sid}", s.updateMessage)
+}
+
+func (s *Server) createMessage(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.CreateMessageParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	msg, err := s.engine.CreateMessage(params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, msg)
+}
+
+func (s *Server) listMessages(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.ListMessageParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	messages, err := s.engine.ListMessage(params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	messages, p, ok := paginate(w, r, messages)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, &twilioopenapi.ListMessageResponse{
+		Messages:        messages,
+		End:             p.end,
+		FirstPageUri:    p.firstPageURI,
+		NextPageUri:     p.nextPageURI,
+		Page:            p.page,
+		PageSize:        p.pageSize,
+		PreviousPageUri: p.previousPageURI,
+		Start:           p.start,
+		Uri:             p.uri,
+	})
+}
+
+func (s *Server) fetchMessage(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.FetchMessageParams{}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	msg, err := s.engine.FetchMessage(r.PathValue("Sid"), params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, msg)
+}
+
+func (s *Server) updateMessage(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.UpdateMessageParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	msg, err := s.engine.UpdateMessage(r.PathValue("Sid"), params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, msg)
+}
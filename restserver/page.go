@@ -0,0 +1,99 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package restserver
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 1000
+	// pageTokenPrefix starts the PageToken of next and previous page URIs.
+	// The rest of the token is the offset of the page's first item.
+	pageTokenPrefix = "PT"
+)
+
+// page describes one page of a list response selected by the PageSize, Page
+// and PageToken parameters
+type page struct {
+	uri             string
+	firstPageURI    string
+	nextPageURI     *string
+	previousPageURI *string
+	page            int
+	pageSize        int
+	start           int
+	end             int
+}
+
+// paginate returns the items on the requested page. It writes an error and
+// returns false when the paging parameters are invalid. The items must be in
+// the same order on every request for the pages to line up.
+func paginate[T any](w http.ResponseWriter, r *http.Request, items []T) ([]T, page, bool) {
+	p, err := newPage(r, len(items))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrorCodeInvalidParameter, err.Error())
+		return nil, page{}, false
+	}
+	from := min(p.start, len(items))
+	to := min(p.start+p.pageSize, len(items))
+	return items[from:to], p, true
+}
+
+func newPage(r *http.Request, count int) (page, error) {
+	query := r.URL.Query()
+	p := page{pageSize: defaultPageSize}
+	if v := query.Get("PageSize"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < 1 || size > maxPageSize {
+			return page{}, fmt.Errorf("PageSize must be between 1 and %d", maxPageSize)
+		}
+		p.pageSize = size
+	}
+	if v := query.Get("Page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return page{}, fmt.Errorf("Page must be a non-negative integer")
+		}
+		p.page = n
+	}
+	p.start = p.page * p.pageSize
+	if v := query.Get("PageToken"); v != "" {
+		offset, err := strconv.Atoi(strings.TrimPrefix(v, pageTokenPrefix))
+		if err != nil || !strings.HasPrefix(v, pageTokenPrefix) || offset < 0 {
+			return page{}, fmt.Errorf("invalid PageToken %q", v)
+		}
+		p.start = offset
+	}
+	p.end = max(min(p.start+p.pageSize, count)-1, p.start)
+
+	path := r.URL.Path + ".json"
+	pageURI := func(n, start int) string {
+		q := query
+		q.Set("PageSize", strconv.Itoa(p.pageSize))
+		q.Set("Page", strconv.Itoa(n))
+		if start > 0 {
+			q.Set("PageToken", pageTokenPrefix+strconv.Itoa(start))
+		} else {
+			q.Del("PageToken")
+		}
+		return path + "?" + q.Encode()
+	}
+	p.uri = pageURI(p.page, p.start)
+	p.firstPageURI = pageURI(0, 0)
+	if p.start+p.pageSize < count {
+		next := pageURI(p.page+1, p.start+p.pageSize)
+		p.nextPageURI = &next
+	}
+	if p.page > 0 && p.start > 0 {
+		previous := pageURI(p.page-1, max(p.start-p.pageSize, 0))
+		p.previousPageURI = &previous
+	}
+	return p, nil
+}
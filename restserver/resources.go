@@ -0,0 +1,208 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package restserver
+
+import (
+	"net/http"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+)
+
+func (s *Server) routeResources() {
+	s.handle("POST /Accounts/{AccountSid}/IncomingPhoneNumbers", s.createIncomingPhoneNumber)
+	s.handle("GET /Accounts/{AccountSid}/IncomingPhoneNumbers", s.listIncomingPhoneNumbers)
+	s.handle("POST /Accounts/{AccountSid}/IncomingPhoneNumbers/{Sid}", s.updateIncomingPhoneNumber)
+	s.handle("DELETE /Accounts/{AccountSid}/IncomingPhoneNumbers/{Sid}", s.deleteIncomingPhoneNumber)
+	s.handle("POST /Accounts/{AccountSid}/Applications", s.createApplication)
+	s.handle("POST /Accounts/{AccountSid}/Queues", s.createQueue)
+	s.handle("POST /Accounts/{AccountSid}/Addresses", s.createAddress)
+	s.handle("POST /Accounts/{AccountSid}/SigningKeys", s.createSigningKey)
+	s.handle("GET /Accounts/{AccountSid}/Recordings", s.listRecordings)
+	s.handle("GET /Accounts/{AccountSid}/Recordings/{Sid}", s.fetchRecording)
+	s.handle("DELETE /Accounts/{AccountSid}/Recordings/{Sid}", s.deleteRecording)
+	s.handle("GET /Accounts/{AccountSid}/Transcriptions/{Sid}", s.fetchTranscription)
+}
+
+func (s *Server) createIncomingPhoneNumber(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.CreateIncomingPhoneNumberParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	number, err := s.engine.CreateIncomingPhoneNumber(params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, number)
+}
+
+func (s *Server) listIncomingPhoneNumbers(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.ListIncomingPhoneNumberParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	numbers, err := s.engine.ListIncomingPhoneNumber(params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	numbers, p, ok := paginate(w, r, numbers)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, &twilioopenapi.ListIncomingPhoneNumberResponse{
+		IncomingPhoneNumbers: numbers,
+		End:                  p.end,
+		FirstPageUri:         p.firstPageURI,
+		NextPageUri:          p.nextPageURI,
+		Page:                 p.page,
+		PageSize:             p.pageSize,
+		PreviousPageUri:      p.previousPageURI,
+		Start:                p.start,
+		Uri:                  p.uri,
+	})
+}
+
+func (s *Server) updateIncomingPhoneNumber(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.UpdateIncomingPhoneNumberParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	number, err := s.engine.UpdateIncomingPhoneNumber(r.PathValue("Sid"), params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, number)
+}
+
+func (s *Server) deleteIncomingPhoneNumber(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.DeleteIncomingPhoneNumberParams{}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	if err := s.engine.DeleteIncomingPhoneNumber(r.PathValue("Sid"), params); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) createApplication(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.CreateApplicationParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	app, err := s.engine.CreateApplication(params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, app)
+}
+
+func (s *Server) createQueue(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.CreateQueueParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	queue, err := s.engine.CreateQueue(params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, queue)
+}
+
+func (s *Server) createAddress(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.CreateAddressParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	address, err := s.engine.CreateAddress(params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, address)
+}
+
+func (s *Server) createSigningKey(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.CreateNewSigningKeyParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	key, err := s.engine.CreateNewSigningKey(params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, key)
+}
+
+func (s *Server) fetchRecording(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.FetchRecordingParams{}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	recording, err := s.engine.FetchRecording(r.PathValue("Sid"), params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, recording)
+}
+
+func (s *Server) listRecordings(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.ListRecordingParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	recordings, err := s.engine.ListRecording(params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	recordings, p, ok := paginate(w, r, recordings)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, &twilioopenapi.ListRecordingResponse{
+		Recordings:      recordings,
+		End:             p.end,
+		FirstPageUri:    p.firstPageURI,
+		NextPageUri:     p.nextPageURI,
+		Page:            p.page,
+		PageSize:        p.pageSize,
+		PreviousPageUri: p.previousPageURI,
+		Start:           p.start,
+		Uri:             p.uri,
+	})
+}
+
+func (s *Server) deleteRecording(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.DeleteRecordingParams{}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	if err := s.engine.DeleteRecording(r.PathValue("Sid"), params); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) fetchTranscription(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.FetchTranscriptionParams{}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	transcription, err := s.engine.FetchTranscription(r.PathValue("Sid"), params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, transcription)
+}
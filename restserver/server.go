@@ -0,0 +1,186 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+// Package restserver exposes an engine over HTTP using Twilio's REST API
+// shapes, so that unmodified Twilio SDKs can be pointed at the simulator.
+package restserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+
+	"github.com/sprucehealth/twimulator/engine"
+)
+
+const apiPrefix = "/2010-04-01"
+
+// Server serves the Twilio 2010-04-01 REST API backed by an engine
+type Server struct {
+	Addr         string
+	engine       engine.Engine
+	server       *http.Server
+	mux          *http.ServeMux
+	masterSID    string
+	masterSecret string
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithMasterCredentials sets credentials that may access every account,
+// including listing and creating accounts. Without them the top level
+// /Accounts endpoints reject every request.
+func WithMasterCredentials(username, password string) Option {
+	return func(s *Server) {
+		s.masterSID = username
+		s.masterSecret = password
+	}
+}
+
+// NewServer creates a REST server for the engine listening on addr
+func NewServer(addr string, e engine.Engine, opts ...Option) *Server {
+	s := &Server{
+		Addr:   addr,
+		engine: e,
+		mux:    http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.routeAccounts()
+	s.routeCalls()
+	s.routeMessages()
+	s.routeConferences()
+	s.routeResources()
+	s.routeSip()
+
+	s.server = &http.Server{
+		Addr:    addr,
+		Handler: s,
+	}
+	return s
+}
+
+// Start starts the REST server
+func (s *Server) Start() error {
+	log.Printf("Twilio REST API running at http://localhost%s", s.Addr)
+	return s.server.ListenAndServe()
+}
+
+// Stop gracefully stops the server
+func (s *Server) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// ServeHTTP authenticates the request and dispatches it to the API routes
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Twilio resources are addressed with a .json suffix which the route
+	// patterns leave out.
+	r2 := r.Clone(r.Context())
+	r2.URL.Path = strings.TrimSuffix(r.URL.Path, ".json")
+	r2.URL.RawPath = ""
+
+	if !s.authorized(r2) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="Twilio API"`)
+		writeJSONError(w, http.StatusUnauthorized, ErrorCodeAuthenticationFailed, "Authentication Error - invalid username")
+		return
+	}
+
+	s.mux.ServeHTTP(w, r2)
+}
+
+func (s *Server) handle(pattern string, h http.HandlerFunc) {
+	method, path, _ := strings.Cut(pattern, " ")
+	s.mux.HandleFunc(method+" "+apiPrefix+path, h)
+}
+
+// unknownRoute answers requests no route matches. A known resource requested
+// with a method it does not support gets 405 and its allowed methods.
+func (s *Server) unknownRoute(w http.ResponseWriter, r *http.Request) {
+	var allowed []string
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodDelete} {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+		if _, pattern := s.mux.Handler(probe); pattern != "/" {
+			allowed = append(allowed, method)
+		}
+	}
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeJSONError(w, http.StatusMethodNotAllowed, ErrorCodeMethodNotAllowed, "Method "+r.Method+" not allowed on "+r.URL.Path)
+		return
+	}
+	writeJSONError(w, http.StatusNotFound, ErrorCodeResourceNotFound, "The requested resource "+r.URL.Path+" was not found")
+}
+
+// authorized checks basic auth credentials against the account in the path.
+// A subaccount may only access its own resources.
+func (s *Server) authorized(r *http.Request) bool {
+	user, pass, ok := r.BasicAuth()
+	if s.masterSID != "" && ok && secureEqual(user, s.masterSID) && secureEqual(pass, s.masterSecret) {
+		return true
+	}
+
+	accountSID := accountFromPath(r.URL.Path)
+	if accountSID == "" {
+		// Only master credentials may list and create accounts
+		return false
+	}
+	if !ok || user != accountSID {
+		return false
+	}
+
+	accounts, err := s.engine.ListAccount(&twilioopenapi.ListAccountParams{})
+	if err != nil {
+		return false
+	}
+	for _, acct := range accounts {
+		if acct.Sid != nil && *acct.Sid == accountSID {
+			return acct.AuthToken != nil && secureEqual(pass, *acct.AuthToken)
+		}
+	}
+	return false
+}
+
+func accountFromPath(path string) string {
+	rest, ok := strings.CutPrefix(path, apiPrefix+"/Accounts/")
+	if !ok {
+		return ""
+	}
+	sid, _, _ := strings.Cut(rest, "/")
+	return sid
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("restserver: failed to encode response: %v", err)
+	}
+}
+
+// parseForm decodes the request parameters into the Twilio params struct dst.
+// GET and DELETE requests carry parameters in the query string.
+func parseForm(w http.ResponseWriter, r *http.Request, dst any) bool {
+	if err := r.ParseForm(); err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrorCodeInvalidParameter, err.Error())
+		return false
+	}
+	if err := decodeForm(r.Form, dst); err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrorCodeInvalidParameter, err.Error())
+		return false
+	}
+	return true
+}
@@ -0,0 +1,327 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package restserver_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/twilio/twilio-go"
+	"github.com/twilio/twilio-go/client"
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+
+	"github.com/sprucehealth/twimulator/engine"
+	"github.com/sprucehealth/twimulator/httpstub"
+	"github.com/sprucehealth/twimulator/restserver"
+)
+
+// rewriteTransport sends requests meant for api.twilio.com to the test server
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func newSDKClient(t *testing.T, serverURL, username, password string) *twilio.RestClient {
+	t.Helper()
+	target, err := url.Parse(serverURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &client.Client{
+		Credentials: client.NewCredentials(username, password),
+		HTTPClient:  &http.Client{Transport: &rewriteTransport{target: target}},
+	}
+	c.SetAccountSid(username)
+	return twilio.NewRestClientWithParams(twilio.ClientParams{Client: c})
+}
+
+func newTestServer(t *testing.T) (engine.Engine, *httptest.Server) {
+	t.Helper()
+	mock := httpstub.NewMockWebhookClient()
+	e := engine.NewEngine(engine.WithManualClock(), engine.WithWebhookClient(mock))
+	t.Cleanup(func() { e.Close() })
+
+	ts := httptest.NewServer(restserver.NewServer("", e))
+	t.Cleanup(ts.Close)
+	return e, ts
+}
+
+func TestSDKCallLifecycle(t *testing.T) {
+	e, ts := newTestServer(t)
+
+	acct, err := e.CreateAccount((&twilioopenapi.CreateAccountParams{}).SetFriendlyName("REST"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := newSDKClient(t, ts.URL, *acct.Sid, *acct.AuthToken).Api
+
+	if _, err := api.CreateIncomingPhoneNumber((&twilioopenapi.CreateIncomingPhoneNumberParams{}).SetPhoneNumber("+15550001111")); err != nil {
+		t.Fatalf("create number failed: %v", err)
+	}
+
+	call, err := api.CreateCall((&twilioopenapi.CreateCallParams{}).
+		SetFrom("+15550001111").
+		SetTo("+15552223333").
+		SetUrl("http://example.com/voice"))
+	if err != nil {
+		t.Fatalf("create call failed: %v", err)
+	}
+	if call.Sid == nil || call.Status == nil {
+		t.Fatalf("unexpected call response: %+v", call)
+	}
+
+	fetched, err := api.FetchCall(*call.Sid, nil)
+	if err != nil {
+		t.Fatalf("fetch call failed: %v", err)
+	}
+	if *fetched.To != "+15552223333" {
+		t.Errorf("expected To +15552223333, got %s", *fetched.To)
+	}
+
+	calls, err := api.ListCall((&twilioopenapi.ListCallParams{}).SetTo("+15552223333"))
+	if err != nil {
+		t.Fatalf("list calls failed: %v", err)
+	}
+	if len(calls) != 1 || *calls[0].Sid != *call.Sid {
+		t.Fatalf("expected 1 call, got %d", len(calls))
+	}
+
+	updated, err := api.UpdateCall(*call.Sid, (&twilioopenapi.UpdateCallParams{}).SetStatus("canceled"))
+	if err != nil {
+		t.Fatalf("update call failed: %v", err)
+	}
+	if updated.Status == nil || *updated.Status != "canceled" {
+		t.Errorf("expected canceled, got %v", updated.Status)
+	}
+}
+
+func TestSDKMessages(t *testing.T) {
+	e, ts := newTestServer(t)
+
+	acct, err := e.CreateAccount((&twilioopenapi.CreateAccountParams{}).SetFriendlyName("REST"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := newSDKClient(t, ts.URL, *acct.Sid, *acct.AuthToken).Api
+
+	if _, err := api.CreateIncomingPhoneNumber((&twilioopenapi.CreateIncomingPhoneNumberParams{}).SetPhoneNumber("+15550001111")); err != nil {
+		t.Fatalf("create number failed: %v", err)
+	}
+
+	msg, err := api.CreateMessage((&twilioopenapi.CreateMessageParams{}).
+		SetFrom("+15550001111").
+		SetTo("+15552223333").
+		SetBody("Hello").
+		SetMediaUrl([]string{"https://example.com/a.png", "https://example.com/b.png"}))
+	if err != nil {
+		t.Fatalf("create message failed: %v", err)
+	}
+	if msg.NumMedia == nil || *msg.NumMedia != "2" {
+		t.Errorf("expected 2 media, got %v", msg.NumMedia)
+	}
+
+	fetched, err := api.FetchMessage(*msg.Sid, nil)
+	if err != nil {
+		t.Fatalf("fetch message failed: %v", err)
+	}
+	if *fetched.Body != "Hello" {
+		t.Errorf("expected body Hello, got %s", *fetched.Body)
+	}
+
+	messages, err := api.ListMessage((&twilioopenapi.ListMessageParams{}).SetFrom("+15550001111"))
+	if err != nil {
+		t.Fatalf("list messages failed: %v", err)
+	}
+	if len(messages) != 1 || *messages[0].Sid != *msg.Sid {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+}
+
+func TestSDKErrors(t *testing.T) {
+	e, ts := newTestServer(t)
+
+	acct, err := e.CreateAccount((&twilioopenapi.CreateAccountParams{}).SetFriendlyName("REST"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := e.CreateAccount((&twilioopenapi.CreateAccountParams{}).SetFriendlyName("Other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Wrong token
+	_, err = newSDKClient(t, ts.URL, *acct.Sid, "bad").Api.ListCall(nil)
+	var restErr *client.TwilioRestError
+	if !errors.As(err, &restErr) || restErr.Code != restserver.ErrorCodeAuthenticationFailed || restErr.Status != http.StatusUnauthorized {
+		t.Errorf("expected authentication error, got %v", err)
+	}
+
+	// Credentials for a different account
+	api := newSDKClient(t, ts.URL, *acct.Sid, *acct.AuthToken).Api
+	_, err = api.ListCall(&twilioopenapi.ListCallParams{PathAccountSid: other.Sid})
+	if !errors.As(err, &restErr) || restErr.Status != http.StatusUnauthorized {
+		t.Errorf("expected authentication error for other account, got %v", err)
+	}
+
+	// Unknown call
+	_, err = api.FetchCall("CA00000000000000000000000000000000", nil)
+	if !errors.As(err, &restErr) || restErr.Code != restserver.ErrorCodeResourceNotFound || restErr.Status != http.StatusNotFound {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	// Missing parameters
+	_, err = api.CreateCall((&twilioopenapi.CreateCallParams{}).SetTo("+15552223333"))
+	if !errors.As(err, &restErr) || restErr.Status != http.StatusBadRequest {
+		t.Errorf("expected bad request, got %v", err)
+	}
+}
+
+func TestSDKPagination(t *testing.T) {
+	e, ts := newTestServer(t)
+
+	acct, err := e.CreateAccount((&twilioopenapi.CreateAccountParams{}).SetFriendlyName("REST"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := newSDKClient(t, ts.URL, *acct.Sid, *acct.AuthToken).Api
+
+	if _, err := api.CreateIncomingPhoneNumber((&twilioopenapi.CreateIncomingPhoneNumberParams{}).SetPhoneNumber("+15550001111")); err != nil {
+		t.Fatalf("create number failed: %v", err)
+	}
+	created := make(map[string]bool)
+	for i := 0; i < 5; i++ {
+		msg, err := api.CreateMessage((&twilioopenapi.CreateMessageParams{}).
+			SetFrom("+15550001111").
+			SetTo("+15552223333").
+			SetBody("Hello"))
+		if err != nil {
+			t.Fatalf("create message failed: %v", err)
+		}
+		created[*msg.Sid] = true
+	}
+
+	// The SDK follows next_page_uri until every message is listed
+	messages, err := api.ListMessage((&twilioopenapi.ListMessageParams{}).SetPageSize(2))
+	if err != nil {
+		t.Fatalf("list messages failed: %v", err)
+	}
+	listed := make(map[string]bool)
+	for _, msg := range messages {
+		listed[*msg.Sid] = true
+	}
+	if len(messages) != 5 || len(listed) != 5 {
+		t.Fatalf("expected 5 distinct messages, got %d (%d distinct)", len(messages), len(listed))
+	}
+	for sid := range created {
+		if !listed[sid] {
+			t.Errorf("message %s was not listed", sid)
+		}
+	}
+
+	// Limit stops after the first page that reaches it
+	messages, err = api.ListMessage((&twilioopenapi.ListMessageParams{}).SetPageSize(2).SetLimit(3))
+	if err != nil {
+		t.Fatalf("list messages failed: %v", err)
+	}
+	if len(messages) != 3 {
+		t.Errorf("expected 3 messages, got %d", len(messages))
+	}
+
+	// An invalid page size is rejected
+	_, err = api.ListMessage((&twilioopenapi.ListMessageParams{}).SetPageSize(0))
+	var restErr *client.TwilioRestError
+	if !errors.As(err, &restErr) || restErr.Status != http.StatusBadRequest {
+		t.Errorf("expected bad request for page size 0, got %v", err)
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	e, ts := newTestServer(t)
+
+	acct, err := e.CreateAccount((&twilioopenapi.CreateAccountParams{}).SetFriendlyName("REST"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := newSDKClient(t, ts.URL, *acct.Sid, *acct.AuthToken).Api
+
+	// Queues can only be created
+	_, err = api.ListQueue(nil)
+	var restErr *client.TwilioRestError
+	if !errors.As(err, &restErr) || restErr.Code != restserver.ErrorCodeMethodNotAllowed || restErr.Status != http.StatusMethodNotAllowed {
+		t.Errorf("expected method not allowed, got %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/2010-04-01/Accounts/"+*acct.Sid+"/SIP/Domains.json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetBasicAuth(*acct.Sid, *acct.AuthToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed || resp.Header.Get("Allow") != http.MethodPost {
+		t.Errorf("expected 405 allowing POST, got %d allowing %q", resp.StatusCode, resp.Header.Get("Allow"))
+	}
+
+	// Unknown resources are still not found
+	req, err = http.NewRequest(http.MethodGet, ts.URL+"/2010-04-01/Accounts/"+*acct.Sid+"/Unknown.json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetBasicAuth(*acct.Sid, *acct.AuthToken)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown resource, got %d", resp.StatusCode)
+	}
+}
+
+func TestAccountsRequireMasterCredentials(t *testing.T) {
+	mock := httpstub.NewMockWebhookClient()
+	e := engine.NewEngine(engine.WithManualClock(), engine.WithWebhookClient(mock))
+	t.Cleanup(func() { e.Close() })
+
+	acct, err := e.CreateAccount((&twilioopenapi.CreateAccountParams{}).SetFriendlyName("REST"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without master credentials nobody may list accounts
+	ts := httptest.NewServer(restserver.NewServer("", e))
+	t.Cleanup(ts.Close)
+	_, err = newSDKClient(t, ts.URL, *acct.Sid, *acct.AuthToken).Api.ListAccount(nil)
+	var restErr *client.TwilioRestError
+	if !errors.As(err, &restErr) || restErr.Status != http.StatusUnauthorized {
+		t.Errorf("expected authentication error without master credentials, got %v", err)
+	}
+
+	master := httptest.NewServer(restserver.NewServer("", e, restserver.WithMasterCredentials("ACmaster", "secret")))
+	t.Cleanup(master.Close)
+	_, err = newSDKClient(t, master.URL, *acct.Sid, *acct.AuthToken).Api.ListAccount(nil)
+	if !errors.As(err, &restErr) || restErr.Status != http.StatusUnauthorized {
+		t.Errorf("expected authentication error for subaccount credentials, got %v", err)
+	}
+	accounts, err := newSDKClient(t, master.URL, "ACmaster", "secret").Api.ListAccount(nil)
+	if err != nil {
+		t.Fatalf("list accounts failed: %v", err)
+	}
+	if len(accounts) != 1 || *accounts[0].Sid != *acct.Sid {
+		t.Errorf("expected the one account, got %d", len(accounts))
+	}
+}
@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package restserver
+
+import (
+	"net/http"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+)
+
+func (s *Server) routeSip() {
+	s.handle("POST /Accounts/{AccountSid}/SIP/Domains", s.createSipDomain)
+	s.handle("GET /Accounts/{AccountSid}/SIP/CredentialLists", s.listSipCredentialLists)
+	s.handle("POST /Accounts/{AccountSid}/SIP/CredentialLists", s.createSipCredentialList)
+	s.handle("GET /Accounts/{AccountSid}/SIP/CredentialLists/{CredentialListSid}/Credentials", s.listSipCredentials)
+	s.handle("POST /Accounts/{AccountSid}/SIP/CredentialLists/{CredentialListSid}/Credentials", s.createSipCredential)
+	s.handle("GET /Accounts/{AccountSid}/SIP/Domains/{DomainSid}/Auth/Calls/CredentialListMappings", s.listSipAuthCallsMappings)
+	s.handle("POST /Accounts/{AccountSid}/SIP/Domains/{DomainSid}/Auth/Calls/CredentialListMappings", s.createSipAuthCallsMapping)
+	s.handle("POST /Accounts/{AccountSid}/SIP/Domains/{DomainSid}/Auth/Registrations/CredentialListMappings", s.createSipAuthRegistrationsMapping)
+}
+
+func (s *Server) createSipDomain(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.CreateSipDomainParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	domain, err := s.engine.CreateSipDomain(params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, domain)
+}
+
+func (s *Server) listSipCredentialLists(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.ListSipCredentialListParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	lists, err := s.engine.ListSipCredentialList(params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	lists, p, ok := paginate(w, r, lists)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, &twilioopenapi.ListSipCredentialListResponse{
+		CredentialLists: lists,
+		End:             p.end,
+		FirstPageUri:    p.firstPageURI,
+		NextPageUri:     p.nextPageURI,
+		Page:            p.page,
+		PageSize:        p.pageSize,
+		PreviousPageUri: p.previousPageURI,
+		Start:           p.start,
+		Uri:             p.uri,
+	})
+}
+
+func (s *Server) createSipCredentialList(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.CreateSipCredentialListParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	list, err := s.engine.CreateSipCredentialList(params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, list)
+}
+
+func (s *Server) listSipCredentials(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.ListSipCredentialParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	creds, err := s.engine.ListSipCredential(r.PathValue("CredentialListSid"), params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	creds, p, ok := paginate(w, r, creds)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, &twilioopenapi.ListSipCredentialResponse{
+		Credentials:     creds,
+		End:             p.end,
+		FirstPageUri:    p.firstPageURI,
+		NextPageUri:     p.nextPageURI,
+		Page:            p.page,
+		PageSize:        p.pageSize,
+		PreviousPageUri: p.previousPageURI,
+		Start:           p.start,
+		Uri:             p.uri,
+	})
+}
+
+func (s *Server) createSipCredential(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.CreateSipCredentialParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	cred, err := s.engine.CreateSipCredential(r.PathValue("CredentialListSid"), params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, cred)
+}
+
+func (s *Server) listSipAuthCallsMappings(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.ListSipAuthCallsCredentialListMappingParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	resp, err := s.engine.PageSipAuthCallsCredentialListMapping(r.PathValue("DomainSid"), params, r.Form.Get("PageToken"), r.Form.Get("Page"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	contents, p, ok := paginate(w, r, resp.Contents)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, &twilioopenapi.ListSipAuthCallsCredentialListMappingResponse{
+		Contents:        contents,
+		End:             p.end,
+		FirstPageUri:    p.firstPageURI,
+		NextPageUri:     p.nextPageURI,
+		Page:            p.page,
+		PageSize:        p.pageSize,
+		PreviousPageUri: p.previousPageURI,
+		Start:           p.start,
+		Uri:             p.uri,
+	})
+}
+
+func (s *Server) createSipAuthCallsMapping(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.CreateSipAuthCallsCredentialListMappingParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	mapping, err := s.engine.CreateSipAuthCallsCredentialListMapping(r.PathValue("DomainSid"), params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, mapping)
+}
+
+func (s *Server) createSipAuthRegistrationsMapping(w http.ResponseWriter, r *http.Request) {
+	params := &twilioopenapi.CreateSipAuthRegistrationsCredentialListMappingParams{}
+	if !parseForm(w, r, params) {
+		return
+	}
+	params.SetPathAccountSid(r.PathValue("AccountSid"))
+	mapping, err := s.engine.CreateSipAuthRegistrationsCredentialListMapping(r.PathValue("DomainSid"), params)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, mapping)
+}
@@ -151,6 +151,56 @@ func (c *Client) FetchCall(sid string, params *twilioopenapi.FetchCallParams) (*
 	return c.engine.FetchCall(sid, params)
 }
 
+// ListCall returns calls for the account, most recent first
+func (c *Client) ListCall(params *twilioopenapi.ListCallParams) ([]twilioopenapi.ApiV2010Call, error) {
+	if params == nil {
+		params = &twilioopenapi.ListCallParams{}
+	}
+	params.PathAccountSid = &c.subaccountSID
+	return c.engine.ListCall(params)
+}
+
+// CreateMessage sends an SMS or MMS from the account
+func (c *Client) CreateMessage(params *twilioopenapi.CreateMessageParams) (*twilioopenapi.ApiV2010Message, error) {
+	if params == nil {
+		params = &twilioopenapi.CreateMessageParams{}
+	}
+	params.PathAccountSid = &c.subaccountSID
+	return c.engine.CreateMessage(params)
+}
+
+// FetchMessage retrieves a message by SID
+func (c *Client) FetchMessage(sid string, params *twilioopenapi.FetchMessageParams) (*twilioopenapi.ApiV2010Message, error) {
+	if params == nil {
+		params = &twilioopenapi.FetchMessageParams{}
+	}
+	params.PathAccountSid = &c.subaccountSID
+	return c.engine.FetchMessage(sid, params)
+}
+
+// ListMessage returns messages for the account, most recent first
+func (c *Client) ListMessage(params *twilioopenapi.ListMessageParams) ([]twilioopenapi.ApiV2010Message, error) {
+	if params == nil {
+		params = &twilioopenapi.ListMessageParams{}
+	}
+	params.PathAccountSid = &c.subaccountSID
+	return c.engine.ListMessage(params)
+}
+
+// UpdateMessage redacts a message body or cancels a scheduled message
+func (c *Client) UpdateMessage(sid string, params *twilioopenapi.UpdateMessageParams) (*twilioopenapi.ApiV2010Message, error) {
+	if params == nil {
+		params = &twilioopenapi.UpdateMessageParams{}
+	}
+	params.PathAccountSid = &c.subaccountSID
+	return c.engine.UpdateMessage(sid, params)
+}
+
+// CreateIncomingMessage simulates an inbound SMS or MMS to one of the account's numbers
+func (c *Client) CreateIncomingMessage(from string, to string, body string, mediaURLs ...string) (*twilioopenapi.ApiV2010Message, error) {
+	return c.engine.CreateIncomingMessage(model.SID(c.subaccountSID), from, to, body, mediaURLs...)
+}
+
 // FetchConference retrieves a conference by SID
 func (c *Client) FetchConference(sid string, params *twilioopenapi.FetchConferenceParams) (*twilioopenapi.ApiV2010Conference, error) {
 	if params == nil {
@@ -196,6 +246,33 @@ func (c *Client) UpdateParticipant(conferenceSid string, callSid string, params
 	return c.engine.UpdateParticipant(conferenceSid, callSid, params)
 }
 
+// CreateParticipant dials a new participant into a conference
+func (c *Client) CreateParticipant(conferenceSid string, params *twilioopenapi.CreateParticipantParams) (*twilioopenapi.ApiV2010Participant, error) {
+	if params == nil {
+		params = &twilioopenapi.CreateParticipantParams{}
+	}
+	params.PathAccountSid = &c.subaccountSID
+	return c.engine.CreateParticipant(conferenceSid, params)
+}
+
+// ListParticipant lists the participants of a conference
+func (c *Client) ListParticipant(conferenceSid string, params *twilioopenapi.ListParticipantParams) ([]twilioopenapi.ApiV2010Participant, error) {
+	if params == nil {
+		params = &twilioopenapi.ListParticipantParams{}
+	}
+	params.PathAccountSid = &c.subaccountSID
+	return c.engine.ListParticipant(conferenceSid, params)
+}
+
+// DeleteParticipant removes a participant from a conference
+func (c *Client) DeleteParticipant(conferenceSid string, callSid string, params *twilioopenapi.DeleteParticipantParams) error {
+	if params == nil {
+		params = &twilioopenapi.DeleteParticipantParams{}
+	}
+	params.PathAccountSid = &c.subaccountSID
+	return c.engine.DeleteParticipant(conferenceSid, callSid, params)
+}
+
 // FetchRecording retrieves a recording by SID
 func (c *Client) FetchRecording(sid string, params *twilioopenapi.FetchRecordingParams) (*twilioopenapi.ApiV2010Recording, error) {
 	if params == nil {
@@ -205,6 +282,60 @@ func (c *Client) FetchRecording(sid string, params *twilioopenapi.FetchRecording
 	return c.engine.FetchRecording(sid, params)
 }
 
+// ListRecording returns the recordings of the client's subaccount
+func (c *Client) ListRecording(params *twilioopenapi.ListRecordingParams) ([]twilioopenapi.ApiV2010Recording, error) {
+	if params == nil {
+		params = &twilioopenapi.ListRecordingParams{}
+	}
+	params.PathAccountSid = &c.subaccountSID
+	return c.engine.ListRecording(params)
+}
+
+// DeleteRecording deletes a recording by SID
+func (c *Client) DeleteRecording(sid string, params *twilioopenapi.DeleteRecordingParams) error {
+	if params == nil {
+		params = &twilioopenapi.DeleteRecordingParams{}
+	}
+	params.PathAccountSid = &c.subaccountSID
+	return c.engine.DeleteRecording(sid, params)
+}
+
+// CreateCallRecording starts recording an in-progress call
+func (c *Client) CreateCallRecording(callSid string, params *twilioopenapi.CreateCallRecordingParams) (*twilioopenapi.ApiV2010CallRecording, error) {
+	if params == nil {
+		params = &twilioopenapi.CreateCallRecordingParams{}
+	}
+	params.PathAccountSid = &c.subaccountSID
+	return c.engine.CreateCallRecording(callSid, params)
+}
+
+// UpdateCallRecording pauses, resumes or stops a call recording
+func (c *Client) UpdateCallRecording(callSid string, sid string, params *twilioopenapi.UpdateCallRecordingParams) (*twilioopenapi.ApiV2010CallRecording, error) {
+	if params == nil {
+		params = &twilioopenapi.UpdateCallRecordingParams{}
+	}
+	params.PathAccountSid = &c.subaccountSID
+	return c.engine.UpdateCallRecording(callSid, sid, params)
+}
+
+// ListCallRecording returns the recordings of a call
+func (c *Client) ListCallRecording(callSid string, params *twilioopenapi.ListCallRecordingParams) ([]twilioopenapi.ApiV2010CallRecording, error) {
+	if params == nil {
+		params = &twilioopenapi.ListCallRecordingParams{}
+	}
+	params.PathAccountSid = &c.subaccountSID
+	return c.engine.ListCallRecording(callSid, params)
+}
+
+// FetchTranscription retrieves a transcription by SID
+func (c *Client) FetchTranscription(sid string, params *twilioopenapi.FetchTranscriptionParams) (*twilioopenapi.ApiV2010Transcription, error) {
+	if params == nil {
+		params = &twilioopenapi.FetchTranscriptionParams{}
+	}
+	params.PathAccountSid = &c.subaccountSID
+	return c.engine.FetchTranscription(sid, params)
+}
+
 // AnswerCall explicitly answers a ringing call
 func (c *Client) AnswerCall(sid model.SID) error {
 	return c.engine.AnswerCall(model.SID(c.subaccountSID), sid)
@@ -263,6 +394,12 @@ func (c *Client) GetRecording(recordingSID model.SID) (*model.Recording, error)
 	return c.engine.GetRecording(model.SID(c.subaccountSID), recordingSID)
 }
 
+// SetRecordingTranscription sets the text a recording transcribes to
+// status: "completed" or "failed"
+func (c *Client) SetRecordingTranscription(recordingSID model.SID, text string, status string) error {
+	return c.engine.SetRecordingTranscription(model.SID(c.subaccountSID), recordingSID, text, status)
+}
+
 // CreateSipDomain creates a new SIP domain for the client's subaccount
 func (c *Client) CreateSipDomain(params *twilioopenapi.CreateSipDomainParams) (*twilioopenapi.ApiV2010SipDomain, error) {
 	if params == nil {
@@ -62,7 +62,13 @@ type Gather struct {
 	Hints         string
 	SpeechTimeout string // Can be "auto" or a positive integer (in seconds)
 	SpeechModel   string
-	Children      []Node // Nested verbs to execute while gathering
+	Language      string // Speech recognition language, default is "en-US"
+	// PartialResultCallback receives interim speech results while the caller is speaking
+	PartialResultCallback       string
+	PartialResultCallbackMethod string
+	// ActionOnEmptyResult requests the action even when the caller gave no input
+	ActionOnEmptyResult bool
+	Children            []Node // Nested verbs to execute while gathering
 }
 
 func (Gather) isNode() {}
@@ -80,6 +86,13 @@ type Dial struct {
 	CallerId                string
 	Record                  string // "do-not-record", "record-from-answer", "record-from-ringing", "record-from-answer-dual", "record-from-ringing-dual"
 	RecordingStatusCallback string
+	Trim                    string        // "trim-silence" or "do-not-trim", default is "do-not-trim"
+	TimeLimit               time.Duration // Maximum length of the bridged call, default is 4 hours
+	AnswerOnBridge          bool          // Keep an unanswered caller ringing until the dialed party answers
+	RingTone                string        // Ringback tone played while the caller is kept ringing
+	Sequential              bool          // Dial the nested nouns one at a time instead of all at once
+	ReferURL                string
+	ReferMethod             string
 	Children                []Node // For nested <Number>, <Client>, <Queue>, <Conference>
 }
 
@@ -100,6 +113,19 @@ type Enqueue struct {
 
 func (Enqueue) isNode() {}
 
+// Message sends an SMS or MMS, also parsed from the legacy <Sms> verb
+type Message struct {
+	To             string
+	From           string
+	Action         string
+	Method         string // "POST" or "GET"
+	StatusCallback string
+	Body           string
+	Media          []string // Media URLs from nested <Media> elements
+}
+
+func (Message) isNode() {}
+
 // Redirect fetches new TwiML from a URL
 type Redirect struct {
 	URL    string
@@ -122,12 +148,18 @@ func (Reject) isNode() {}
 
 // Record records the caller's voice
 type Record struct {
-	MaxLength        time.Duration
-	PlayBeep         bool
-	Action           string
-	Method           string
-	Transcribe       bool
-	TimeoutInSeconds time.Duration
+	MaxLength                     time.Duration
+	PlayBeep                      bool
+	Action                        string
+	Method                        string
+	Transcribe                    bool
+	TranscribeCallback            string
+	TimeoutInSeconds              time.Duration
+	FinishOnKey                   string
+	Trim                          string // "trim-silence" or "do-not-trim"
+	RecordingStatusCallback       string
+	RecordingStatusCallbackMethod string
+	RecordingStatusCallbackEvent  string // space-separated: in-progress, completed, absent
 }
 
 func (Record) isNode() {}
@@ -165,7 +197,60 @@ func (c Client) ChildNodes() []Node {
 	return c.Children
 }
 
-// Parameter is used inside <Client> to pass custom key-value pairs
+// Connect hands the call to a bidirectional media stream until the stream ends
+type Connect struct {
+	Action   string
+	Method   string // "POST" or "GET"
+	Children []Node // For nested <Stream>
+}
+
+func (Connect) isNode() {}
+
+func (c Connect) ChildNodes() []Node {
+	return c.Children
+}
+
+// Start forks the call audio to media streams while TwiML execution continues
+type Start struct {
+	Action   string
+	Method   string // "POST" or "GET"
+	Children []Node // For nested <Stream>
+}
+
+func (Start) isNode() {}
+
+func (s Start) ChildNodes() []Node {
+	return s.Children
+}
+
+// Stop ends media streams begun with <Start>
+type Stop struct {
+	Children []Node // For nested <Stream>
+}
+
+func (Stop) isNode() {}
+
+func (s Stop) ChildNodes() []Node {
+	return s.Children
+}
+
+// Stream is a Media Streams WebSocket used inside <Connect>, <Start> or <Stop>
+type Stream struct {
+	Name                 string
+	URL                  string
+	Track                string // "inbound_track", "outbound_track" or "both_tracks"
+	StatusCallback       string
+	StatusCallbackMethod string
+	Children             []Node // For nested <Parameter>
+}
+
+func (Stream) isNode() {}
+
+func (s Stream) ChildNodes() []Node {
+	return s.Children
+}
+
+// Parameter is used inside <Client> or <Stream> to pass custom key-value pairs
 type Parameter struct {
 	Name  string
 	Value string
@@ -193,6 +278,7 @@ type Conference struct {
 	StatusCallbackEvent     string
 	Record                  string
 	RecordingStatusCallback string
+	Coach                   string // SID of the call this participant coaches
 }
 
 func (Conference) isNode() {}
@@ -99,6 +99,14 @@ func parseNode(decoder *xml.Decoder, start *xml.StartElement) (Node, error) {
 		return parseReject(decoder, start)
 	case "Record":
 		return parseRecord(decoder, start)
+	case "Message", "Sms":
+		return parseMessage(decoder, start)
+	case "Connect":
+		return parseConnect(decoder, start)
+	case "Start":
+		return parseStart(decoder, start)
+	case "Stop":
+		return parseStop(decoder, start)
 	case "Number":
 		return parseNumber(decoder, start)
 	case "Sip":
@@ -194,7 +202,7 @@ func parseGather(decoder *xml.Decoder, start *xml.StartElement) (*Gather, error)
 		Input:       "dtmf",
 		Timeout:     "5", // Default timeout is 5 seconds
 		NumDigits:   0,
-		FinishOnKey: "",
+		FinishOnKey: "#",
 		Method:      "POST",
 	}
 
@@ -228,6 +236,14 @@ func parseGather(decoder *xml.Decoder, start *xml.StartElement) (*Gather, error)
 			gather.SpeechTimeout = attr.Value
 		case "speechModel":
 			gather.SpeechModel = attr.Value
+		case "language":
+			gather.Language = attr.Value
+		case "partialResultCallback":
+			gather.PartialResultCallback = attr.Value
+		case "partialResultCallbackMethod":
+			gather.PartialResultCallbackMethod = strings.ToUpper(attr.Value)
+		case "actionOnEmptyResult":
+			gather.ActionOnEmptyResult = attr.Value == "true"
 		default:
 			if attr.Value != "" {
 				return nil, fmt.Errorf("unknown attribute '%s' on <Gather>", attr.Name.Local)
@@ -264,10 +280,22 @@ func parseGather(decoder *xml.Decoder, start *xml.StartElement) (*Gather, error)
 	return gather, nil
 }
 
+// ringTones are the country codes accepted by the ringTone attribute of <Dial>
+var ringTones = map[string]bool{
+	"at": true, "au": true, "bg": true, "br": true, "be": true, "ch": true, "cl": true, "cn": true,
+	"cz": true, "de": true, "dk": true, "ee": true, "es": true, "fi": true, "fr": true, "gr": true,
+	"hu": true, "il": true, "in": true, "it": true, "lt": true, "jp": true, "mx": true, "my": true,
+	"nl": true, "no": true, "nz": true, "ph": true, "pl": true, "pt": true, "ru": true, "se": true,
+	"sg": true, "th": true, "uk": true, "us": true, "us-old": true, "tw": true, "ve": true, "za": true,
+}
+
 func parseDial(decoder *xml.Decoder, start *xml.StartElement) (*Dial, error) {
 	dial := &Dial{
-		Method:  "POST",
-		Timeout: 30 * time.Second,
+		Method:      "POST",
+		Timeout:     30 * time.Second,
+		TimeLimit:   4 * time.Hour,
+		Trim:        "do-not-trim",
+		ReferMethod: "POST",
 	}
 
 	for _, attr := range start.Attr {
@@ -306,6 +334,30 @@ func parseDial(decoder *xml.Decoder, start *xml.StartElement) (*Dial, error) {
 			}
 		case "recordingStatusCallback":
 			dial.RecordingStatusCallback = attr.Value
+		case "trim":
+			if attr.Value != "trim-silence" && attr.Value != "do-not-trim" {
+				return nil, fmt.Errorf("invalid trim '%s' on <Dial>: must be trim-silence or do-not-trim", attr.Value)
+			}
+			dial.Trim = attr.Value
+		case "timeLimit":
+			n, err := strconv.Atoi(attr.Value)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid timeLimit '%s' on <Dial>: must be a positive number of seconds", attr.Value)
+			}
+			dial.TimeLimit = time.Duration(n) * time.Second
+		case "answerOnBridge":
+			dial.AnswerOnBridge = attr.Value == "true"
+		case "ringTone":
+			if !ringTones[attr.Value] {
+				return nil, fmt.Errorf("invalid ringTone '%s' on <Dial>", attr.Value)
+			}
+			dial.RingTone = attr.Value
+		case "sequential":
+			dial.Sequential = attr.Value == "true"
+		case "referUrl":
+			dial.ReferURL = attr.Value
+		case "referMethod":
+			dial.ReferMethod = strings.ToUpper(attr.Value)
 		default:
 			if attr.Value != "" {
 				return nil, fmt.Errorf("unknown attribute '%s' on <Dial>", attr.Name.Local)
@@ -400,6 +452,242 @@ func parseRedirect(decoder *xml.Decoder, start *xml.StartElement) (*Redirect, er
 	return redirect, nil
 }
 
+func parseMessage(decoder *xml.Decoder, start *xml.StartElement) (*Message, error) {
+	msg := &Message{Method: "POST"}
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "to":
+			msg.To = attr.Value
+		case "from":
+			msg.From = attr.Value
+		case "action":
+			msg.Action = attr.Value
+		case "method":
+			msg.Method = strings.ToUpper(attr.Value)
+		case "statusCallback":
+			msg.StatusCallback = attr.Value
+		default:
+			if attr.Value != "" {
+				return nil, fmt.Errorf("unknown attribute '%s' on <%s>", attr.Name.Local, start.Name.Local)
+			}
+		}
+	}
+
+	// The body is either the element text or a nested <Body>, optionally
+	// alongside <Media> elements
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "Body":
+				if err := decoder.DecodeElement(&msg.Body, &t); err != nil {
+					return nil, err
+				}
+			case "Media":
+				var mediaURL string
+				if err := decoder.DecodeElement(&mediaURL, &t); err != nil {
+					return nil, err
+				}
+				msg.Media = append(msg.Media, strings.TrimSpace(mediaURL))
+			default:
+				return nil, fmt.Errorf("<%s> cannot contain <%s>", start.Name.Local, t.Name.Local)
+			}
+		case xml.EndElement:
+			if t.Name.Local == start.Name.Local {
+				if msg.Body == "" {
+					msg.Body = text.String()
+				}
+				msg.Body = strings.TrimSpace(msg.Body)
+				return msg, nil
+			}
+		}
+	}
+
+	return msg, nil
+}
+
+func parseConnect(decoder *xml.Decoder, start *xml.StartElement) (*Connect, error) {
+	connect := &Connect{
+		Method: "POST",
+	}
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "action":
+			connect.Action = attr.Value
+		case "method":
+			connect.Method = strings.ToUpper(attr.Value)
+		default:
+			if attr.Value != "" {
+				return nil, fmt.Errorf("unknown attribute '%s' on <Connect>", attr.Name.Local)
+			}
+		}
+	}
+
+	children, err := parseStreams(decoder, "Connect")
+	if err != nil {
+		return nil, err
+	}
+	if len(children) != 1 {
+		return nil, fmt.Errorf("<Connect> requires exactly one <Stream>")
+	}
+	connect.Children = children
+	return connect, nil
+}
+
+func parseStart(decoder *xml.Decoder, start *xml.StartElement) (*Start, error) {
+	st := &Start{
+		Method: "POST",
+	}
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "action":
+			st.Action = attr.Value
+		case "method":
+			st.Method = strings.ToUpper(attr.Value)
+		default:
+			if attr.Value != "" {
+				return nil, fmt.Errorf("unknown attribute '%s' on <Start>", attr.Name.Local)
+			}
+		}
+	}
+
+	children, err := parseStreams(decoder, "Start")
+	if err != nil {
+		return nil, err
+	}
+	if len(children) == 0 {
+		return nil, fmt.Errorf("<Start> requires a <Stream>")
+	}
+	st.Children = children
+	return st, nil
+}
+
+func parseStop(decoder *xml.Decoder, start *xml.StartElement) (*Stop, error) {
+	for _, attr := range start.Attr {
+		if attr.Value != "" {
+			return nil, fmt.Errorf("unknown attribute '%s' on <Stop>", attr.Name.Local)
+		}
+	}
+
+	children, err := parseStreams(decoder, "Stop")
+	if err != nil {
+		return nil, err
+	}
+	return &Stop{Children: children}, nil
+}
+
+// parseStreams reads the <Stream> children of <Connect>, <Start> or <Stop>
+func parseStreams(decoder *xml.Decoder, parent string) ([]Node, error) {
+	var children []Node
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local != "Stream" {
+				return nil, fmt.Errorf("unknown element '<%s>' inside <%s>", t.Name.Local, parent)
+			}
+			stream, err := parseStream(decoder, &t, parent)
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, stream)
+		case xml.EndElement:
+			if t.Name.Local == parent {
+				return children, nil
+			}
+		}
+	}
+	return children, nil
+}
+
+func parseStream(decoder *xml.Decoder, start *xml.StartElement, parent string) (*Stream, error) {
+	stream := &Stream{
+		StatusCallbackMethod: "POST",
+	}
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "name":
+			stream.Name = attr.Value
+		case "url":
+			stream.URL = attr.Value
+		case "track":
+			stream.Track = attr.Value
+		case "statusCallback":
+			stream.StatusCallback = attr.Value
+		case "statusCallbackMethod":
+			stream.StatusCallbackMethod = strings.ToUpper(attr.Value)
+		default:
+			if attr.Value != "" {
+				return nil, fmt.Errorf("unknown attribute '%s' on <Stream>", attr.Name.Local)
+			}
+		}
+	}
+
+	switch stream.Track {
+	case "":
+		if parent != "Stop" {
+			stream.Track = "inbound_track"
+		}
+	case "inbound_track", "outbound_track", "both_tracks":
+		if parent == "Connect" && stream.Track != "inbound_track" {
+			return nil, fmt.Errorf("track '%s' is not supported on bidirectional <Stream>", stream.Track)
+		}
+	default:
+		return nil, fmt.Errorf("invalid track '%s' on <Stream>", stream.Track)
+	}
+	if parent != "Stop" && stream.URL == "" {
+		return nil, fmt.Errorf("<Stream> requires a url")
+	}
+	if parent == "Stop" && stream.Name == "" {
+		return nil, fmt.Errorf("<Stream> inside <Stop> requires a name")
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local != "Parameter" {
+				return nil, fmt.Errorf("unknown element '<%s>' inside <Stream>", t.Name.Local)
+			}
+			param, err := parseParameter(decoder, &t)
+			if err != nil {
+				return nil, err
+			}
+			stream.Children = append(stream.Children, param)
+		case xml.EndElement:
+			if t.Name.Local == "Stream" {
+				return stream, nil
+			}
+		}
+	}
+	return stream, nil
+}
+
 func parseNumber(decoder *xml.Decoder, start *xml.StartElement) (*Number, error) {
 	num := &Number{}
 	for _, attr := range start.Attr {
@@ -564,6 +852,8 @@ func parseConferenceDial(decoder *xml.Decoder, start *xml.StartElement) (*Confer
 			conf.Record = attr.Value
 		case "recordingStatusCallback":
 			conf.RecordingStatusCallback = attr.Value
+		case "coach":
+			conf.Coach = attr.Value
 		default:
 			if attr.Value != "" {
 				return nil, fmt.Errorf("unknown attribute '%s' on <Conference>", attr.Name.Local)
@@ -580,11 +870,15 @@ func parseConferenceDial(decoder *xml.Decoder, start *xml.StartElement) (*Confer
 
 func parseRecord(decoder *xml.Decoder, start *xml.StartElement) (*Record, error) {
 	record := &Record{
-		MaxLength:        3600 * time.Second, // default 1 hour
-		PlayBeep:         true,               // default true
-		Method:           "POST",
-		Transcribe:       false,
-		TimeoutInSeconds: 5 * time.Second, // default 5 seconds
+		MaxLength:                     3600 * time.Second, // default 1 hour
+		PlayBeep:                      true,               // default true
+		Method:                        "POST",
+		Transcribe:                    false,
+		TimeoutInSeconds:              5 * time.Second, // default 5 seconds
+		FinishOnKey:                   "1234567890*#",
+		Trim:                          "trim-silence",
+		RecordingStatusCallbackMethod: "POST",
+		RecordingStatusCallbackEvent:  "completed",
 	}
 
 	for _, attr := range start.Attr {
@@ -605,6 +899,30 @@ func parseRecord(decoder *xml.Decoder, start *xml.StartElement) (*Record, error)
 			if n, err := strconv.Atoi(attr.Value); err == nil {
 				record.TimeoutInSeconds = time.Duration(n) * time.Second
 			}
+		case "transcribeCallback":
+			// A transcribe callback implies transcription
+			record.TranscribeCallback = attr.Value
+			if attr.Value != "" {
+				record.Transcribe = true
+			}
+		case "finishOnKey":
+			record.FinishOnKey = attr.Value
+		case "trim":
+			if attr.Value != "trim-silence" && attr.Value != "do-not-trim" {
+				return nil, fmt.Errorf("invalid trim '%s' on <Record>: must be 'trim-silence' or 'do-not-trim'", attr.Value)
+			}
+			record.Trim = attr.Value
+		case "recordingStatusCallback":
+			record.RecordingStatusCallback = attr.Value
+		case "recordingStatusCallbackMethod":
+			record.RecordingStatusCallbackMethod = strings.ToUpper(attr.Value)
+		case "recordingStatusCallbackEvent":
+			for _, event := range strings.Fields(attr.Value) {
+				if event != "in-progress" && event != "completed" && event != "absent" {
+					return nil, fmt.Errorf("invalid recordingStatusCallbackEvent '%s' on <Record>", event)
+				}
+			}
+			record.RecordingStatusCallbackEvent = attr.Value
 		default:
 			if attr.Value != "" {
 				return nil, fmt.Errorf("unknown attribute '%s' on <Record>", attr.Name.Local)
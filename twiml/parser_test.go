@@ -192,6 +192,17 @@ func TestParseDialConference(t *testing.T) {
 	}
 }
 
+func TestParseConferenceCoach(t *testing.T) {
+	resp, err := Parse([]byte(`<Response><Dial><Conference coach="CA123" muted="true">room</Conference></Dial></Response>`))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	conf := resp.Children[0].(*Dial).Children[0].(*Conference)
+	if conf.Coach != "CA123" || !conf.Muted {
+		t.Errorf("unexpected conference %+v", conf)
+	}
+}
+
 func TestParseConferenceDialBeep(t *testing.T) {
 	xml := `<?xml version="1.0" encoding="UTF-8"?>
 <Response>
@@ -580,6 +591,70 @@ func TestParseDialRecordDefault(t *testing.T) {
 	}
 }
 
+func TestParseDialAttributes(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<Response>
+  <Dial timeLimit="600" answerOnBridge="true" ringTone="uk" sequential="true" trim="trim-silence" referUrl="/refer" referMethod="get">
+    <Number>+15551234567</Number>
+    <Number>+15557654321</Number>
+  </Dial>
+</Response>`
+
+	resp, err := Parse([]byte(xml))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	dial, ok := resp.Children[0].(*Dial)
+	if !ok {
+		t.Fatalf("Expected *Dial, got %T", resp.Children[0])
+	}
+
+	if dial.TimeLimit != 10*time.Minute {
+		t.Errorf("Expected timeLimit 10m, got %v", dial.TimeLimit)
+	}
+	if !dial.AnswerOnBridge {
+		t.Error("Expected answerOnBridge to be true")
+	}
+	if dial.RingTone != "uk" {
+		t.Errorf("Expected ringTone 'uk', got %q", dial.RingTone)
+	}
+	if !dial.Sequential {
+		t.Error("Expected sequential to be true")
+	}
+	if dial.Trim != "trim-silence" {
+		t.Errorf("Expected trim 'trim-silence', got %q", dial.Trim)
+	}
+	if dial.ReferURL != "/refer" || dial.ReferMethod != "GET" {
+		t.Errorf("Expected referUrl '/refer' with GET, got %q %q", dial.ReferURL, dial.ReferMethod)
+	}
+}
+
+func TestParseDialAttributeDefaults(t *testing.T) {
+	resp, err := Parse([]byte(`<Response><Dial>+15551234567</Dial></Response>`))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	dial := resp.Children[0].(*Dial)
+	if dial.TimeLimit != 4*time.Hour {
+		t.Errorf("Expected default timeLimit 4h, got %v", dial.TimeLimit)
+	}
+	if dial.AnswerOnBridge || dial.Sequential {
+		t.Error("Expected answerOnBridge and sequential to default to false")
+	}
+	if dial.Trim != "do-not-trim" {
+		t.Errorf("Expected default trim 'do-not-trim', got %q", dial.Trim)
+	}
+}
+
+func TestParseDialAttributesInvalid(t *testing.T) {
+	for _, attr := range []string{`timeLimit="0"`, `timeLimit="soon"`, `ringTone="mars"`, `trim="some"`} {
+		if _, err := Parse([]byte(`<Response><Dial ` + attr + `>+15551234567</Dial></Response>`)); err == nil {
+			t.Errorf("Expected error for %s, got none", attr)
+		}
+	}
+}
+
 func TestParseEnqueue(t *testing.T) {
 	xml := `<?xml version="1.0" encoding="UTF-8"?>
 <Response>
@@ -731,6 +806,30 @@ func TestParseRecord(t *testing.T) {
 	}
 }
 
+func TestParseRecordCallbacks(t *testing.T) {
+	resp, err := Parse([]byte(`<Response><Record finishOnKey="#" trim="do-not-trim" transcribeCallback="/transcribed" recordingStatusCallback="/rec-status" recordingStatusCallbackMethod="get" recordingStatusCallbackEvent="in-progress completed absent"/></Response>`))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	record := resp.Children[0].(*Record)
+	if record.FinishOnKey != "#" || record.Trim != "do-not-trim" {
+		t.Errorf("unexpected finishOnKey %q or trim %q", record.FinishOnKey, record.Trim)
+	}
+	if !record.Transcribe || record.TranscribeCallback != "/transcribed" {
+		t.Error("expected transcribeCallback to imply transcribe")
+	}
+	if record.RecordingStatusCallback != "/rec-status" || record.RecordingStatusCallbackMethod != "GET" || record.RecordingStatusCallbackEvent != "in-progress completed absent" {
+		t.Errorf("unexpected recording status callback %+v", record)
+	}
+
+	if _, err := Parse([]byte(`<Response><Record recordingStatusCallbackEvent="started"/></Response>`)); err == nil {
+		t.Error("expected error for invalid recordingStatusCallbackEvent")
+	}
+	if _, err := Parse([]byte(`<Response><Record trim="sometimes"/></Response>`)); err == nil {
+		t.Error("expected error for invalid trim")
+	}
+}
+
 func TestParseRecordDefaults(t *testing.T) {
 	xml := `<?xml version="1.0" encoding="UTF-8"?>
 <Response>
@@ -862,6 +961,33 @@ func TestParseGatherSpeechTimeout(t *testing.T) {
 	}
 }
 
+func TestParseGatherPartialResultCallback(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<Response>
+  <Gather input="speech" language="en-GB" partialResultCallback="/partial" partialResultCallbackMethod="get"/>
+</Response>`
+
+	resp, err := Parse([]byte(xml))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	gather, ok := resp.Children[0].(*Gather)
+	if !ok {
+		t.Fatalf("Expected *Gather, got %T", resp.Children[0])
+	}
+
+	if gather.Language != "en-GB" {
+		t.Errorf("Expected language 'en-GB', got %q", gather.Language)
+	}
+	if gather.PartialResultCallback != "/partial" {
+		t.Errorf("Expected partialResultCallback '/partial', got %q", gather.PartialResultCallback)
+	}
+	if gather.PartialResultCallbackMethod != "GET" {
+		t.Errorf("Expected partialResultCallbackMethod 'GET', got %q", gather.PartialResultCallbackMethod)
+	}
+}
+
 func TestParseGatherFinishOnKey(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -939,6 +1065,23 @@ func TestParseGatherDefaults(t *testing.T) {
 	if gather.Method != "POST" {
 		t.Errorf("Expected default method 'POST', got %q", gather.Method)
 	}
+	if gather.FinishOnKey != "#" {
+		t.Errorf("Expected default finishOnKey '#', got %q", gather.FinishOnKey)
+	}
+	if gather.ActionOnEmptyResult {
+		t.Error("Expected actionOnEmptyResult to default to false")
+	}
+}
+
+func TestParseGatherActionOnEmptyResult(t *testing.T) {
+	resp, err := Parse([]byte(`<Response><Gather actionOnEmptyResult="true" action="/menu"/></Response>`))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	gather := resp.Children[0].(*Gather)
+	if !gather.ActionOnEmptyResult {
+		t.Error("Expected actionOnEmptyResult to be true")
+	}
 }
 
 func TestParseUnknownAttribute(t *testing.T) {
@@ -952,3 +1095,121 @@ func TestParseUnknownAttribute(t *testing.T) {
 		t.Fatal("Expected error for unknown attribute, got none")
 	}
 }
+
+func TestParseMessage(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<Response>
+  <Message to="+15551112222" action="/sent" method="get" statusCallback="/status">
+    <Body>Your appointment is tomorrow</Body>
+    <Media>https://example.com/map.png</Media>
+  </Message>
+  <Sms>Legacy text</Sms>
+</Response>`
+
+	resp, err := Parse([]byte(xml))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if len(resp.Children) != 2 {
+		t.Fatalf("Expected 2 children, got %d", len(resp.Children))
+	}
+
+	msg, ok := resp.Children[0].(*Message)
+	if !ok {
+		t.Fatalf("Expected *Message, got %T", resp.Children[0])
+	}
+	if msg.To != "+15551112222" {
+		t.Errorf("Expected to '+15551112222', got %q", msg.To)
+	}
+	if msg.Body != "Your appointment is tomorrow" {
+		t.Errorf("Expected body, got %q", msg.Body)
+	}
+	if len(msg.Media) != 1 || msg.Media[0] != "https://example.com/map.png" {
+		t.Errorf("Expected one media URL, got %v", msg.Media)
+	}
+	if msg.Action != "/sent" || msg.Method != "GET" {
+		t.Errorf("Expected action /sent with GET, got %q %q", msg.Action, msg.Method)
+	}
+	if msg.StatusCallback != "/status" {
+		t.Errorf("Expected statusCallback '/status', got %q", msg.StatusCallback)
+	}
+
+	sms, ok := resp.Children[1].(*Message)
+	if !ok {
+		t.Fatalf("Expected <Sms> to parse as *Message, got %T", resp.Children[1])
+	}
+	if sms.Body != "Legacy text" || sms.Method != "POST" {
+		t.Errorf("Expected legacy body with POST, got %q %q", sms.Body, sms.Method)
+	}
+}
+
+func TestParseConnectAndStartStream(t *testing.T) {
+	xml := `<?xml version="1.0" encoding="UTF-8"?>
+<Response>
+  <Start>
+    <Stream name="tap" url="wss://example.com/tap" track="both_tracks"/>
+  </Start>
+  <Connect action="/after">
+    <Stream url="wss://example.com/bot" statusCallback="/stream-status">
+      <Parameter name="patient" value="42"/>
+    </Stream>
+  </Connect>
+  <Stop>
+    <Stream name="tap"/>
+  </Stop>
+</Response>`
+
+	resp, err := Parse([]byte(xml))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if len(resp.Children) != 3 {
+		t.Fatalf("Expected 3 children, got %d", len(resp.Children))
+	}
+
+	start, ok := resp.Children[0].(*Start)
+	if !ok {
+		t.Fatalf("Expected *Start, got %T", resp.Children[0])
+	}
+	tap := start.Children[0].(*Stream)
+	if tap.Name != "tap" || tap.Track != "both_tracks" {
+		t.Errorf("Unexpected <Start> stream %+v", tap)
+	}
+
+	connect, ok := resp.Children[1].(*Connect)
+	if !ok {
+		t.Fatalf("Expected *Connect, got %T", resp.Children[1])
+	}
+	if connect.Action != "/after" || connect.Method != "POST" {
+		t.Errorf("Unexpected <Connect> action %q %q", connect.Action, connect.Method)
+	}
+	bot := connect.Children[0].(*Stream)
+	if bot.URL != "wss://example.com/bot" || bot.Track != "inbound_track" || bot.StatusCallback != "/stream-status" {
+		t.Errorf("Unexpected <Connect> stream %+v", bot)
+	}
+	if param, ok := bot.Children[0].(*Parameter); !ok || param.Name != "patient" || param.Value != "42" {
+		t.Errorf("Expected patient parameter, got %+v", bot.Children)
+	}
+
+	stop, ok := resp.Children[2].(*Stop)
+	if !ok {
+		t.Fatalf("Expected *Stop, got %T", resp.Children[2])
+	}
+	if stop.Children[0].(*Stream).Name != "tap" {
+		t.Errorf("Expected <Stop> to name the tap stream")
+	}
+}
+
+func TestParseStreamErrors(t *testing.T) {
+	for _, xml := range []string{
+		`<Response><Connect></Connect></Response>`,
+		`<Response><Connect><Stream url="wss://x" track="both_tracks"/></Connect></Response>`,
+		`<Response><Start><Stream/></Start></Response>`,
+		`<Response><Stop><Stream/></Stop></Response>`,
+		`<Response><Start><Say>hi</Say></Start></Response>`,
+	} {
+		if _, err := Parse([]byte(xml)); err == nil {
+			t.Errorf("Expected error parsing %s", xml)
+		}
+	}
+}
@@ -0,0 +1,565 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+// Package twimtest provides fluent assertions on the TwiML a call executed and
+// on the webhook requests the engine made, for use in tests:
+//
+//	twimtest.ExpectCall(t, e, call.SID).
+//		Said("Press 1").
+//		ThenGathered(twimtest.WithNumDigits(1)).
+//		ThenDialedQueue("support").
+//		EndedWith(model.CallCompleted)
+//
+// Each step matches the first executed verb after the previous match, so steps
+// may skip verbs that are not asserted. Options match attributes partially:
+// attributes that are not given are ignored. Verbs nested in a <Gather> come
+// before the <Gather> itself, so a prompt is asserted before the gather.
+package twimtest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sprucehealth/twimulator/engine"
+	"github.com/sprucehealth/twimulator/model"
+	"github.com/sprucehealth/twimulator/twiml"
+)
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// Option matches one attribute of a verb
+type Option struct {
+	field string
+	want  any
+}
+
+// With matches the verb attribute named by field, which is the name of the
+// struct field in the twiml package, such as "NumDigits" or "CallerId". Numbers
+// compare with string attributes by their decimal form and integers compare
+// with duration attributes as seconds, as they are written in TwiML.
+func With(field string, want any) Option {
+	return Option{field: field, want: want}
+}
+
+// WithAction matches the action URL of a verb
+func WithAction(url string) Option { return With("Action", url) }
+
+// WithMethod matches the method of a verb
+func WithMethod(method string) Option { return With("Method", method) }
+
+// WithURL matches the URL of a verb
+func WithURL(url string) Option { return With("URL", url) }
+
+// WithTimeout matches the timeout of a verb in seconds
+func WithTimeout(seconds int) Option { return With("Timeout", seconds) }
+
+// WithVoice matches the voice of a <Say>
+func WithVoice(voice string) Option { return With("Voice", voice) }
+
+// WithLanguage matches the language of a <Say> or <Gather>
+func WithLanguage(language string) Option { return With("Language", language) }
+
+// WithLoop matches the loop count of a <Say> or <Play>
+func WithLoop(loop int) Option { return With("Loop", loop) }
+
+// WithNumDigits matches the number of digits of a <Gather>
+func WithNumDigits(n int) Option { return With("NumDigits", n) }
+
+// WithInput matches the input types of a <Gather>
+func WithInput(input string) Option { return With("Input", input) }
+
+// WithFinishOnKey matches the finish key of a <Gather> or <Record>
+func WithFinishOnKey(key string) Option { return With("FinishOnKey", key) }
+
+// WithCallerID matches the caller ID of a <Dial>
+func WithCallerID(callerID string) Option { return With("CallerId", callerID) }
+
+// String formats the option as it appears in failure messages
+func (o Option) String() string {
+	return fmt.Sprintf("%s=%s", o.field, formatValue(reflect.ValueOf(o.want)))
+}
+
+// check compares the attribute of the verb struct v and describes a mismatch
+func (o Option) check(v reflect.Value) string {
+	got := v.FieldByName(o.field)
+	if !got.IsValid() {
+		return fmt.Sprintf("%s: %s has no such attribute", o.field, v.Type().Name())
+	}
+	want, ok := convert(reflect.ValueOf(o.want), got.Type())
+	if !ok {
+		return fmt.Sprintf("%s: want %T, attribute is %s", o.field, o.want, got.Type())
+	}
+	if !reflect.DeepEqual(got.Interface(), want.Interface()) {
+		return fmt.Sprintf("%s: want %s, got %s", o.field, formatValue(want), formatValue(got))
+	}
+	return ""
+}
+
+// convert converts a wanted value to the type of the attribute it is compared with
+func convert(want reflect.Value, typ reflect.Type) (reflect.Value, bool) {
+	if !want.IsValid() {
+		return reflect.Zero(typ), true
+	}
+	switch {
+	case want.Type() == typ:
+		return want, true
+	case typ == durationType && want.CanInt():
+		return reflect.ValueOf(time.Duration(want.Int()) * time.Second), true
+	case typ.Kind() == reflect.String && want.Kind() != reflect.String:
+		return reflect.ValueOf(fmt.Sprint(want.Interface())).Convert(typ), true
+	case want.CanConvert(typ):
+		return want.Convert(typ), true
+	}
+	return want, false
+}
+
+// CallExpectation asserts on the TwiML a call executed, in order
+type CallExpectation struct {
+	t          testing.TB
+	e          engine.Engine
+	accountSID model.SID
+	sid        model.SID
+
+	// cursor is the index in the executed verbs where the next step starts
+	cursor int
+	// last describes the previous matched step
+	last   string
+	failed bool
+}
+
+// ExpectCall starts assertions on a call. The call may belong to any account of
+// the engine.
+func ExpectCall(t testing.TB, e engine.Engine, sid model.SID) *CallExpectation {
+	t.Helper()
+	x := &CallExpectation{t: t, e: e, sid: sid}
+	for _, snap := range e.SnapshotAll() {
+		if call, ok := snap.Calls[sid]; ok {
+			x.accountSID = call.AccountSID
+			return x
+		}
+	}
+	x.fail("call %s not found", sid)
+	return x
+}
+
+// entry is an executed verb, with the <Gather> it was nested in if any
+type entry struct {
+	node   any
+	parent any
+}
+
+// executed flattens the TwiML the call executed so far, placing verbs nested in
+// a <Gather> before the <Gather>
+func (x *CallExpectation) executed() []entry {
+	snap, err := x.e.Snapshot(x.accountSID)
+	if err != nil {
+		return nil
+	}
+	call := snap.Calls[x.sid]
+	if call == nil {
+		return nil
+	}
+
+	seen := make(map[any]bool)
+	var entries []entry
+	for _, node := range call.ExecutedTwiML {
+		if seen[node] {
+			// Nested verbs can also be recorded on their own
+			continue
+		}
+		if gather, ok := node.(*twiml.Gather); ok {
+			for _, child := range gather.Children {
+				seen[child] = true
+				entries = append(entries, entry{node: child, parent: gather})
+			}
+		}
+		entries = append(entries, entry{node: node})
+	}
+	return entries
+}
+
+// step matches the next executed verb of the same type as proto
+func (x *CallExpectation) step(desc string, proto any, opts []Option, extra func(node any) []string) *CallExpectation {
+	x.t.Helper()
+	if x.failed {
+		return x
+	}
+	typ := reflect.TypeOf(proto)
+	entries := x.executed()
+
+	closest := -1
+	var closestDiff []string
+	for i := x.cursor; i < len(entries); i++ {
+		node := entries[i].node
+		if reflect.TypeOf(node) != typ {
+			continue
+		}
+		v := reflect.ValueOf(node).Elem()
+		var diff []string
+		for _, opt := range opts {
+			if d := opt.check(v); d != "" {
+				diff = append(diff, d)
+			}
+		}
+		if extra != nil {
+			diff = append(diff, extra(node)...)
+		}
+		if len(diff) == 0 {
+			x.cursor = i + 1
+			x.last = desc
+			return x
+		}
+		if closest < 0 {
+			closest, closestDiff = i, diff
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "call %s: expected %s", x.sid, desc)
+	if x.last != "" {
+		fmt.Fprintf(&b, " after %s", x.last)
+	}
+	if closest >= 0 {
+		fmt.Fprintf(&b, "\nclosest match at #%d:", closest)
+		for _, d := range closestDiff {
+			fmt.Fprintf(&b, "\n    %s", d)
+		}
+	}
+	b.WriteString("\nexecuted TwiML:")
+	if len(entries) == 0 {
+		b.WriteString(" none")
+	}
+	for i, ent := range entries {
+		marker := "  "
+		if i == x.cursor {
+			marker = "> "
+		}
+		fmt.Fprintf(&b, "\n%s#%d %s", marker, i, describe(ent.node))
+		if ent.parent != nil {
+			b.WriteString(" (in Gather)")
+		}
+	}
+	x.fail("%s", b.String())
+	return x
+}
+
+func (x *CallExpectation) fail(format string, args ...any) {
+	x.t.Helper()
+	x.failed = true
+	x.t.Errorf("twimtest: "+format, args...)
+}
+
+// describeStep formats a step for failure messages
+func describeStep(verb, arg string, opts []Option) string {
+	parts := make([]string, 0, len(opts)+1)
+	if arg != "" {
+		parts = append(parts, fmt.Sprintf("%q", arg))
+	}
+	for _, opt := range opts {
+		parts = append(parts, opt.String())
+	}
+	if len(parts) == 0 {
+		return verb
+	}
+	return verb + " " + strings.Join(parts, " ")
+}
+
+// Executed expects a verb of the same type as proto, such as &twiml.Connect{}.
+// Only the attributes given as options are matched.
+func (x *CallExpectation) Executed(proto twiml.Node, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	name := reflect.TypeOf(proto).Elem().Name()
+	return x.step(describeStep(name, "", opts), proto, opts, nil)
+}
+
+// ThenExecuted is Executed for a verb after the previous step
+func (x *CallExpectation) ThenExecuted(proto twiml.Node, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.Executed(proto, opts...)
+}
+
+// Said expects a <Say> of text
+func (x *CallExpectation) Said(text string, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.step(describeStep("Say", text, opts), &twiml.Say{}, append([]Option{With("Text", text)}, opts...), nil)
+}
+
+// ThenSaid is Said for a verb after the previous step
+func (x *CallExpectation) ThenSaid(text string, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.Said(text, opts...)
+}
+
+// Played expects a <Play> of url
+func (x *CallExpectation) Played(url string, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.step(describeStep("Play", url, opts), &twiml.Play{}, append([]Option{With("URL", url)}, opts...), nil)
+}
+
+// ThenPlayed is Played for a verb after the previous step
+func (x *CallExpectation) ThenPlayed(url string, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.Played(url, opts...)
+}
+
+// Paused expects a <Pause>
+func (x *CallExpectation) Paused(opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.step(describeStep("Pause", "", opts), &twiml.Pause{}, opts, nil)
+}
+
+// ThenPaused is Paused for a verb after the previous step
+func (x *CallExpectation) ThenPaused(opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.Paused(opts...)
+}
+
+// Gathered expects a <Gather>
+func (x *CallExpectation) Gathered(opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.step(describeStep("Gather", "", opts), &twiml.Gather{}, opts, nil)
+}
+
+// ThenGathered is Gathered for a verb after the previous step
+func (x *CallExpectation) ThenGathered(opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.Gathered(opts...)
+}
+
+// Dialed expects a <Dial> of any target
+func (x *CallExpectation) Dialed(opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.step(describeStep("Dial", "", opts), &twiml.Dial{}, opts, nil)
+}
+
+// ThenDialed is Dialed for a verb after the previous step
+func (x *CallExpectation) ThenDialed(opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.Dialed(opts...)
+}
+
+// dialed expects a <Dial> with a noun whose attribute field is target
+func (x *CallExpectation) dialed(noun twiml.Node, field, target string, opts []Option) *CallExpectation {
+	x.t.Helper()
+	nounType := reflect.TypeOf(noun)
+	name := nounType.Elem().Name()
+	return x.step(describeStep("Dial "+name, target, opts), &twiml.Dial{}, opts, func(node any) []string {
+		var got []string
+		for _, child := range node.(*twiml.Dial).Children {
+			if reflect.TypeOf(child) != nounType {
+				continue
+			}
+			value := reflect.ValueOf(child).Elem().FieldByName(field).String()
+			if value == target {
+				return nil
+			}
+			got = append(got, fmt.Sprintf("%q", value))
+		}
+		if len(got) == 0 {
+			return []string{fmt.Sprintf("%s: want %q, dialed no %s", name, target, name)}
+		}
+		return []string{fmt.Sprintf("%s: want %q, got %s", name, target, strings.Join(got, ", "))}
+	})
+}
+
+// DialedNumber expects a <Dial> of a <Number>
+func (x *CallExpectation) DialedNumber(number string, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.dialed(&twiml.Number{}, "Number", number, opts)
+}
+
+// ThenDialedNumber is DialedNumber for a verb after the previous step
+func (x *CallExpectation) ThenDialedNumber(number string, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.DialedNumber(number, opts...)
+}
+
+// DialedClient expects a <Dial> of a <Client>
+func (x *CallExpectation) DialedClient(name string, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.dialed(&twiml.Client{}, "Name", name, opts)
+}
+
+// ThenDialedClient is DialedClient for a verb after the previous step
+func (x *CallExpectation) ThenDialedClient(name string, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.DialedClient(name, opts...)
+}
+
+// DialedSip expects a <Dial> of a <Sip> address
+func (x *CallExpectation) DialedSip(address string, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.dialed(&twiml.Sip{}, "SipAddress", address, opts)
+}
+
+// ThenDialedSip is DialedSip for a verb after the previous step
+func (x *CallExpectation) ThenDialedSip(address string, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.DialedSip(address, opts...)
+}
+
+// DialedQueue expects a <Dial> of a <Queue>
+func (x *CallExpectation) DialedQueue(name string, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.dialed(&twiml.Queue{}, "Name", name, opts)
+}
+
+// ThenDialedQueue is DialedQueue for a verb after the previous step
+func (x *CallExpectation) ThenDialedQueue(name string, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.DialedQueue(name, opts...)
+}
+
+// DialedConference expects a <Dial> of a <Conference>
+func (x *CallExpectation) DialedConference(name string, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.dialed(&twiml.Conference{}, "Name", name, opts)
+}
+
+// ThenDialedConference is DialedConference for a verb after the previous step
+func (x *CallExpectation) ThenDialedConference(name string, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.DialedConference(name, opts...)
+}
+
+// Enqueued expects an <Enqueue> into the named queue
+func (x *CallExpectation) Enqueued(name string, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.step(describeStep("Enqueue", name, opts), &twiml.Enqueue{}, append([]Option{With("Name", name)}, opts...), nil)
+}
+
+// ThenEnqueued is Enqueued for a verb after the previous step
+func (x *CallExpectation) ThenEnqueued(name string, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.Enqueued(name, opts...)
+}
+
+// Recorded expects a <Record>
+func (x *CallExpectation) Recorded(opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.step(describeStep("Record", "", opts), &twiml.Record{}, opts, nil)
+}
+
+// ThenRecorded is Recorded for a verb after the previous step
+func (x *CallExpectation) ThenRecorded(opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.Recorded(opts...)
+}
+
+// Redirected expects a <Redirect> to url
+func (x *CallExpectation) Redirected(url string, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.step(describeStep("Redirect", url, opts), &twiml.Redirect{}, append([]Option{With("URL", url)}, opts...), nil)
+}
+
+// ThenRedirected is Redirected for a verb after the previous step
+func (x *CallExpectation) ThenRedirected(url string, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.Redirected(url, opts...)
+}
+
+// SentMessage expects a <Message> with body
+func (x *CallExpectation) SentMessage(body string, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.step(describeStep("Message", body, opts), &twiml.Message{}, append([]Option{With("Body", body)}, opts...), nil)
+}
+
+// ThenSentMessage is SentMessage for a verb after the previous step
+func (x *CallExpectation) ThenSentMessage(body string, opts ...Option) *CallExpectation {
+	x.t.Helper()
+	return x.SentMessage(body, opts...)
+}
+
+// HungUp expects a <Hangup>
+func (x *CallExpectation) HungUp() *CallExpectation {
+	x.t.Helper()
+	return x.step("Hangup", &twiml.Hangup{}, nil, nil)
+}
+
+// ThenHungUp is HungUp for a verb after the previous step
+func (x *CallExpectation) ThenHungUp() *CallExpectation {
+	x.t.Helper()
+	return x.HungUp()
+}
+
+// Rejected expects a <Reject> with reason, "rejected" or "busy"
+func (x *CallExpectation) Rejected(reason string) *CallExpectation {
+	x.t.Helper()
+	return x.step(describeStep("Reject", reason, nil), &twiml.Reject{}, []Option{With("Reason", reason)}, nil)
+}
+
+// ThenRejected is Rejected for a verb after the previous step
+func (x *CallExpectation) ThenRejected(reason string) *CallExpectation {
+	x.t.Helper()
+	return x.Rejected(reason)
+}
+
+// EndedWith expects the call to have ended with status
+func (x *CallExpectation) EndedWith(status model.CallStatus) *CallExpectation {
+	x.t.Helper()
+	if x.failed {
+		return x
+	}
+	snap, err := x.e.Snapshot(x.accountSID)
+	if err != nil {
+		x.fail("call %s: %v", x.sid, err)
+		return x
+	}
+	call := snap.Calls[x.sid]
+	switch {
+	case call == nil:
+		x.fail("call %s not found", x.sid)
+	case call.EndedAt == nil:
+		x.fail("call %s: expected it to end with %s, still %s", x.sid, status, call.Status)
+	case call.Status != status:
+		x.fail("call %s: expected it to end with %s, ended with %s", x.sid, status, call.Status)
+	}
+	return x
+}
+
+// describe formats a verb with its non-zero attributes
+func describe(node any) string {
+	v := reflect.ValueOf(node)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "<nil>"
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Sprintf("%v", node)
+	}
+	var fields []string
+	for i := range v.NumField() {
+		f := v.Field(i)
+		if !v.Type().Field(i).IsExported() || f.IsZero() {
+			continue
+		}
+		fields = append(fields, fmt.Sprintf("%s: %s", v.Type().Field(i).Name, formatValue(f)))
+	}
+	return v.Type().Name() + "{" + strings.Join(fields, ", ") + "}"
+}
+
+// formatValue formats an attribute value, describing nested verbs and nouns
+func formatValue(v reflect.Value) string {
+	if !v.IsValid() {
+		return "<nil>"
+	}
+	switch {
+	case v.Kind() == reflect.String:
+		return fmt.Sprintf("%q", v.String())
+	case v.Type() == durationType:
+		return v.Interface().(time.Duration).String()
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Interface:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = describe(v.Index(i).Interface())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprintf("%v", v.Interface())
+}
@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package twimtest_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	twilioopenapi "github.com/twilio/twilio-go/rest/api/v2010"
+
+	"github.com/sprucehealth/twimulator/engine"
+	"github.com/sprucehealth/twimulator/httpstub"
+	"github.com/sprucehealth/twimulator/model"
+	"github.com/sprucehealth/twimulator/twimtest"
+)
+
+// recorder captures failures instead of failing the test
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func settle(t *testing.T, e engine.Engine) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := e.Settle(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// runMenuCall places a call through a menu that gathers a digit and dials a queue
+func runMenuCall(t *testing.T) (engine.Engine, *httpstub.MockWebhookClient, model.SID) {
+	mock := httpstub.NewMockWebhookClient()
+	mock.ResponseFunc = func(targetURL string, form url.Values) (int, []byte, http.Header, error) {
+		switch targetURL {
+		case "http://test/answer":
+			return 200, []byte(`<Response>
+  <Say voice="alice">Welcome</Say>
+  <Gather numDigits="1" action="http://test/menu" timeout="600"><Say>Press 1</Say></Gather>
+</Response>`), make(http.Header), nil
+		case "http://test/menu":
+			return 200, []byte(`<Response><Dial timeout="30"><Queue>support</Queue></Dial></Response>`), make(http.Header), nil
+		}
+		return 200, []byte(`<Response></Response>`), make(http.Header), nil
+	}
+	e := engine.NewEngine(engine.WithManualClock(), engine.WithWebhookClient(mock))
+	t.Cleanup(func() { e.Close() })
+
+	account, err := e.CreateAccount((&twilioopenapi.CreateAccountParams{}).SetFriendlyName("twimtest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.CreateIncomingPhoneNumber((&twilioopenapi.CreateIncomingPhoneNumberParams{}).
+		SetPathAccountSid(*account.Sid).
+		SetPhoneNumber("+15550001111")); err != nil {
+		t.Fatal(err)
+	}
+	call, err := e.CreateCall((&twilioopenapi.CreateCallParams{}).
+		SetPathAccountSid(*account.Sid).
+		SetFrom("+15550001111").
+		SetTo("+15552223333").
+		SetUrl("http://test/answer"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	accountSID, callSID := model.SID(*account.Sid), model.SID(*call.Sid)
+
+	settle(t, e)
+	if err := e.AnswerCall(accountSID, callSID); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	if err := e.SendDigits(accountSID, callSID, "1"); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	if err := e.Hangup(accountSID, callSID); err != nil {
+		t.Fatal(err)
+	}
+	settle(t, e)
+	return e, mock, callSID
+}
+
+func TestExpectCall(t *testing.T) {
+	e, _, sid := runMenuCall(t)
+
+	twimtest.ExpectCall(t, e, sid).
+		Said("Welcome", twimtest.WithVoice("alice")).
+		ThenSaid("Press 1").
+		ThenGathered(twimtest.WithNumDigits(1), twimtest.WithTimeout(600)).
+		ThenDialedQueue("support", twimtest.WithTimeout(30)).
+		EndedWith(model.CallCompleted)
+
+	// Steps may skip verbs that are not asserted
+	twimtest.ExpectCall(t, e, sid).
+		Dialed().
+		EndedWith(model.CallCompleted)
+
+	rec := &recorder{TB: t}
+	twimtest.ExpectCall(rec, e, sid).
+		Said("Welcome").
+		ThenGathered(twimtest.WithNumDigits(4)).
+		ThenDialedQueue("support")
+	if len(rec.failures) != 1 {
+		t.Fatalf("expected one failure, got %q", rec.failures)
+	}
+	for _, want := range []string{`expected Gather NumDigits=4 after Say "Welcome"`, "NumDigits: want 4, got 1", `#1 Say{Text: "Press 1"`, "(in Gather)"} {
+		if !strings.Contains(rec.failures[0], want) {
+			t.Errorf("expected the failure to contain %q, got:\n%s", want, rec.failures[0])
+		}
+	}
+
+	rec = &recorder{TB: t}
+	twimtest.ExpectCall(rec, e, sid).
+		DialedQueue("sales").
+		EndedWith(model.CallBusy)
+	if len(rec.failures) != 1 || !strings.Contains(rec.failures[0], `Queue: want "sales", got "support"`) {
+		t.Errorf("expected a queue mismatch, got %q", rec.failures)
+	}
+
+	rec = &recorder{TB: t}
+	twimtest.ExpectCall(rec, e, sid).
+		Gathered().
+		ThenSaid("Press 1")
+	if len(rec.failures) != 1 {
+		t.Errorf("expected nested verbs to come before their Gather, got %q", rec.failures)
+	}
+}
+
+func TestExpectWebhook(t *testing.T) {
+	_, mock, sid := runMenuCall(t)
+
+	form := twimtest.ExpectWebhook(t, mock, "http://test/menu").
+		WithForm("Digits", "1").
+		WithFormValues(url.Values{"CallSid": {string(sid)}}).
+		Times(1).
+		Form()
+	if form.Get("CallStatus") != "in-progress" {
+		t.Errorf("expected the menu request form, got %v", form)
+	}
+
+	rec := &recorder{TB: t}
+	twimtest.ExpectWebhook(rec, mock, "http://test/menu").WithForm("Digits", "2")
+	if len(rec.failures) != 1 || !strings.Contains(rec.failures[0], `Digits: want "2", got "1"`) {
+		t.Errorf("expected a form diff, got %q", rec.failures)
+	}
+
+	rec = &recorder{TB: t}
+	twimtest.ExpectWebhook(rec, mock, "http://test/missing").Times(1)
+	if len(rec.failures) != 1 || !strings.Contains(rec.failures[0], "http://test/answer") {
+		t.Errorf("expected the requested URLs to be listed, got %q", rec.failures)
+	}
+}
@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Copyright (c) 2025 Spruce Health
+
+package twimtest
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/sprucehealth/twimulator/httpstub"
+)
+
+// WebhookExpectation asserts on the requests a MockWebhookClient made to one
+// URL. Each WithForm narrows the requests that later steps look at.
+type WebhookExpectation struct {
+	t        testing.TB
+	url      string
+	requests []httpstub.MockCall
+	filters  []string
+	failed   bool
+}
+
+// ExpectWebhook expects at least one request to url
+func ExpectWebhook(t testing.TB, mock *httpstub.MockWebhookClient, url string) *WebhookExpectation {
+	t.Helper()
+	w := &WebhookExpectation{t: t, url: url, requests: mock.GetCallsTo(url)}
+	if len(w.requests) == 0 {
+		var urls []string
+		for _, call := range mock.Calls {
+			if !slices.Contains(urls, call.URL) {
+				urls = append(urls, call.URL)
+			}
+		}
+		w.fail("no request to %s\nrequested URLs: %s", url, strings.Join(urls, ", "))
+	}
+	return w
+}
+
+func (w *WebhookExpectation) fail(format string, args ...any) {
+	w.t.Helper()
+	w.failed = true
+	w.t.Errorf("twimtest: "+format, args...)
+}
+
+// WithForm expects a request with the form value key=value
+func (w *WebhookExpectation) WithForm(key, value string) *WebhookExpectation {
+	w.t.Helper()
+	return w.WithFormValues(url.Values{key: {value}})
+}
+
+// WithFormValues expects a request whose form has every given value. Keys
+// that are not given are ignored.
+func (w *WebhookExpectation) WithFormValues(want url.Values) *WebhookExpectation {
+	w.t.Helper()
+	if w.failed {
+		return w
+	}
+	keys := make([]string, 0, len(want))
+	for key := range want {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var matched []httpstub.MockCall
+	var diffs []string
+	for i, req := range w.requests {
+		diff := formDiff(want, keys, req.Form)
+		if len(diff) == 0 {
+			matched = append(matched, req)
+			continue
+		}
+		diffs = append(diffs, fmt.Sprintf("request #%d:\n    %s", i, strings.Join(diff, "\n    ")))
+	}
+
+	filter := formatForm(want, keys)
+	if len(matched) == 0 {
+		w.fail("no request to %s%s with %s\n%s", w.url, w.describeFilters(), filter, strings.Join(diffs, "\n"))
+		return w
+	}
+	w.requests = matched
+	w.filters = append(w.filters, filter)
+	return w
+}
+
+// Times expects exactly n matching requests
+func (w *WebhookExpectation) Times(n int) *WebhookExpectation {
+	w.t.Helper()
+	if w.failed {
+		return w
+	}
+	if len(w.requests) != n {
+		w.fail("expected %d requests to %s%s, got %d", n, w.url, w.describeFilters(), len(w.requests))
+	}
+	return w
+}
+
+// Form returns the form of the last matching request, or nil if there is none
+func (w *WebhookExpectation) Form() url.Values {
+	if w.failed || len(w.requests) == 0 {
+		return nil
+	}
+	return w.requests[len(w.requests)-1].Form
+}
+
+// Requests returns the matching requests
+func (w *WebhookExpectation) Requests() []httpstub.MockCall {
+	if w.failed {
+		return nil
+	}
+	return w.requests
+}
+
+func (w *WebhookExpectation) describeFilters() string {
+	if len(w.filters) == 0 {
+		return ""
+	}
+	return " with " + strings.Join(w.filters, " and ")
+}
+
+// formDiff describes the wanted keys whose values differ in got
+func formDiff(want url.Values, keys []string, got url.Values) []string {
+	var diff []string
+	for _, key := range keys {
+		values, ok := got[key]
+		switch {
+		case !ok:
+			diff = append(diff, fmt.Sprintf("%s: want %s, not set", key, quoteValues(want[key])))
+		case !slices.Equal(values, want[key]):
+			diff = append(diff, fmt.Sprintf("%s: want %s, got %s", key, quoteValues(want[key]), quoteValues(values)))
+		}
+	}
+	return diff
+}
+
+func formatForm(form url.Values, keys []string) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key + "=" + quoteValues(form[key])
+	}
+	return strings.Join(parts, " ")
+}
+
+func quoteValues(values []string) string {
+	if len(values) == 1 {
+		return fmt.Sprintf("%q", values[0])
+	}
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}